@@ -0,0 +1,115 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kinds of domain error. Match them with errors.Is to decide how a failure
+// should be reported; anything that matches none of them is an internal error.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("too many requests")
+)
+
+// Error is a domain error carrying its kind and a stable machine-readable code
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  []FieldError
+	// RetryAfter is how long a rate limited caller should wait before retrying
+	RetryAfter time.Duration
+}
+
+// FieldError describes why a single request field was rejected
+type FieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// NotFound reports that the requested resource does not exist
+func NotFound(code, format string, args ...any) error {
+	return newError(ErrNotFound, code, format, args...)
+}
+
+// Conflict reports that the request clashes with the current state of a resource
+func Conflict(code, format string, args ...any) error {
+	return newError(ErrConflict, code, format, args...)
+}
+
+// Validation reports that the request itself is invalid
+func Validation(code, format string, args ...any) error {
+	return newError(ErrValidation, code, format, args...)
+}
+
+// InvalidFields reports that one or more request fields failed validation
+func InvalidFields(fields ...FieldError) error {
+	messages := make([]string, 0, len(fields))
+	for _, f := range fields {
+		messages = append(messages, f.Message)
+	}
+	return &Error{
+		Kind:    ErrValidation,
+		Code:    "validation_failed",
+		Message: strings.Join(messages, "; "),
+		Fields:  fields,
+	}
+}
+
+// Unauthorized reports that the caller's credentials were rejected
+func Unauthorized(code, format string, args ...any) error {
+	return newError(ErrUnauthorized, code, format, args...)
+}
+
+// RateLimited reports that the caller must wait retryAfter before trying again
+func RateLimited(code string, retryAfter time.Duration, format string, args ...any) error {
+	return &Error{Kind: ErrRateLimited, Code: code, Message: fmt.Sprintf(format, args...), RetryAfter: retryAfter}
+}
+
+// Fields returns the field errors of the first domain error in err's chain
+func Fields(err error) []FieldError {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Fields
+	}
+	return nil
+}
+
+// RetryAfter returns the retry delay of the first domain error in err's
+// chain, or zero when there is none
+func RetryAfter(err error) time.Duration {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.RetryAfter
+	}
+	return 0
+}
+
+// Code returns the code of the first domain error in err's chain, or an empty
+// string when there is none
+func Code(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ""
+}
+
+func newError(kind error, code, format string, args ...any) error {
+	return &Error{Kind: kind, Code: code, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,86 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestConstructors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"not found", NotFound("user_not_found", "user not found"), ErrNotFound},
+		{"conflict", Conflict("email_taken", "email %s is already taken", "a@b.c"), ErrConflict},
+		{"validation", Validation("invalid_email", "invalid email format"), ErrValidation},
+		{"unauthorized", Unauthorized("invalid_credentials", "invalid email or password"), ErrUnauthorized},
+		{"rate limited", RateLimited("account_locked", time.Minute, "account is locked"), ErrRateLimited},
+	}
+
+	kinds := []error{ErrNotFound, ErrConflict, ErrValidation, ErrUnauthorized, ErrRateLimited}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, kind := range kinds {
+				if got := errors.Is(tt.err, kind); got != (kind == tt.kind) {
+					t.Errorf("errors.Is(%v, %v) = %v", tt.err, kind, got)
+				}
+			}
+		})
+	}
+}
+
+func TestError_Message(t *testing.T) {
+	err := Conflict("email_taken", "email %s is already taken", "john@example.com")
+	if err.Error() != "email john@example.com is already taken" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
+
+func TestCode(t *testing.T) {
+	err := NotFound("user_not_found", "user not found")
+	wrapped := fmt.Errorf("failed to update user: %w", err)
+
+	if got := Code(wrapped); got != "user_not_found" {
+		t.Errorf("Expected user_not_found, got %q", got)
+	}
+	if !errors.Is(wrapped, ErrNotFound) {
+		t.Error("Expected wrapped error to match ErrNotFound")
+	}
+	if got := Code(errors.New("connection refused")); got != "" {
+		t.Errorf("Expected no code for plain error, got %q", got)
+	}
+}
+
+func TestInvalidFields(t *testing.T) {
+	err := InvalidFields(
+		FieldError{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		FieldError{Field: "password", Rule: "min", Message: "password must be at least 8 characters"},
+	)
+
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Expected ErrValidation, got %v", err)
+	}
+	if Code(err) != "validation_failed" {
+		t.Errorf("Expected validation_failed, got %q", Code(err))
+	}
+	if err.Error() != "email must be a valid email address; password must be at least 8 characters" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+	if fields := Fields(fmt.Errorf("wrapped: %w", err)); len(fields) != 2 || fields[1].Field != "password" {
+		t.Errorf("Expected both field errors through wrapping, got %v", fields)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := fmt.Errorf("login failed: %w", RateLimited("account_locked", 90*time.Second, "account is locked"))
+
+	if got := RetryAfter(err); got != 90*time.Second {
+		t.Errorf("Expected 1m30s, got %v", got)
+	}
+	if got := RetryAfter(NotFound("user_not_found", "user not found")); got != 0 {
+		t.Errorf("Expected no retry delay, got %v", got)
+	}
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID       uuid.UUID
+	AccessLevels []string
+	TokenID      string
+	SessionID    string
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal stored in ctx, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the IP address of the client
+// that sent the request
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFromContext returns the client IP address stored in ctx, if any
+func ClientIPFromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPKey{}).(string)
+	return ip, ok && ip != ""
+}
+
+type userAgentKey struct{}
+
+// WithUserAgent returns a copy of ctx carrying the User-Agent of the request
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentKey{}, userAgent)
+}
+
+// UserAgentFromContext returns the User-Agent stored in ctx, if any
+func UserAgentFromContext(ctx context.Context) (string, bool) {
+	userAgent, ok := ctx.Value(userAgentKey{}).(string)
+	return userAgent, ok && userAgent != ""
+}
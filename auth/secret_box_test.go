@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSecretBox(t *testing.T) {
+	box, err := NewSecretBox([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("Failed to create secret box: %v", err)
+	}
+
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Failed to seal: %v", err)
+	}
+	if sealed == "JBSWY3DPEHPK3PXP" {
+		t.Error("Expected the sealed value to differ from the plaintext")
+	}
+	again, _ := box.Seal("JBSWY3DPEHPK3PXP")
+	if again == sealed {
+		t.Error("Expected every seal to use a new nonce")
+	}
+
+	opened, err := box.Open(sealed)
+	if err != nil || opened != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Expected the plaintext back, got %q (%v)", opened, err)
+	}
+
+	other, _ := NewSecretBox([]byte("other-secret"))
+	if _, err := other.Open(sealed); !errors.Is(err, ErrSealedValueInvalid) {
+		t.Errorf("Expected ErrSealedValueInvalid for another key, got %v", err)
+	}
+	for _, value := range []string{"", "not base64!", "c2hvcnQ", sealed[:len(sealed)-2]} {
+		if _, err := box.Open(value); !errors.Is(err, ErrSealedValueInvalid) {
+			t.Errorf("Expected ErrSealedValueInvalid for %q, got %v", value, err)
+		}
+	}
+
+	if _, err := NewSecretBox(nil); err == nil {
+		t.Error("Expected an error for an empty key")
+	}
+}
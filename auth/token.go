@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	// TokenType is the token_type reported to clients alongside issued access tokens
+	TokenType = "Bearer"
+
+	defaultAccessTokenTTL = 15 * time.Minute
+)
+
+// Claims are the JWT claims carried by an access token
+type Claims struct {
+	AccessLevels []string `json:"access_levels,omitempty"`
+	// SessionID names the server-side session the token was issued for, if any
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the subject of the token parsed as a user ID
+func (c *Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+// TokenConfig describes how access tokens are signed and validated
+type TokenConfig struct {
+	Algorithm      string
+	Secret         string
+	PrivateKeyPath string
+	KeyID          string
+	Issuer         string
+	Audience       string
+	AccessTokenTTL time.Duration
+}
+
+// TokenManager issues and verifies signed access tokens
+type TokenManager struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	keyID     string
+	issuer    string
+	audience  string
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+// NewTokenManager loads the signing key described by cfg
+func NewTokenManager(cfg TokenConfig) (*TokenManager, error) {
+	m := &TokenManager{
+		keyID:    cfg.KeyID,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      cfg.AccessTokenTTL,
+		now:      time.Now,
+	}
+	if m.ttl <= 0 {
+		m.ttl = defaultAccessTokenTTL
+	}
+
+	switch cfg.Algorithm {
+	case AlgorithmHS256, "":
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("HS256 signing requires a secret")
+		}
+		m.method = jwt.SigningMethodHS256
+		m.signKey = []byte(cfg.Secret)
+		m.verifyKey = []byte(cfg.Secret)
+	case AlgorithmRS256:
+		pemBytes, err := readKeyFile(cfg.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse RSA private key: %w", err)
+		}
+		m.method = jwt.SigningMethodRS256
+		m.signKey = key
+		m.verifyKey = &key.PublicKey
+	case AlgorithmEdDSA:
+		pemBytes, err := readKeyFile(cfg.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		key, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Ed25519 private key: %w", err)
+		}
+		edKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is not an Ed25519 key")
+		}
+		m.method = jwt.SigningMethodEdDSA
+		m.signKey = edKey
+		m.verifyKey = edKey.Public()
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", cfg.Algorithm)
+	}
+
+	return m, nil
+}
+
+// IssueAccessToken signs a token for the given user and returns it with its
+// lifetime. sessionID binds the token to a server-side session; it may be empty.
+func (m *TokenManager) IssueAccessToken(userID uuid.UUID, accessLevels []string, sessionID string) (string, time.Duration, error) {
+	now := m.now()
+	claims := &Claims{
+		AccessLevels: accessLevels,
+		SessionID:    sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID.String(),
+			Issuer:    m.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
+		},
+	}
+	if m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+
+	token := jwt.NewWithClaims(m.method, claims)
+	if m.keyID != "" {
+		token.Header["kid"] = m.keyID
+	}
+
+	signed, err := token.SignedString(m.signKey)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return signed, m.ttl, nil
+}
+
+// VerifyAccessToken checks the signature and standard claims of a token
+func (m *TokenManager) VerifyAccessToken(tokenString string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{m.method.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(m.now),
+	}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+	if m.audience != "" {
+		opts = append(opts, jwt.WithAudience(m.audience))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return m.verifyKey, nil
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %w", err)
+	}
+	return claims, nil
+}
+
+func readKeyFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, fmt.Errorf("private key path is required")
+	}
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	return pemBytes, nil
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	return path
+}
+
+func TestTokenManager_IssueAndVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal Ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  func(t *testing.T) TokenConfig
+	}{
+		{
+			name: "HS256",
+			cfg: func(t *testing.T) TokenConfig {
+				return TokenConfig{Algorithm: AlgorithmHS256, Secret: "test-secret"}
+			},
+		},
+		{
+			name: "RS256",
+			cfg: func(t *testing.T) TokenConfig {
+				path := writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+				return TokenConfig{Algorithm: AlgorithmRS256, PrivateKeyPath: path, KeyID: "rsa-1"}
+			},
+		},
+		{
+			name: "EdDSA",
+			cfg: func(t *testing.T) TokenConfig {
+				path := writePEM(t, "PRIVATE KEY", edDER)
+				return TokenConfig{Algorithm: AlgorithmEdDSA, PrivateKeyPath: path}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg(t)
+			cfg.Issuer = "user_service"
+			cfg.Audience = "internal"
+			cfg.AccessTokenTTL = 5 * time.Minute
+
+			manager, err := NewTokenManager(cfg)
+			if err != nil {
+				t.Fatalf("Failed to create token manager: %v", err)
+			}
+
+			userID := uuid.New()
+			token, expiresIn, err := manager.IssueAccessToken(userID, []string{"admin"}, "session-1")
+			if err != nil {
+				t.Fatalf("Failed to issue token: %v", err)
+			}
+			if expiresIn != 5*time.Minute {
+				t.Errorf("Expected lifetime 5m, got %v", expiresIn)
+			}
+
+			claims, err := manager.VerifyAccessToken(token)
+			if err != nil {
+				t.Fatalf("Failed to verify token: %v", err)
+			}
+			gotID, err := claims.UserID()
+			if err != nil || gotID != userID {
+				t.Errorf("Expected user ID %s, got %s (%v)", userID, gotID, err)
+			}
+			if len(claims.AccessLevels) != 1 || claims.AccessLevels[0] != "admin" {
+				t.Errorf("Expected access levels [admin], got %v", claims.AccessLevels)
+			}
+			if claims.SessionID != "session-1" {
+				t.Errorf("Expected session ID session-1, got %q", claims.SessionID)
+			}
+		})
+	}
+}
+
+func TestTokenManager_VerifyRejectsInvalidTokens(t *testing.T) {
+	manager, err := NewTokenManager(TokenConfig{Secret: "test-secret", Issuer: "user_service"})
+	if err != nil {
+		t.Fatalf("Failed to create token manager: %v", err)
+	}
+	token, _, err := manager.IssueAccessToken(uuid.New(), nil, "")
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	t.Run("Wrong secret", func(t *testing.T) {
+		other, _ := NewTokenManager(TokenConfig{Secret: "other-secret", Issuer: "user_service"})
+		if _, err := other.VerifyAccessToken(token); err == nil {
+			t.Error("Expected error for token signed with another secret")
+		}
+	})
+
+	t.Run("Wrong issuer", func(t *testing.T) {
+		other, _ := NewTokenManager(TokenConfig{Secret: "test-secret", Issuer: "someone-else"})
+		if _, err := other.VerifyAccessToken(token); err == nil {
+			t.Error("Expected error for token from another issuer")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		later := *manager
+		later.now = func() time.Time { return time.Now().Add(time.Hour) }
+		if _, err := later.VerifyAccessToken(token); err == nil {
+			t.Error("Expected error for expired token")
+		}
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		if _, err := manager.VerifyAccessToken("not-a-token"); err == nil {
+			t.Error("Expected error for malformed token")
+		}
+	})
+}
+
+func TestNewTokenManager_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      TokenConfig
+		errMatch string
+	}{
+		{"missing secret", TokenConfig{Algorithm: AlgorithmHS256}, "requires a secret"},
+		{"missing key path", TokenConfig{Algorithm: AlgorithmRS256}, "private key path is required"},
+		{"missing key file", TokenConfig{Algorithm: AlgorithmEdDSA, PrivateKeyPath: "/nonexistent.pem"}, "failed to read private key"},
+		{"unsupported algorithm", TokenConfig{Algorithm: "none"}, "unsupported signing algorithm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTokenManager(tt.cfg)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.errMatch) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMatch, err)
+			}
+		})
+	}
+}
@@ -2,13 +2,19 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/wabtcdi/user_service/auth"
 	"github.com/wabtcdi/user_service/cmd/health"
 	"github.com/wabtcdi/user_service/cmd/log"
 	"github.com/wabtcdi/user_service/handlers"
+	"github.com/wabtcdi/user_service/notify"
+	"github.com/wabtcdi/user_service/pagination"
+	"github.com/wabtcdi/user_service/password"
 	"github.com/wabtcdi/user_service/repository"
 	"github.com/wabtcdi/user_service/service"
 
@@ -18,49 +24,74 @@ import (
 	"gorm.io/gorm"
 )
 
+// Access levels referenced by the route authorization rules
+const (
+	accessLevelAdmin       = "admin"
+	accessLevelUserManager = "user-manager"
+
+	permissionUsersRead          = "users:read"
+	permissionUsersWrite         = "users:write"
+	permissionUsersDelete        = "users:delete"
+	permissionAccessLevelsManage = "access-levels:manage"
+)
+
+// Per-IP login and email requests allowed when auth.loginRateLimit is unset
+const (
+	defaultLoginRateLimit       = 20
+	defaultLoginRateLimitWindow = time.Minute
+)
+
 type Pinger interface {
 	Ping() error
 }
 
 type DBOpener func(dsn string) (*gorm.DB, error)
 
+// ServerStarter serves HTTP until ctx is cancelled and then shuts the server
+// down, giving in-flight requests up to shutdownTimeout to complete
 type ServerStarter interface {
-	Start(addr string, handler http.Handler) error
+	Start(ctx context.Context, server *http.Server, shutdownTimeout time.Duration) error
 }
 
 type RealStarter struct{}
 
-func (r *RealStarter) Start(addr string, handler http.Handler) error {
-	server := &http.Server{
-		Addr:    addr,
-		Handler: handler,
-	}
-
+func (r *RealStarter) Start(ctx context.Context, server *http.Server, shutdownTimeout time.Duration) error {
 	errChan := make(chan error, 1)
 
 	// Start server in goroutine
 	go func() {
+		if server.TLSConfig != nil {
+			// The certificate comes from TLSConfig.GetCertificate
+			errChan <- server.ListenAndServeTLS("", "")
+			return
+		}
 		errChan <- server.ListenAndServe()
 	}()
 
-	// Create timeout context
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Wait for either server error or timeout
+	// Serve until the server fails or shutdown is requested
 	select {
 	case err := <-errChan:
 		return err
 	case <-ctx.Done():
-		// Timeout occurred, shutdown server gracefully
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer shutdownCancel()
-		server.Shutdown(shutdownCtx)
-		return fmt.Errorf("server start timeout after 10 seconds")
 	}
+
+	logrus.Infof("Shutting down server, waiting up to %v for in-flight requests", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("failed to drain in-flight requests: %w", err)
+	}
+
+	// ListenAndServe returns http.ErrServerClosed once Shutdown has begun
+	<-errChan
+	logrus.Info("Server stopped")
+	return nil
 }
 
-func Init(configName string, opener DBOpener, starter ServerStarter) error {
+// Init loads the configuration, connects to the database and serves requests
+// until ctx is cancelled, after which it drains the server and closes the database
+func Init(ctx context.Context, configName string, opener DBOpener, starter ServerStarter) error {
 	path := "../resources/" + configName + ".yaml"
 	cfg, err := loadConfiguration(path)
 	if err != nil {
@@ -71,7 +102,13 @@ func Init(configName string, opener DBOpener, starter ServerStarter) error {
 	if err != nil {
 		return err
 	}
-	err = startServer(cfg, db, starter)
+	defer closeDatabase(db)
+
+	if err := bootstrapAdmin(ctx, cfg, db); err != nil {
+		return fmt.Errorf("failed to bootstrap admin: %w", err)
+	}
+
+	err = startServer(ctx, cfg, db, starter)
 	if err != nil {
 		return err
 	}
@@ -138,51 +175,341 @@ func connectDatabase(cfg Config, opener DBOpener) (*gorm.DB, error) {
 	return db, nil
 }
 
-func startServer(cfg Config, db *gorm.DB, starter ServerStarter) error {
-	r := createRouter(cfg, db)
-	addr := getAddr(cfg)
-	logrus.Infof("Starting server on %s", addr)
-	return starter.Start(addr, r)
+func startServer(ctx context.Context, cfg Config, db *gorm.DB, starter ServerStarter) error {
+	// Readiness fails as soon as shutdown is requested, while the server keeps
+	// serving for the drain delay so load balancers can stop routing to it
+	r, err := createRouter(cfg, db, ctx.Done())
+	if err != nil {
+		return err
+	}
+	serveCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		if cfg.Server.DrainDelay > 0 {
+			logrus.Infof("Shutdown requested, draining for %v before closing listeners", cfg.Server.DrainDelay)
+		}
+		time.AfterFunc(cfg.Server.DrainDelay, cancel)
+	})
+	defer stop()
+
+	server, err := newHTTPServer(serveCtx, cfg, r)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Starting server on %s", server.Addr)
+	return starter.Start(serveCtx, server, getShutdownTimeout(cfg))
+}
+
+func closeDatabase(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		logrus.Errorf("Failed to get underlying sql.DB: %v", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		logrus.Errorf("Failed to close database: %v", err)
+		return
+	}
+	logrus.Info("Database connections closed")
 }
 
-func createRouter(cfg Config, db *gorm.DB) *mux.Router {
+func createRouter(cfg Config, db *gorm.DB, draining <-chan struct{}) (*mux.Router, error) {
 	r := mux.NewRouter()
 
+	tokenManager, err := newTokenManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure token signing: %w", err)
+	}
+
+	passwordPolicy, err := newPasswordPolicy(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure password policy: %w", err)
+	}
+
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure notifications: %w", err)
+	}
+
+	cursorCodec, err := newCursorCodec(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure pagination: %w", err)
+	}
+
+	mfaSecrets, err := newMFASecretBox(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure MFA: %w", err)
+	}
+
+	sessionRepo, err := newSessionRepository(cfg, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure sessions: %w", err)
+	}
+
 	// Health checks
-	checker := &health.Checker{DB: db}
+	checker := &health.Checker{DB: db, Draining: draining}
 	r.HandleFunc(cfg.Server.LivenessPath, livenessHandler).Methods("GET")
 	r.HandleFunc(cfg.Server.ReadinessPath, checker.Check).Methods("GET")
 
 	// Initialize repositories
 	userRepo := repository.NewPostgresUserRepository(db)
 	accessLevelRepo := repository.NewPostgresAccessLevelRepository(db)
+	refreshTokenRepo := repository.NewPostgresRefreshTokenRepository(db)
+	permissionRepo := repository.NewPostgresPermissionRepository(db)
+	passwordResetRepo := repository.NewPostgresPasswordResetTokenRepository(db)
+	auditEventRepo := repository.NewPostgresAuditEventRepository(db)
+	mfaRepo := repository.NewPostgresMFARepository(db)
+	mfaChallengeRepo := repository.NewPostgresMFAChallengeRepository(db)
+	emailVerificationRepo := repository.NewPostgresEmailVerificationTokenRepository(db)
 
 	// Initialize services
-	userService := service.NewUserService(userRepo, accessLevelRepo)
-	accessLevelService := service.NewAccessLevelService(accessLevelRepo)
+	userOptions := []service.UserServiceOption{
+		service.WithTokenIssuer(tokenManager),
+		service.WithRefreshTokens(refreshTokenRepo, cfg.Auth.RefreshTokenTTL),
+		service.WithPermissions(permissionRepo),
+		service.WithPasswordReset(passwordResetRepo, notifier, cfg.Auth.PasswordReset.URL, cfg.Auth.PasswordReset.TokenTTL),
+		service.WithEmailVerification(emailVerificationRepo, notifier, cfg.Auth.EmailVerification.URL,
+			cfg.Auth.EmailVerification.TokenTTL, cfg.Auth.EmailVerification.Required),
+		service.WithPasswordPolicy(passwordPolicy),
+		service.WithLockout(service.LockoutPolicy{
+			MaxAttempts: cfg.Auth.Lockout.MaxAttempts,
+			Duration:    cfg.Auth.Lockout.Duration,
+			BaseDelay:   cfg.Auth.Lockout.BaseDelay,
+		}, auditEventRepo),
+		service.WithMFA(mfaRepo, mfaChallengeRepo, mfaSecrets, cfg.Auth.MFA.Issuer, cfg.Auth.MFA.ChallengeTTL),
+		service.WithRequiredMFA(cfg.Auth.MFA.RequiredAccessLevels...),
+		service.WithCursorCodec(cursorCodec),
+		service.WithUnitOfWork(repository.NewPostgresUnitOfWork(db)),
+	}
+	if sessionRepo != nil {
+		userOptions = append(userOptions, service.WithSessions(sessionRepo,
+			cfg.Auth.Sessions.IdleTimeout, cfg.Auth.Sessions.AbsoluteTimeout))
+	}
+	userService := service.NewUserService(userRepo, accessLevelRepo, userOptions...)
+	accessLevelService := service.NewAccessLevelService(accessLevelRepo, permissionRepo,
+		service.WithAccessLevelUnitOfWork(repository.NewPostgresUnitOfWork(db)),
+		service.WithProtectedAccessLevels(accessLevelAdmin, accessLevelUserManager))
 
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService)
 	accessLevelHandler := handlers.NewAccessLevelHandler(accessLevelService)
 
+	// Client IPs feed the rate limits and audit events
+	r.Use(handlers.NewClientIPResolver(cfg.Server.TrustForwardedFor).Resolve)
+	loginLimiter := handlers.NewRateLimiter(
+		orDefault(cfg.Auth.LoginRateLimit.Requests, defaultLoginRateLimit),
+		orDefault(cfg.Auth.LoginRateLimit.Window, defaultLoginRateLimitWindow),
+	)
+	// Routes that send email get the same limit, counted separately so they
+	// cannot use up a client's login attempts
+	emailLimiter := handlers.NewRateLimiter(
+		orDefault(cfg.Auth.LoginRateLimit.Requests, defaultLoginRateLimit),
+		orDefault(cfg.Auth.LoginRateLimit.Window, defaultLoginRateLimitWindow),
+	)
+
+	// Every route requires a bearer token unless listed here
+	authMiddleware := handlers.NewAuthMiddleware(tokenManager,
+		cfg.Server.LivenessPath,
+		cfg.Server.ReadinessPath,
+		"/auth/login",
+		"/auth/mfa/verify",
+		"/auth/refresh",
+		"/auth/password-reset/request",
+		"/auth/password-reset/confirm",
+		"/auth/verify-email",
+		"/auth/verify-email/resend",
+	)
+	r.Use(authMiddleware.Authenticate)
+	if sessionRepo != nil {
+		r.Use(handlers.NewSessionMiddleware(userService).Check)
+	}
+
+	// Access levels grant routes wholesale; permissions let narrower levels
+	// reach individual operations
+	authz := handlers.NewAuthorizer(userRepo, accessLevelRepo, permissionRepo)
+	userManagers := handlers.RequireAccessLevel(accessLevelAdmin, accessLevelUserManager)
+	adminOnly := handlers.RequireAccessLevel(accessLevelAdmin)
+	readUsers := handlers.AnyOf(userManagers, handlers.RequirePermission(permissionUsersRead))
+	// Changing another account also requires holding all of its access levels,
+	// so user managers cannot take over admin accounts
+	outranksUser := handlers.AnyOf(adminOnly, authz.HoldsLevelsOf("id"))
+	writeUsers := handlers.AnyOf(userManagers, handlers.RequirePermission(permissionUsersWrite))
+	editUsers := handlers.AllOf(writeUsers, outranksUser)
+	deleteUsers := handlers.AllOf(handlers.AnyOf(userManagers, handlers.RequirePermission(permissionUsersDelete)), outranksUser)
+	selfOrReadUsers := handlers.SelfOr("id", readUsers)
+	selfOrEditUsers := handlers.SelfOr("id", editUsers)
+	manageAccessLevels := handlers.AnyOf(adminOnly, handlers.RequirePermission(permissionAccessLevelsManage))
+
 	// User routes
-	r.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
-	r.HandleFunc("/users", userHandler.ListUsers).Methods("GET")
-	r.HandleFunc("/users/{id}", userHandler.GetUser).Methods("GET")
-	r.HandleFunc("/users/{id}", userHandler.UpdateUser).Methods("PUT")
-	r.HandleFunc("/users/{id}", userHandler.DeleteUser).Methods("DELETE")
-	r.HandleFunc("/users/{id}/access-levels", userHandler.AssignAccessLevels).Methods("POST")
-	r.HandleFunc("/users/{id}/access-levels", userHandler.GetUserAccessLevels).Methods("GET")
+	r.HandleFunc("/users", authz.Require(writeUsers, userHandler.CreateUser)).Methods("POST")
+	r.HandleFunc("/users", authz.Require(readUsers, userHandler.ListUsers)).Methods("GET")
+	r.HandleFunc("/users/search", authz.Require(readUsers, userHandler.SearchUsers)).Methods("GET")
+	r.HandleFunc("/users/{id}", authz.Require(selfOrReadUsers, userHandler.GetUser)).Methods("GET")
+	r.HandleFunc("/users/{id}", authz.Require(selfOrEditUsers, userHandler.UpdateUser)).Methods("PUT")
+	r.HandleFunc("/users/{id}", authz.Require(deleteUsers, userHandler.DeleteUser)).Methods("DELETE")
+	r.HandleFunc("/users/{id}/password", authz.Require(selfOrEditUsers, userHandler.ChangePassword)).Methods("PUT")
+	r.HandleFunc("/users/{id}/restore", authz.Require(deleteUsers, userHandler.RestoreUser)).Methods("POST")
+	r.HandleFunc("/users/{id}/unlock", authz.Require(editUsers, userHandler.UnlockUser)).Methods("POST")
+	r.HandleFunc("/users/{id}/mfa/totp", authz.Require(handlers.Self("id"), userHandler.EnrollTOTP)).Methods("POST")
+	r.HandleFunc("/users/{id}/mfa/totp/activate", authz.Require(handlers.Self("id"), userHandler.ActivateTOTP)).Methods("POST")
+	r.HandleFunc("/users/{id}/mfa/totp/disable", authz.Require(handlers.Self("id"), userHandler.DisableTOTP)).Methods("POST")
+	r.HandleFunc("/users/{id}/mfa/recovery-codes", authz.Require(handlers.Self("id"), userHandler.RegenerateRecoveryCodes)).Methods("POST")
+	r.HandleFunc("/users/{id}/mfa/reset", authz.Require(adminOnly, userHandler.ResetMFA)).Methods("POST")
+	r.HandleFunc("/users/{id}/access-levels", authz.Require(adminOnly, userHandler.AssignAccessLevels)).Methods("POST")
+	r.HandleFunc("/users/{id}/access-levels", authz.Require(adminOnly, userHandler.ReplaceAccessLevels)).Methods("PUT")
+	r.HandleFunc("/users/{id}/access-levels/{levelId}", authz.Require(adminOnly, userHandler.RemoveAccessLevel)).Methods("DELETE")
+	r.HandleFunc("/users/{id}/access-levels", authz.Require(selfOrReadUsers, userHandler.GetUserAccessLevels)).Methods("GET")
+	r.HandleFunc("/users/{id}/access-levels/effective", authz.Require(selfOrReadUsers, userHandler.GetUserEffectiveAccessLevels)).Methods("GET")
+	if sessionRepo != nil {
+		r.HandleFunc("/users/{id}/sessions", authz.Require(selfOrReadUsers, userHandler.ListSessions)).Methods("GET")
+		r.HandleFunc("/users/{id}/sessions", authz.Require(selfOrEditUsers, userHandler.RevokeAllSessions)).Methods("DELETE")
+		r.HandleFunc("/users/{id}/sessions/{sid}", authz.Require(selfOrEditUsers, userHandler.RevokeSession)).Methods("DELETE")
+	}
 
 	// Authentication routes
-	r.HandleFunc("/auth/login", userHandler.Login).Methods("POST")
-
-	// Access level routes
-	r.HandleFunc("/access-levels", accessLevelHandler.CreateAccessLevel).Methods("POST")
+	r.HandleFunc("/auth/login", loginLimiter.Limit(userHandler.Login)).Methods("POST")
+	r.HandleFunc("/auth/mfa/verify", loginLimiter.Limit(userHandler.VerifyMFA)).Methods("POST")
+	r.HandleFunc("/auth/refresh", userHandler.RefreshToken).Methods("POST")
+	r.HandleFunc("/auth/password-reset/request", emailLimiter.Limit(userHandler.RequestPasswordReset)).Methods("POST")
+	r.HandleFunc("/auth/password-reset/confirm", userHandler.ConfirmPasswordReset).Methods("POST")
+	r.HandleFunc("/auth/verify-email", userHandler.VerifyEmail).Methods("POST")
+	r.HandleFunc("/auth/verify-email/resend", emailLimiter.Limit(userHandler.ResendVerificationEmail)).Methods("POST")
+
+	// Access level routes. Granting permissions or parents could lift a level
+	// above what its manager holds, so only admins may do either.
+	r.HandleFunc("/access-levels", authz.Require(adminOnly, accessLevelHandler.CreateAccessLevel)).Methods("POST")
 	r.HandleFunc("/access-levels", accessLevelHandler.ListAccessLevels).Methods("GET")
 	r.HandleFunc("/access-levels/{id}", accessLevelHandler.GetAccessLevel).Methods("GET")
+	r.HandleFunc("/access-levels/{id}", authz.Require(manageAccessLevels, accessLevelHandler.UpdateAccessLevel)).Methods("PUT")
+	r.HandleFunc("/access-levels/{id}", authz.Require(manageAccessLevels, accessLevelHandler.UpdateAccessLevel)).Methods("PATCH")
+	r.HandleFunc("/access-levels/{id}", authz.Require(manageAccessLevels, accessLevelHandler.DeleteAccessLevel)).Methods("DELETE")
+	r.HandleFunc("/access-levels/{id}/parents", authz.Require(adminOnly, accessLevelHandler.SetAccessLevelParents)).Methods("PUT")
+	r.HandleFunc("/access-levels/{id}/permissions", accessLevelHandler.GetAccessLevelPermissions).Methods("GET")
+	r.HandleFunc("/access-levels/{id}/permissions", authz.Require(adminOnly, accessLevelHandler.AssignPermissions)).Methods("POST")
+	r.HandleFunc("/access-levels/{id}/permissions/{permissionId}", authz.Require(manageAccessLevels, accessLevelHandler.RemovePermission)).Methods("DELETE")
+
+	// Permission routes
+	r.HandleFunc("/permissions", accessLevelHandler.ListPermissions).Methods("GET")
+
+	return r, nil
+}
+
+func newTokenManager(cfg Config) (*auth.TokenManager, error) {
+	tokenCfg := auth.TokenConfig{
+		Algorithm:      cfg.Auth.Algorithm,
+		Secret:         cfg.Auth.Secret,
+		PrivateKeyPath: cfg.Auth.PrivateKeyPath,
+		KeyID:          cfg.Auth.KeyID,
+		Issuer:         cfg.Auth.Issuer,
+		Audience:       cfg.Auth.Audience,
+		AccessTokenTTL: cfg.Auth.AccessTokenTTL,
+	}
 
-	return r
+	// Fall back to a per-process secret only when the configuration allows it;
+	// tokens issued this way do not survive a restart or validate on other replicas
+	if (tokenCfg.Algorithm == "" || tokenCfg.Algorithm == auth.AlgorithmHS256) && tokenCfg.Secret == "" {
+		if !cfg.Auth.AllowEphemeralSecret {
+			return nil, fmt.Errorf("auth.secret is required for HS256 unless auth.allowEphemeralSecret is set")
+		}
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate signing secret: %w", err)
+		}
+		tokenCfg.Secret = base64.RawURLEncoding.EncodeToString(secret)
+		logrus.Warn("No auth secret configured, using an ephemeral HS256 signing secret")
+	}
+
+	return auth.NewTokenManager(tokenCfg)
+}
+
+// newPasswordPolicy builds the password policy from the configuration. Unset
+// lengths keep their defaults.
+func newPasswordPolicy(cfg Config) (password.Policy, error) {
+	policyCfg := cfg.Auth.PasswordPolicy
+	policy := password.DefaultPolicy()
+	policy.MinLength = orDefault(policyCfg.MinLength, policy.MinLength)
+	policy.MaxLength = orDefault(policyCfg.MaxLength, policy.MaxLength)
+	policy.RequireUppercase = policyCfg.RequireUppercase
+	policy.RequireLowercase = policyCfg.RequireLowercase
+	policy.RequireDigit = policyCfg.RequireDigit
+	policy.RequireSymbol = policyCfg.RequireSymbol
+	policy.DisallowPersonalInfo = policyCfg.DisallowPersonalInfo
+	policy.HistorySize = policyCfg.HistorySize
+
+	if policy.MaxLength > password.BcryptMaxBytes {
+		return password.Policy{}, fmt.Errorf("maxLength must not exceed %d", password.BcryptMaxBytes)
+	}
+	if policy.MinLength > policy.MaxLength {
+		return password.Policy{}, fmt.Errorf("minLength %d exceeds maxLength %d", policy.MinLength, policy.MaxLength)
+	}
+
+	if policyCfg.BreachedPasswordsFile != "" {
+		breached, err := password.NewBreachedPasswordFile(policyCfg.BreachedPasswordsFile)
+		if err != nil {
+			return password.Policy{}, err
+		}
+		policy.Breached = breached
+		logrus.Infof("Checking new passwords against %s", policyCfg.BreachedPasswordsFile)
+	}
+	return policy, nil
+}
+
+// newNotifier builds the notifier that delivers messages such as password
+// reset links. Without a configured notifier messages are written to the log.
+func newNotifier(cfg Config) (notify.Notifier, error) {
+	switch cfg.Notifications.Notifier {
+	case "", "log":
+		return notify.NewLogNotifier(), nil
+	case "file":
+		if cfg.Notifications.FilePath == "" {
+			return nil, fmt.Errorf("file notifier requires notifications.filePath")
+		}
+		return notify.NewFileNotifier(cfg.Notifications.FilePath), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", cfg.Notifications.Notifier)
+	}
+}
+
+// newSessionRepository returns the configured session store, or nil when
+// sessions are disabled. The memory store is lost on restart and not shared
+// between instances, so it only suits tests and single-instance deployments.
+func newSessionRepository(cfg Config, db *gorm.DB) (repository.SessionRepository, error) {
+	if !cfg.Auth.Sessions.Enabled {
+		return nil, nil
+	}
+	switch cfg.Auth.Sessions.Store {
+	case "", "database":
+		return repository.NewPostgresSessionRepository(db), nil
+	case "memory":
+		return repository.NewMemorySessionRepository(), nil
+	default:
+		return nil, fmt.Errorf("unknown session store %q", cfg.Auth.Sessions.Store)
+	}
+}
+
+// newCursorCodec returns the codec signing user listing cursors. Without a
+// configured secret it fails unless pagination.allowEphemeralSecret is set, in
+// which case cursors are signed with a per-process key and do not survive a
+// restart or work across replicas.
+func newCursorCodec(cfg Config) (*pagination.Codec, error) {
+	if cfg.Pagination.CursorSecret == "" {
+		if !cfg.Pagination.AllowEphemeralSecret {
+			return nil, fmt.Errorf("pagination.cursorSecret is required unless pagination.allowEphemeralSecret is set")
+		}
+		logrus.Warn("No pagination cursor secret configured, using an ephemeral signing key")
+		return pagination.NewRandomCodec(), nil
+	}
+	return pagination.NewCodec([]byte(cfg.Pagination.CursorSecret)), nil
+}
+
+// newMFASecretBox returns the box TOTP secrets are sealed with. The key has
+// to stay the same across restarts and replicas, or enrolled authenticators
+// stop working.
+func newMFASecretBox(cfg Config) (*auth.SecretBox, error) {
+	if cfg.Auth.MFA.SecretKey == "" {
+		return nil, fmt.Errorf("auth.mfa.secretKey is required")
+	}
+	return auth.NewSecretBox([]byte(cfg.Auth.MFA.SecretKey))
 }
 
 func getAddr(cfg Config) string {
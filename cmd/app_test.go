@@ -1,20 +1,37 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/wabtcdi/user_service/models"
+	"github.com/wabtcdi/user_service/password"
+	"github.com/wabtcdi/user_service/repository"
 	"gorm.io/gorm"
 )
 
+// testConfig returns an empty configuration with the secrets startup requires
+func testConfig() Config {
+	cfg := Config{}
+	cfg.Auth.Secret = "test-secret"
+	cfg.Pagination.CursorSecret = "test-cursor-secret"
+	cfg.Auth.MFA.SecretKey = "test-mfa-secret"
+	return cfg
+}
+
 func TestLivenessHandler(t *testing.T) {
 	req, err := http.NewRequest("GET", "/health", nil)
 	if err != nil {
@@ -87,7 +104,7 @@ func TestConnectDatabase_Success(t *testing.T) {
 	// Expect ping to succeed
 	mock.ExpectPing()
 
-	cfg := Config{}
+	cfg := testConfig()
 	cfg.Database.Host = "localhost"
 	cfg.Database.Port = 5432
 	cfg.Database.User = "testuser"
@@ -122,7 +139,7 @@ func TestConnectDatabase_Success(t *testing.T) {
 }
 
 func TestConnectDatabase_OpenerError(t *testing.T) {
-	cfg := Config{}
+	cfg := testConfig()
 	cfg.Database.Host = "localhost"
 	cfg.Database.Port = 5432
 	cfg.Database.User = "testuser"
@@ -143,7 +160,7 @@ func TestConnectDatabase_OpenerError(t *testing.T) {
 }
 
 func TestConnectDatabase_DSNFormat(t *testing.T) {
-	cfg := Config{}
+	cfg := testConfig()
 	cfg.Database.Host = "testhost"
 	cfg.Database.Port = 5433
 	cfg.Database.User = "myuser"
@@ -179,7 +196,7 @@ func TestGetAddr(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := Config{}
+			cfg := testConfig()
 			cfg.Server.Host = tt.host
 			cfg.Server.Port = tt.port
 
@@ -197,7 +214,7 @@ func TestStartServer(t *testing.T) {
 
 	mockStarter := NewMockServerStarter(ctrl)
 
-	cfg := Config{}
+	cfg := testConfig()
 	cfg.Server.Host = "127.0.0.1"
 	cfg.Server.Port = 8081
 	cfg.Server.LivenessPath = "/health"
@@ -205,9 +222,9 @@ func TestStartServer(t *testing.T) {
 
 	// We can't easily create a real *gorm.DB without a database connection
 	// So we'll test that startServer calls the starter with the right address
-	mockStarter.EXPECT().Start("127.0.0.1:8081", gomock.Any()).Return(nil)
+	mockStarter.EXPECT().Start(gomock.Any(), serverAt("127.0.0.1:8081"), defaultShutdownTimeout).Return(nil)
 
-	err := startServer(cfg, nil, mockStarter)
+	err := startServer(context.Background(), cfg, nil, mockStarter)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -219,16 +236,16 @@ func TestStartServer_StarterError(t *testing.T) {
 
 	mockStarter := NewMockServerStarter(ctrl)
 
-	cfg := Config{}
+	cfg := testConfig()
 	cfg.Server.Host = "127.0.0.1"
 	cfg.Server.Port = 8081
 	cfg.Server.LivenessPath = "/health"
 	cfg.Server.ReadinessPath = "/ready"
 
 	expectedErr := errors.New("server start failed")
-	mockStarter.EXPECT().Start("127.0.0.1:8081", gomock.Any()).Return(expectedErr)
+	mockStarter.EXPECT().Start(gomock.Any(), serverAt("127.0.0.1:8081"), defaultShutdownTimeout).Return(expectedErr)
 
-	err := startServer(cfg, nil, mockStarter)
+	err := startServer(context.Background(), cfg, nil, mockStarter)
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -246,7 +263,7 @@ func TestInit_ConfigError(t *testing.T) {
 		return nil, nil
 	}
 
-	err := Init("nonexistent", opener, mockStarter)
+	err := Init(context.Background(), "nonexistent", opener, mockStarter)
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -264,7 +281,7 @@ func TestInit_DatabaseError(t *testing.T) {
 		return nil, errors.New("database connection failed")
 	}
 
-	err := Init("test", opener, mockStarter)
+	err := Init(context.Background(), "test", opener, mockStarter)
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -274,12 +291,15 @@ func TestInit_DatabaseError(t *testing.T) {
 }
 
 func TestCreateRouter_HealthEndpoints(t *testing.T) {
-	cfg := Config{}
+	cfg := testConfig()
 	cfg.Server.LivenessPath = "/health"
 	cfg.Server.ReadinessPath = "/ready"
 
 	// Create router with nil DB (we're only testing route registration)
-	r := createRouter(cfg, nil)
+	r, err := createRouter(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
 
 	// Test liveness endpoint
 	req, err := http.NewRequest("GET", "/health", nil)
@@ -300,11 +320,14 @@ func TestCreateRouter_HealthEndpoints(t *testing.T) {
 }
 
 func TestCreateRouter_RouteRegistration(t *testing.T) {
-	cfg := Config{}
+	cfg := testConfig()
 	cfg.Server.LivenessPath = "/health"
 	cfg.Server.ReadinessPath = "/ready"
 
-	r := createRouter(cfg, nil)
+	r, err := createRouter(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
 
 	// Define expected routes
 	expectedRoutes := []struct {
@@ -315,15 +338,41 @@ func TestCreateRouter_RouteRegistration(t *testing.T) {
 		{"GET", "/ready"},
 		{"POST", "/users"},
 		{"GET", "/users"},
+		{"GET", "/users/search"},
 		{"GET", "/users/{id}"},
 		{"PUT", "/users/{id}"},
 		{"DELETE", "/users/{id}"},
+		{"PUT", "/users/{id}/password"},
+		{"POST", "/users/{id}/restore"},
+		{"POST", "/users/{id}/unlock"},
+		{"POST", "/users/{id}/mfa/totp"},
+		{"POST", "/users/{id}/mfa/totp/activate"},
+		{"POST", "/users/{id}/mfa/totp/disable"},
+		{"POST", "/users/{id}/mfa/recovery-codes"},
+		{"POST", "/users/{id}/mfa/reset"},
 		{"POST", "/users/{id}/access-levels"},
 		{"GET", "/users/{id}/access-levels"},
+		{"PUT", "/users/{id}/access-levels"},
+		{"DELETE", "/users/{id}/access-levels/{levelId}"},
+		{"GET", "/users/{id}/access-levels/effective"},
 		{"POST", "/auth/login"},
+		{"POST", "/auth/mfa/verify"},
+		{"POST", "/auth/refresh"},
+		{"POST", "/auth/password-reset/request"},
+		{"POST", "/auth/password-reset/confirm"},
+		{"POST", "/auth/verify-email"},
+		{"POST", "/auth/verify-email/resend"},
 		{"POST", "/access-levels"},
 		{"GET", "/access-levels"},
 		{"GET", "/access-levels/{id}"},
+		{"PUT", "/access-levels/{id}"},
+		{"PATCH", "/access-levels/{id}"},
+		{"DELETE", "/access-levels/{id}"},
+		{"PUT", "/access-levels/{id}/parents"},
+		{"GET", "/access-levels/{id}/permissions"},
+		{"POST", "/access-levels/{id}/permissions"},
+		{"DELETE", "/access-levels/{id}/permissions/{permissionId}"},
+		{"GET", "/permissions"},
 	}
 
 	// Walk through the router to check if routes are registered
@@ -343,6 +392,331 @@ func TestCreateRouter_RouteRegistration(t *testing.T) {
 	}
 }
 
+func TestCreateRouter_SessionRoutes(t *testing.T) {
+	sessionRoutes := []string{
+		"GET /users/{id}/sessions",
+		"DELETE /users/{id}/sessions",
+		"DELETE /users/{id}/sessions/{sid}",
+	}
+
+	for _, enabled := range []bool{false, true} {
+		cfg := testConfig()
+		cfg.Auth.Sessions.Enabled = enabled
+		cfg.Auth.Sessions.Store = "memory"
+
+		r, err := createRouter(cfg, nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to create router: %v", err)
+		}
+
+		registered := make(map[string]bool)
+		r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+			path, _ := route.GetPathTemplate()
+			methods, _ := route.GetMethods()
+			for _, method := range methods {
+				registered[method+" "+path] = true
+			}
+			return nil
+		})
+
+		for _, route := range sessionRoutes {
+			if registered[route] != enabled {
+				t.Errorf("Sessions enabled=%v: expected route %s registered=%v", enabled, route, enabled)
+			}
+		}
+	}
+}
+
+func TestCreateRouter_RequiresAuthentication(t *testing.T) {
+	cfg := testConfig()
+	cfg.Server.LivenessPath = "/health"
+	cfg.Server.ReadinessPath = "/ready"
+
+	r, err := createRouter(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	protected := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/users"},
+		{"GET", "/users/search?q=smith"},
+		{"POST", "/users/" + uuid.NewString() + "/restore"},
+		{"DELETE", "/users/" + uuid.NewString()},
+		{"POST", "/access-levels"},
+		{"POST", "/access-levels/1/permissions"},
+	}
+	for _, route := range protected {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: expected status %d, got %d", route.method, route.path, http.StatusUnauthorized, rr.Code)
+		}
+	}
+
+	// Liveness stays reachable without a token
+	req := httptest.NewRequest("GET", "/health", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected liveness to be public, got status %d", rr.Code)
+	}
+}
+
+// accessLevelManagerRouter serves a router backed by an in-memory SQLite
+// database holding the built-in access levels, a "level-managers" level
+// granted access-levels:manage and a user who holds it
+type accessLevelManagerRouter struct {
+	router *mux.Router
+	db     *gorm.DB
+	token  string
+	admin  *models.AccessLevel
+	own    *models.AccessLevel
+}
+
+func newAccessLevelManagerRouter(t *testing.T) *accessLevelManagerRouter {
+	t.Helper()
+
+	db, err := repository.OpenTestDB()
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.UserAuthentication{}, &models.AccessLevel{},
+		&models.UserAccessLevel{}, &models.AccessLevelParent{}, &models.Permission{},
+		&models.AccessLevelPermission{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	ctx := context.Background()
+	cfg := testConfig()
+	levels := map[string]*models.AccessLevel{}
+	for _, name := range []string{accessLevelAdmin, accessLevelUserManager, "level-managers"} {
+		levels[name] = &models.AccessLevel{Name: name}
+		if err := db.Create(levels[name]).Error; err != nil {
+			t.Fatalf("Failed to create access level: %v", err)
+		}
+	}
+	for _, name := range []string{permissionUsersDelete, permissionAccessLevelsManage} {
+		if err := db.Create(&models.Permission{Name: name}).Error; err != nil {
+			t.Fatalf("Failed to create permission: %v", err)
+		}
+	}
+	permissionRepo := repository.NewPostgresPermissionRepository(db)
+	manage, err := permissionRepo.GetByNames(ctx, []string{permissionAccessLevelsManage})
+	if err != nil || len(manage) != 1 {
+		t.Fatalf("Failed to load permission: %v", err)
+	}
+	if err := permissionRepo.AssignToAccessLevel(ctx, levels["level-managers"].ID, manage[0].ID); err != nil {
+		t.Fatalf("Failed to assign permission: %v", err)
+	}
+
+	user := &models.User{FirstName: "Level", LastName: "Manager", Email: "manager@example.com"}
+	if err := repository.NewPostgresUserRepository(db).Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if err := repository.NewPostgresAccessLevelRepository(db).AssignToUser(ctx, user.ID, levels["level-managers"].ID); err != nil {
+		t.Fatalf("Failed to assign access level: %v", err)
+	}
+
+	tokens, err := newTokenManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create token manager: %v", err)
+	}
+	token, _, err := tokens.IssueAccessToken(user.ID, []string{"level-managers"}, "")
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	r, err := createRouter(cfg, db, nil)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+	return &accessLevelManagerRouter{router: r, db: db, token: token, admin: levels[accessLevelAdmin], own: levels["level-managers"]}
+}
+
+// serve sends a request with the manager's token and returns the response
+func (m *accessLevelManagerRouter) serve(method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+m.token)
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	m.router.ServeHTTP(rr, req)
+	return rr
+}
+
+// A caller managing access levels through access-levels:manage must not be
+// able to lift their own level to admin or grant it more than they hold
+func TestCreateRouter_AccessLevelManagerCannotEscalate(t *testing.T) {
+	m := newAccessLevelManagerRouter(t)
+
+	t.Run("Assign Permissions", func(t *testing.T) {
+		rr := m.serve("POST", fmt.Sprintf("/access-levels/%d/permissions", m.own.ID), `{"permissions": ["users:delete"]}`)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusForbidden, rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Inherit Admin", func(t *testing.T) {
+		rr := m.serve("PUT", fmt.Sprintf("/access-levels/%d/parents", m.own.ID), fmt.Sprintf(`{"parent_ids": [%d]}`, m.admin.ID))
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusForbidden, rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Take Admin Name", func(t *testing.T) {
+		rr := m.serve("PATCH", fmt.Sprintf("/access-levels/%d", m.own.ID), `{"name": "admin"}`)
+		if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "access_level_name_reserved") {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+		}
+	})
+
+	// Renaming admin away would let the level take its name afterwards
+	t.Run("Rename Admin", func(t *testing.T) {
+		rr := m.serve("PATCH", fmt.Sprintf("/access-levels/%d", m.admin.ID), `{"name": "former-admin"}`)
+		if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "access_level_protected") {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Delete Admin", func(t *testing.T) {
+		rr := m.serve("DELETE", fmt.Sprintf("/access-levels/%d?force=true", m.admin.ID), "")
+		if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "access_level_protected") {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+		}
+	})
+
+	// The manager keeps the routes access-levels:manage is meant for
+	t.Run("Update Own Level", func(t *testing.T) {
+		rr := m.serve("PATCH", fmt.Sprintf("/access-levels/%d", m.own.ID), `{"description": "Manages access levels"}`)
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+	})
+}
+
+// A user manager must not be able to take over an admin account, for example
+// by changing its email and requesting a password reset
+func TestCreateRouter_UserManagerCannotEditAdmin(t *testing.T) {
+	db, err := repository.OpenTestDB()
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.UserAuthentication{}, &models.AccessLevel{},
+		&models.UserAccessLevel{}, &models.AccessLevelParent{}, &models.Permission{},
+		&models.AccessLevelPermission{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	ctx := context.Background()
+	cfg := testConfig()
+	userRepo := repository.NewPostgresUserRepository(db)
+	accessLevelRepo := repository.NewPostgresAccessLevelRepository(db)
+	users := map[string]*models.User{}
+	for _, name := range []string{accessLevelAdmin, accessLevelUserManager, "member"} {
+		users[name] = &models.User{FirstName: "Test", LastName: "User", Email: name + "@example.com"}
+		if err := userRepo.Create(ctx, users[name], &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if name == "member" {
+			continue
+		}
+		level := &models.AccessLevel{Name: name}
+		if err := db.Create(level).Error; err != nil {
+			t.Fatalf("Failed to create access level: %v", err)
+		}
+		if err := accessLevelRepo.AssignToUser(ctx, users[name].ID, level.ID); err != nil {
+			t.Fatalf("Failed to assign access level: %v", err)
+		}
+	}
+
+	tokens, err := newTokenManager(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create token manager: %v", err)
+	}
+	token, _, err := tokens.IssueAccessToken(users[accessLevelUserManager].ID, []string{accessLevelUserManager}, "")
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+	r, err := createRouter(cfg, db, nil)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Edit Admin", func(t *testing.T) {
+		rr := serve("PUT", "/users/"+users[accessLevelAdmin].ID.String(), `{"email": "attacker@example.com"}`)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusForbidden, rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Delete Admin", func(t *testing.T) {
+		rr := serve("DELETE", "/users/"+users[accessLevelAdmin].ID.String(), "")
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusForbidden, rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Edit Member", func(t *testing.T) {
+		rr := serve("PUT", "/users/"+users["member"].ID.String(), `{"first_name": "Renamed"}`)
+		if rr.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+	})
+}
+
+func TestCreateRouter_RateLimitsEmailRoutes(t *testing.T) {
+	db, err := repository.OpenTestDB()
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.UserAuthentication{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	cfg := testConfig()
+	cfg.Auth.LoginRateLimit.Requests = 2
+	r, err := createRouter(cfg, db, nil)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
+
+	serve := func(path string) int {
+		req := httptest.NewRequest("POST", path, strings.NewReader(`{"email": "nobody@example.com", "password": "wrong"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Both routes send email and share one allowance per client
+	for i := 0; i < 2; i++ {
+		if code := serve("/auth/password-reset/request"); code != http.StatusAccepted {
+			t.Fatalf("Request %d: expected status %d, got %d", i+1, http.StatusAccepted, code)
+		}
+	}
+	if code := serve("/auth/verify-email/resend"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d once the limit is used up, got %d", http.StatusTooManyRequests, code)
+	}
+	if code := serve("/auth/password-reset/request"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d once the limit is used up, got %d", http.StatusTooManyRequests, code)
+	}
+
+	// Logins are counted separately
+	if code := serve("/auth/login"); code != http.StatusUnauthorized {
+		t.Errorf("Expected login to be unaffected, got status %d", code)
+	}
+}
+
 func TestRealStarterStart(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -356,12 +730,6 @@ func TestRealStarterStart(t *testing.T) {
 			expectError: true,
 			errorMatch:  "address",
 		},
-		{
-			name:        "empty address",
-			address:     "",
-			expectError: true,
-			errorMatch:  "",
-		},
 		{
 			name:        "malformed address with colons",
 			address:     "not:a:valid:address",
@@ -378,7 +746,7 @@ func TestRealStarterStart(t *testing.T) {
 				fmt.Fprint(w, "test")
 			})
 
-			err := starter.Start(tt.address, handler)
+			err := starter.Start(context.Background(), &http.Server{Addr: tt.address, Handler: handler}, time.Second)
 
 			if tt.expectError && err == nil {
 				t.Error("Expected error, got nil")
@@ -391,38 +759,6 @@ func TestRealStarterStart(t *testing.T) {
 	}
 }
 
-func TestRealStarterStartTimeout(t *testing.T) {
-	starter := &RealStarter{}
-
-	// Create a handler that will keep the server running
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-
-	start := time.Now()
-
-	// Start server on a valid address that will bind successfully
-	// The server should timeout after 10 seconds since it starts successfully
-	// and doesn't return an error immediately
-	err := starter.Start("127.0.0.1:0", handler)
-
-	elapsed := time.Since(start)
-
-	// We expect a timeout error
-	if err == nil {
-		t.Fatal("Expected timeout error, got nil")
-	}
-
-	if !strings.Contains(err.Error(), "timeout") {
-		t.Errorf("Expected timeout error, got: %v", err)
-	}
-
-	// Verify the timeout occurred around 10 seconds (with some tolerance)
-	if elapsed < 9*time.Second || elapsed > 11*time.Second {
-		t.Errorf("Expected timeout around 10 seconds, got %v", elapsed)
-	}
-}
-
 func TestRealStarterStartImmediateError(t *testing.T) {
 	starter := &RealStarter{}
 
@@ -431,7 +767,7 @@ func TestRealStarterStartImmediateError(t *testing.T) {
 	})
 
 	// Use an invalid address format to get immediate error
-	err := starter.Start("invalid-address-format", handler)
+	err := starter.Start(context.Background(), &http.Server{Addr: "invalid-address-format", Handler: handler}, time.Second)
 
 	if err == nil {
 		t.Error("Expected error with invalid address, got nil")
@@ -448,7 +784,7 @@ func TestRealStarterStartWithNilHandler(t *testing.T) {
 
 	// Test with nil handler - http.Server allows nil handler (uses DefaultServeMux)
 	// but we should still get an error from invalid address
-	err := starter.Start("invalid-addr", nil)
+	err := starter.Start(context.Background(), &http.Server{Addr: "invalid-addr", Handler: nil}, time.Second)
 
 	if err == nil {
 		t.Error("Expected error, got nil")
@@ -475,7 +811,7 @@ func TestRealStarterStartPortInUse(t *testing.T) {
 
 	// Try to start another server on the same port
 	starter := &RealStarter{}
-	err := starter.Start("127.0.0.1:18899", handler)
+	err := starter.Start(context.Background(), &http.Server{Addr: "127.0.0.1:18899", Handler: handler}, time.Second)
 
 	if err == nil {
 		t.Error("Expected error when port is in use, got nil")
@@ -509,7 +845,7 @@ func TestRealStarterZeroValue(t *testing.T) {
 	})
 
 	// Test with invalid address
-	err := starter.Start("not:a:valid:address", handler)
+	err := starter.Start(context.Background(), &http.Server{Addr: "not:a:valid:address", Handler: handler}, time.Second)
 	if err == nil {
 		t.Error("Expected error with malformed address")
 	}
@@ -532,7 +868,7 @@ func TestConnectDatabase_ConnectionPoolConfiguration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := Config{}
+			cfg := testConfig()
 			cfg.Database.Host = "localhost"
 			cfg.Database.Port = 5432
 			cfg.Database.User = "test"
@@ -598,7 +934,7 @@ func TestLivenessHandler_DebugLogging(t *testing.T) {
 func TestCreateRouter_NilDatabase(t *testing.T) {
 	// Test that createRouter can handle nil database for route setup
 	// (actual handlers will fail, but route registration should work)
-	cfg := Config{}
+	cfg := testConfig()
 	cfg.Server.LivenessPath = "/health"
 	cfg.Server.ReadinessPath = "/ready"
 
@@ -608,12 +944,229 @@ func TestCreateRouter_NilDatabase(t *testing.T) {
 		}
 	}()
 
-	r := createRouter(cfg, nil)
+	r, err := createRouter(cfg, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create router: %v", err)
+	}
 	if r == nil {
 		t.Fatal("Expected router, got nil")
 	}
 }
 
+func TestNewTokenManager_EphemeralSecret(t *testing.T) {
+	cfg := Config{}
+	if _, err := newTokenManager(cfg); err == nil {
+		t.Fatal("Expected error without a configured secret, got nil")
+	}
+	if _, err := createRouter(cfg, nil, nil); err == nil {
+		t.Fatal("Expected createRouter to refuse to start without a secret")
+	}
+
+	cfg.Auth.AllowEphemeralSecret = true
+	manager, err := newTokenManager(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error when an ephemeral secret is allowed, got %v", err)
+	}
+	if _, _, err := manager.IssueAccessToken(uuid.New(), nil, ""); err != nil {
+		t.Errorf("Expected token issue to succeed, got %v", err)
+	}
+}
+
+func TestNewTokenManager_InvalidConfig(t *testing.T) {
+	cfg := testConfig()
+	cfg.Auth.Algorithm = "RS256"
+	cfg.Auth.PrivateKeyPath = "../resources/nonexistent.pem"
+
+	if _, err := newTokenManager(cfg); err == nil {
+		t.Fatal("Expected error for missing private key, got nil")
+	}
+	if _, err := createRouter(cfg, nil, nil); err == nil {
+		t.Fatal("Expected createRouter to surface token configuration error")
+	}
+}
+
+func TestNewPasswordPolicy(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		policy, err := newPasswordPolicy(Config{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if policy.MinLength != 8 || policy.MaxLength != password.BcryptMaxBytes || policy.Breached != nil {
+			t.Errorf("Expected default policy, got %+v", policy)
+		}
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		breachedFile := filepath.Join(t.TempDir(), "breached.txt")
+		if err := os.WriteFile(breachedFile, nil, 0o600); err != nil {
+			t.Fatalf("Failed to write breached password file: %v", err)
+		}
+
+		cfg := testConfig()
+		cfg.Auth.PasswordPolicy.MinLength = 12
+		cfg.Auth.PasswordPolicy.RequireSymbol = true
+		cfg.Auth.PasswordPolicy.HistorySize = 5
+		cfg.Auth.PasswordPolicy.BreachedPasswordsFile = breachedFile
+
+		policy, err := newPasswordPolicy(cfg)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if policy.MinLength != 12 || !policy.RequireSymbol || policy.HistorySize != 5 || policy.Breached == nil {
+			t.Errorf("Expected configured policy, got %+v", policy)
+		}
+	})
+
+	invalid := map[string]func(cfg *Config){
+		"max over bcrypt limit": func(cfg *Config) { cfg.Auth.PasswordPolicy.MaxLength = 100 },
+		"min over max":          func(cfg *Config) { cfg.Auth.PasswordPolicy.MinLength, cfg.Auth.PasswordPolicy.MaxLength = 20, 10 },
+		"missing breached file": func(cfg *Config) { cfg.Auth.PasswordPolicy.BreachedPasswordsFile = "../resources/missing.txt" },
+	}
+	for name, modify := range invalid {
+		t.Run(name, func(t *testing.T) {
+			cfg := testConfig()
+			modify(&cfg)
+			if _, err := newPasswordPolicy(cfg); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestNewNotifier(t *testing.T) {
+	tests := []struct {
+		name        string
+		notifier    string
+		filePath    string
+		expectError bool
+	}{
+		{name: "default", notifier: ""},
+		{name: "log", notifier: "log"},
+		{name: "file", notifier: "file", filePath: filepath.Join(t.TempDir(), "notifications.jsonl")},
+		{name: "file without path", notifier: "file", expectError: true},
+		{name: "unknown", notifier: "smtp", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Notifications.Notifier = tt.notifier
+			cfg.Notifications.FilePath = tt.filePath
+
+			notifier, err := newNotifier(cfg)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil || notifier == nil {
+				t.Errorf("Expected notifier, got %v (err %v)", notifier, err)
+			}
+		})
+	}
+}
+
+func TestNewSessionRepository(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		store       string
+		expectNil   bool
+		expectError bool
+	}{
+		{name: "disabled", store: "memory", expectNil: true},
+		{name: "default", enabled: true},
+		{name: "database", enabled: true, store: "database"},
+		{name: "memory", enabled: true, store: "memory"},
+		{name: "unknown", enabled: true, store: "redis", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Auth.Sessions.Enabled = tt.enabled
+			cfg.Auth.Sessions.Store = tt.store
+
+			repo, err := newSessionRepository(cfg, nil)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if (repo == nil) != tt.expectNil {
+				t.Errorf("Expected nil repository=%v, got %v", tt.expectNil, repo)
+			}
+		})
+	}
+}
+
+func TestNewCursorCodec(t *testing.T) {
+	type position struct{ N int }
+	cfg := testConfig()
+	cfg.Pagination.CursorSecret = "cursor-secret"
+
+	codec, err := newCursorCodec(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	token, err := codec.Encode(position{N: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var got position
+	shared, _ := newCursorCodec(cfg)
+	if err := shared.Decode(token, &got); err != nil || got.N != 1 {
+		t.Errorf("Expected codecs sharing a secret to accept each other's cursors, got %v", err)
+	}
+
+	cfg.Pagination.CursorSecret = ""
+	if _, err := newCursorCodec(cfg); err == nil {
+		t.Fatal("Expected error without a configured secret, got nil")
+	}
+	if _, err := createRouter(cfg, nil, nil); err == nil {
+		t.Fatal("Expected createRouter to refuse to start without a cursor secret")
+	}
+
+	cfg.Pagination.AllowEphemeralSecret = true
+	ephemeral, err := newCursorCodec(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error when an ephemeral secret is allowed, got %v", err)
+	}
+	if err := ephemeral.Decode(token, &got); err == nil {
+		t.Error("Expected the ephemeral codec to reject the cursor, got nil")
+	}
+}
+
+func TestNewMFASecretBox(t *testing.T) {
+	cfg := testConfig()
+
+	box, err := newMFASecretBox(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	shared, _ := newMFASecretBox(cfg)
+	if opened, err := shared.Open(sealed); err != nil || opened != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Expected boxes sharing a key to open each other's secrets, got %q (%v)", opened, err)
+	}
+
+	cfg.Auth.MFA.SecretKey = ""
+	if _, err := newMFASecretBox(cfg); err == nil {
+		t.Fatal("Expected error without a configured key, got nil")
+	}
+	if _, err := createRouter(cfg, nil, nil); err == nil {
+		t.Fatal("Expected createRouter to refuse to start without an MFA key")
+	}
+}
+
 func TestStartServer_AddressFormat(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -634,15 +1187,15 @@ func TestStartServer_AddressFormat(t *testing.T) {
 
 			mockStarter := NewMockServerStarter(ctrl)
 
-			cfg := Config{}
+			cfg := testConfig()
 			cfg.Server.Host = tt.host
 			cfg.Server.Port = tt.port
 			cfg.Server.LivenessPath = "/health"
 			cfg.Server.ReadinessPath = "/ready"
 
-			mockStarter.EXPECT().Start(tt.expectedAddr, gomock.Any()).Return(nil)
+			mockStarter.EXPECT().Start(gomock.Any(), serverAt(tt.expectedAddr), defaultShutdownTimeout).Return(nil)
 
-			err := startServer(cfg, nil, mockStarter)
+			err := startServer(context.Background(), cfg, nil, mockStarter)
 			if err != nil {
 				t.Errorf("Expected no error, got %v", err)
 			}
@@ -650,33 +1203,6 @@ func TestStartServer_AddressFormat(t *testing.T) {
 	}
 }
 
-func TestRealStarter_ServerShutdown(t *testing.T) {
-	// Test that RealStarter properly handles graceful shutdown
-	starter := &RealStarter{}
-
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
-
-	// Use a valid address that will bind and trigger timeout
-	start := time.Now()
-	err := starter.Start("127.0.0.1:0", handler)
-	elapsed := time.Since(start)
-
-	if err == nil {
-		t.Error("Expected timeout error, got nil")
-	}
-
-	if err != nil && !strings.Contains(err.Error(), "timeout") {
-		t.Errorf("Expected timeout error, got: %v", err)
-	}
-
-	// Should take approximately 10 seconds
-	if elapsed < 9*time.Second || elapsed > 12*time.Second {
-		t.Logf("Timeout duration: %v (expected ~10s)", elapsed)
-	}
-}
-
 func TestInit_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -700,7 +1226,7 @@ func TestInit_Success(t *testing.T) {
 		return nil, errors.New("cannot create GORM DB in unit test")
 	}
 
-	err = Init("test", opener, mockStarter)
+	err = Init(context.Background(), "test", opener, mockStarter)
 
 	// We expect this to fail at DB connection since we can't easily mock GORM
 	if err == nil {
@@ -711,7 +1237,7 @@ func TestInit_Success(t *testing.T) {
 func TestConnectDatabase_WithMigrations(t *testing.T) {
 	// This test verifies the migration logic is called
 	// Actual migration testing requires a real database or complex mocking
-	cfg := Config{}
+	cfg := testConfig()
 	cfg.Database.Host = "localhost"
 	cfg.Database.Port = 5432
 	cfg.Database.User = "test"
@@ -749,7 +1275,7 @@ func TestGetAddr_EdgeCases(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := Config{}
+			cfg := testConfig()
 			cfg.Server.Host = tt.host
 			cfg.Server.Port = tt.port
 
@@ -760,3 +1286,213 @@ func TestGetAddr_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// serverAt matches an *http.Server listening on addr
+type serverAt string
+
+func (m serverAt) Matches(x interface{}) bool {
+	server, ok := x.(*http.Server)
+	return ok && server.Addr == string(m)
+}
+
+func (m serverAt) String() string {
+	return "is a server on " + string(m)
+}
+
+// freeAddr returns a loopback address with a port that is free to listen on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return addr
+}
+
+// waitForServer polls addr until it accepts connections
+func waitForServer(t *testing.T, addr string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Server on %s did not start", addr)
+}
+
+func TestRealStarter_RunsUntilCancelled(t *testing.T) {
+	addr := freeAddr(t)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- (&RealStarter{}).Start(ctx, &http.Server{Addr: addr, Handler: handler}, time.Second)
+	}()
+	waitForServer(t, addr)
+
+	// The server keeps running well past the old 10 second self-shutdown window
+	// in production; here it is enough that it serves until told to stop
+	resp, err := http.Get("http://" + addr)
+	if err != nil {
+		t.Fatalf("Expected server to be running, got %v", err)
+	}
+	resp.Body.Close()
+	select {
+	case err := <-done:
+		t.Fatalf("Server stopped before shutdown was requested: %v", err)
+	default:
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Server did not shut down after cancellation")
+	}
+
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Error("Expected listener to be closed after shutdown")
+	}
+}
+
+func TestRealStarter_DrainsInFlightRequests(t *testing.T) {
+	addr := freeAddr(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- (&RealStarter{}).Start(ctx, &http.Server{Addr: addr, Handler: handler}, 5*time.Second)
+	}()
+	waitForServer(t, addr)
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	cancel()
+	// Shutdown waits for the in-flight request instead of cutting it off
+	select {
+	case err := <-done:
+		t.Fatalf("Server stopped with a request in flight: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	if code := <-status; code != http.StatusOK {
+		t.Errorf("Expected in-flight request to complete with 200, got %d", code)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+}
+
+func TestRealStarter_ShutdownTimeout(t *testing.T) {
+	addr := freeAddr(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- (&RealStarter{}).Start(ctx, &http.Server{Addr: addr, Handler: handler}, 100*time.Millisecond)
+	}()
+	waitForServer(t, addr)
+
+	go http.Get("http://" + addr)
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "failed to drain") {
+			t.Errorf("Expected drain timeout error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown did not give up after the shutdown timeout")
+	}
+}
+
+func TestStartServer_ShutdownSequence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStarter := NewMockServerStarter(ctrl)
+
+	cfg := testConfig()
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = 8081
+	cfg.Server.LivenessPath = "/health"
+	cfg.Server.ReadinessPath = "/ready"
+	cfg.Server.ShutdownTimeout = 15 * time.Second
+	cfg.Server.DrainDelay = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockStarter.EXPECT().Start(gomock.Any(), serverAt("127.0.0.1:8081"), 15*time.Second).DoAndReturn(
+		func(serveCtx context.Context, server *http.Server, _ time.Duration) error {
+			cancel()
+
+			// Readiness fails straight away while the server is still serving
+			rr := httptest.NewRecorder()
+			server.Handler.ServeHTTP(rr, httptest.NewRequest("GET", "/ready", nil))
+			if rr.Code != http.StatusServiceUnavailable {
+				t.Errorf("Expected readiness to fail during drain, got %d", rr.Code)
+			}
+			if serveCtx.Err() != nil {
+				t.Error("Expected server to keep serving during the drain delay")
+			}
+
+			select {
+			case <-serveCtx.Done():
+			case <-time.After(time.Second):
+				t.Error("Expected server to be stopped after the drain delay")
+			}
+			return nil
+		})
+
+	if err := startServer(ctx, cfg, nil, mockStarter); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestGetShutdownTimeout(t *testing.T) {
+	cfg := testConfig()
+	if got := getShutdownTimeout(cfg); got != defaultShutdownTimeout {
+		t.Errorf("Expected default %v, got %v", defaultShutdownTimeout, got)
+	}
+
+	cfg.Server.ShutdownTimeout = 5 * time.Second
+	if got := getShutdownTimeout(cfg); got != 5*time.Second {
+		t.Errorf("Expected 5s, got %v", got)
+	}
+}
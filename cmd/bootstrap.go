@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/dto"
+	"github.com/wabtcdi/user_service/repository"
+	"github.com/wabtcdi/user_service/service"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// bootstrapAdmin gives the account configured under bootstrap.admin the admin
+// access level while nobody holds it, so a new deployment has someone able to
+// grant access levels. The account is created if it does not exist; an
+// existing account keeps its password.
+func bootstrapAdmin(ctx context.Context, cfg Config, db *gorm.DB) error {
+	admin := cfg.Bootstrap.Admin
+	if admin.Email == "" {
+		return nil
+	}
+
+	accessLevelRepo := repository.NewPostgresAccessLevelRepository(db)
+	level, err := accessLevelRepo.GetByName(ctx, accessLevelAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to load the %s access level: %w", accessLevelAdmin, err)
+	}
+	holders, err := accessLevelRepo.CountUsers(ctx, level.ID)
+	if err != nil {
+		return err
+	}
+	if holders > 0 {
+		logrus.Debug("An admin already exists, skipping the bootstrap admin")
+		return nil
+	}
+
+	userRepo := repository.NewPostgresUserRepository(db)
+	var userID uuid.UUID
+	user, err := userRepo.GetByEmail(ctx, admin.Email)
+	switch {
+	case err == nil:
+		userID = user.ID
+	case errors.Is(err, apperrors.ErrNotFound):
+		if admin.Password == "" {
+			return fmt.Errorf("bootstrap.admin.password is required to create %s", admin.Email)
+		}
+		policy, err := newPasswordPolicy(cfg)
+		if err != nil {
+			return err
+		}
+		req := &dto.CreateUserRequest{
+			FirstName: admin.FirstName,
+			LastName:  admin.LastName,
+			Email:     admin.Email,
+			Password:  admin.Password,
+		}
+		if req.FirstName == "" {
+			req.FirstName = "Admin"
+		}
+		if req.LastName == "" {
+			req.LastName = "User"
+		}
+		created, err := service.NewUserService(userRepo, accessLevelRepo, service.WithPasswordPolicy(policy)).
+			CreateUser(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", admin.Email, err)
+		}
+		userID = created.ID
+		// The operator chose the address, so there is nothing to confirm
+		if err := userRepo.MarkEmailVerified(ctx, userID, admin.Email); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	if err := accessLevelRepo.AssignToUser(ctx, userID, level.ID); err != nil {
+		return err
+	}
+	logrus.Infof("Granted the %s access level to bootstrap admin %s", accessLevelAdmin, admin.Email)
+	return nil
+}
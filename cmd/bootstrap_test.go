@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wabtcdi/user_service/models"
+	"github.com/wabtcdi/user_service/repository"
+	"gorm.io/gorm"
+)
+
+func newBootstrapTestDB(t *testing.T) (*gorm.DB, *models.AccessLevel) {
+	t.Helper()
+
+	db, err := repository.OpenTestDB()
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.UserAuthentication{}, &models.PasswordHistory{},
+		&models.AccessLevel{}, &models.UserAccessLevel{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	admin := &models.AccessLevel{Name: accessLevelAdmin}
+	if err := db.Create(admin).Error; err != nil {
+		t.Fatalf("Failed to create access level: %v", err)
+	}
+	return db, admin
+}
+
+func bootstrapConfig(email, password string) Config {
+	cfg := testConfig()
+	cfg.Bootstrap.Admin.Email = email
+	cfg.Bootstrap.Admin.Password = password
+	return cfg
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Creates Admin", func(t *testing.T) {
+		db, admin := newBootstrapTestDB(t)
+
+		if err := bootstrapAdmin(ctx, bootstrapConfig("admin@example.com", "bootstrap-password"), db); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		user, err := repository.NewPostgresUserRepository(db).GetByEmail(ctx, "admin@example.com")
+		if err != nil {
+			t.Fatalf("Expected the admin to be created, got %v", err)
+		}
+		if user.FirstName != "Admin" || user.EmailVerifiedAt == nil {
+			t.Errorf("Expected a verified account named Admin, got %+v", user)
+		}
+		count, err := repository.NewPostgresAccessLevelRepository(db).CountUsers(ctx, admin.ID)
+		if err != nil || count != 1 {
+			t.Errorf("Expected one admin, got %d (%v)", count, err)
+		}
+	})
+
+	t.Run("Promotes Existing Account", func(t *testing.T) {
+		db, admin := newBootstrapTestDB(t)
+		user := &models.User{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+		if err := repository.NewPostgresUserRepository(db).Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+
+		if err := bootstrapAdmin(ctx, bootstrapConfig("jane@example.com", ""), db); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		levels, err := repository.NewPostgresAccessLevelRepository(db).GetUserAccessLevels(ctx, user.ID)
+		if err != nil || len(levels) != 1 || levels[0].ID != admin.ID {
+			t.Errorf("Expected the account to hold admin, got %v (%v)", levels, err)
+		}
+		auth, err := repository.NewPostgresUserRepository(db).GetUserAuthentication(ctx, user.ID)
+		if err != nil || auth.PasswordHash != "hashedpassword" {
+			t.Errorf("Expected the password to be kept, got %v (%v)", auth, err)
+		}
+	})
+
+	t.Run("Admin Already Exists", func(t *testing.T) {
+		db, admin := newBootstrapTestDB(t)
+		user := &models.User{FirstName: "Jane", LastName: "Doe", Email: "jane@example.com"}
+		if err := repository.NewPostgresUserRepository(db).Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if err := repository.NewPostgresAccessLevelRepository(db).AssignToUser(ctx, user.ID, admin.ID); err != nil {
+			t.Fatalf("Failed to assign access level: %v", err)
+		}
+
+		if err := bootstrapAdmin(ctx, bootstrapConfig("admin@example.com", "bootstrap-password"), db); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if _, err := repository.NewPostgresUserRepository(db).GetByEmail(ctx, "admin@example.com"); err == nil {
+			t.Error("Expected no account to be created while an admin exists")
+		}
+	})
+
+	t.Run("Missing Password", func(t *testing.T) {
+		db, _ := newBootstrapTestDB(t)
+
+		if err := bootstrapAdmin(ctx, bootstrapConfig("admin@example.com", ""), db); err == nil {
+			t.Error("Expected an error when the account cannot be created")
+		}
+	})
+
+	t.Run("Not Configured", func(t *testing.T) {
+		db, _ := newBootstrapTestDB(t)
+
+		if err := bootstrapAdmin(ctx, testConfig(), db); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+}
@@ -10,9 +10,21 @@ import (
 
 type Checker struct {
 	DB *gorm.DB
+	// Draining is closed once the server starts shutting down, after which the
+	// service reports itself as not ready so no new traffic is routed to it
+	Draining <-chan struct{}
 }
 
 func (c *Checker) Check(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-c.Draining:
+		logrus.Debug("Readiness check failed, server is shutting down")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "Shutting Down")
+		return
+	default:
+	}
+
 	sqlDB, err := c.DB.DB()
 	if err != nil {
 		logrus.Warn("Failed to get underlying database")
@@ -208,6 +208,51 @@ func TestChecker_Check_AlternatingResults(t *testing.T) {
 	}
 }
 
+func TestChecker_Check_Draining(t *testing.T) {
+	// The database is never pinged once the server is draining
+	mockDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("Failed to create mock db: %v", err)
+	}
+	defer mockDB.Close()
+
+	mock.ExpectPing()
+
+	dialector := &mockDialector{sqlDB: mockDB}
+	gormDB, err := gorm.Open(dialector, &gorm.Config{
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create GORM DB: %v", err)
+	}
+
+	draining := make(chan struct{})
+	checker := &Checker{DB: gormDB, Draining: draining}
+
+	// Ready before shutdown starts
+	rr1 := httptest.NewRecorder()
+	checker.Check(rr1, httptest.NewRequest("GET", "/ready", nil))
+	if rr1.Code != http.StatusOK {
+		t.Errorf("Before draining: expected 200, got %d", rr1.Code)
+	}
+
+	close(draining)
+
+	rr2 := httptest.NewRecorder()
+	checker.Check(rr2, httptest.NewRequest("GET", "/ready", nil))
+	if rr2.Code != http.StatusServiceUnavailable {
+		t.Errorf("While draining: expected 503, got %d", rr2.Code)
+	}
+	if !strings.Contains(rr2.Body.String(), "Shutting Down") {
+		t.Errorf("While draining: unexpected body %q", rr2.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 // mockDialector is a custom GORM dialector for testing with sqlmock
 type mockDialector struct {
 	sqlDB *sql.DB
@@ -5,8 +5,10 @@
 package cmd
 
 import (
+	context "context"
 	http "net/http"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,15 +37,15 @@ func (m *MockServerStarter) EXPECT() *MockServerStarterMockRecorder {
 }
 
 // Start mocks base method.
-func (m *MockServerStarter) Start(addr string, handler http.Handler) error {
+func (m *MockServerStarter) Start(ctx context.Context, server *http.Server, shutdownTimeout time.Duration) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Start", addr, handler)
+	ret := m.ctrl.Call(m, "Start", ctx, server, shutdownTimeout)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Start indicates an expected call of Start.
-func (mr *MockServerStarterMockRecorder) Start(addr, handler interface{}) *gomock.Call {
+func (mr *MockServerStarterMockRecorder) Start(ctx, server, shutdownTimeout interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockServerStarter)(nil).Start), addr, handler)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockServerStarter)(nil).Start), ctx, server, shutdownTimeout)
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,10 +19,24 @@ type Config struct {
 		Name     string `yaml:"name"`
 	} `yaml:"database"`
 	Server struct {
-		Host          string `yaml:"host"`
-		Port          int    `yaml:"port"`
-		ReadinessPath string `yaml:"readinessPath"`
-		LivenessPath  string `yaml:"livenessPath"`
+		Host              string        `yaml:"host"`
+		Port              int           `yaml:"port"`
+		ReadinessPath     string        `yaml:"readinessPath"`
+		LivenessPath      string        `yaml:"livenessPath"`
+		ShutdownTimeout   time.Duration `yaml:"shutdownTimeout"`
+		DrainDelay        time.Duration `yaml:"drainDelay"`
+		ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+		ReadTimeout       time.Duration `yaml:"readTimeout"`
+		WriteTimeout      time.Duration `yaml:"writeTimeout"`
+		IdleTimeout       time.Duration `yaml:"idleTimeout"`
+		MaxHeaderBytes    int           `yaml:"maxHeaderBytes"`
+		MaxBodyBytes      int64         `yaml:"maxBodyBytes"`
+		TrustForwardedFor bool          `yaml:"trustForwardedFor"`
+		TLS               struct {
+			CertFile       string        `yaml:"certFile"`
+			KeyFile        string        `yaml:"keyFile"`
+			ReloadInterval time.Duration `yaml:"reloadInterval"`
+		} `yaml:"tls"`
 	} `yaml:"server"`
 	Resources struct {
 		Memory  string `yaml:"memory"`
@@ -29,6 +44,74 @@ type Config struct {
 		Storage string `yaml:"storage"`
 		Threads int    `yaml:"threads"`
 	} `yaml:"resources"`
+	Auth struct {
+		Algorithm            string        `yaml:"algorithm"`
+		Secret               string        `yaml:"secret"`
+		AllowEphemeralSecret bool          `yaml:"allowEphemeralSecret"`
+		PrivateKeyPath       string        `yaml:"privateKeyPath"`
+		KeyID                string        `yaml:"keyId"`
+		Issuer               string        `yaml:"issuer"`
+		Audience             string        `yaml:"audience"`
+		AccessTokenTTL       time.Duration `yaml:"accessTokenTTL"`
+		RefreshTokenTTL      time.Duration `yaml:"refreshTokenTTL"`
+		PasswordReset        struct {
+			URL      string        `yaml:"url"`
+			TokenTTL time.Duration `yaml:"tokenTTL"`
+		} `yaml:"passwordReset"`
+		EmailVerification struct {
+			URL      string        `yaml:"url"`
+			TokenTTL time.Duration `yaml:"tokenTTL"`
+			Required bool          `yaml:"required"`
+		} `yaml:"emailVerification"`
+		PasswordPolicy struct {
+			MinLength             int    `yaml:"minLength"`
+			MaxLength             int    `yaml:"maxLength"`
+			RequireUppercase      bool   `yaml:"requireUppercase"`
+			RequireLowercase      bool   `yaml:"requireLowercase"`
+			RequireDigit          bool   `yaml:"requireDigit"`
+			RequireSymbol         bool   `yaml:"requireSymbol"`
+			DisallowPersonalInfo  bool   `yaml:"disallowPersonalInfo"`
+			HistorySize           int    `yaml:"historySize"`
+			BreachedPasswordsFile string `yaml:"breachedPasswordsFile"`
+		} `yaml:"passwordPolicy"`
+		Lockout struct {
+			MaxAttempts int           `yaml:"maxAttempts"`
+			Duration    time.Duration `yaml:"duration"`
+			BaseDelay   time.Duration `yaml:"baseDelay"`
+		} `yaml:"lockout"`
+		LoginRateLimit struct {
+			Requests int           `yaml:"requests"`
+			Window   time.Duration `yaml:"window"`
+		} `yaml:"loginRateLimit"`
+		MFA struct {
+			Issuer               string        `yaml:"issuer"`
+			ChallengeTTL         time.Duration `yaml:"challengeTTL"`
+			SecretKey            string        `yaml:"secretKey"`
+			RequiredAccessLevels []string      `yaml:"requiredAccessLevels"`
+		} `yaml:"mfa"`
+		Sessions struct {
+			Enabled         bool          `yaml:"enabled"`
+			Store           string        `yaml:"store"`
+			IdleTimeout     time.Duration `yaml:"idleTimeout"`
+			AbsoluteTimeout time.Duration `yaml:"absoluteTimeout"`
+		} `yaml:"sessions"`
+	} `yaml:"auth"`
+	Bootstrap struct {
+		Admin struct {
+			Email     string `yaml:"email"`
+			Password  string `yaml:"password"`
+			FirstName string `yaml:"firstName"`
+			LastName  string `yaml:"lastName"`
+		} `yaml:"admin"`
+	} `yaml:"bootstrap"`
+	Pagination struct {
+		CursorSecret         string `yaml:"cursorSecret"`
+		AllowEphemeralSecret bool   `yaml:"allowEphemeralSecret"`
+	} `yaml:"pagination"`
+	Notifications struct {
+		Notifier string `yaml:"notifier"`
+		FilePath string `yaml:"filePath"`
+	} `yaml:"notifications"`
 	Logging struct {
 		Level  string `yaml:"level"`
 		Format string `yaml:"format"`
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func createTempConfigFile(t *testing.T, content string) string {
@@ -49,6 +50,9 @@ func TestLoadConfig(t *testing.T) {
 				if cfg.Logging.Level != "debug" {
 					t.Errorf("Expected log level 'debug', got %s", cfg.Logging.Level)
 				}
+				if cfg.Auth.AccessTokenTTL != 15*time.Minute {
+					t.Errorf("Expected access token TTL 15m, got %v", cfg.Auth.AccessTokenTTL)
+				}
 			},
 		},
 		{
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wabtcdi/user_service/cmd/tlscert"
+)
+
+// Defaults for server settings left unset in the configuration
+const (
+	defaultShutdownTimeout   = 30 * time.Second
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultReadTimeout       = 30 * time.Second
+	defaultWriteTimeout      = 30 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+	defaultMaxHeaderBytes    = 1 << 20 // 1 MiB
+	defaultMaxBodyBytes      = 1 << 20 // 1 MiB
+	defaultTLSReloadInterval = time.Minute
+)
+
+// newHTTPServer builds the server for handler from the server configuration.
+// When a TLS certificate is configured the server serves it, reloading it
+// whenever the files change until ctx is cancelled.
+func newHTTPServer(ctx context.Context, cfg Config, handler http.Handler) (*http.Server, error) {
+	server := &http.Server{
+		Addr:              getAddr(cfg),
+		Handler:           limitRequestBody(handler, orDefault(cfg.Server.MaxBodyBytes, defaultMaxBodyBytes)),
+		ReadHeaderTimeout: orDefault(cfg.Server.ReadHeaderTimeout, defaultReadHeaderTimeout),
+		ReadTimeout:       orDefault(cfg.Server.ReadTimeout, defaultReadTimeout),
+		WriteTimeout:      orDefault(cfg.Server.WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:       orDefault(cfg.Server.IdleTimeout, defaultIdleTimeout),
+		MaxHeaderBytes:    orDefault(cfg.Server.MaxHeaderBytes, defaultMaxHeaderBytes),
+	}
+
+	tlsCfg := cfg.Server.TLS
+	if tlsCfg.CertFile == "" && tlsCfg.KeyFile == "" {
+		return server, nil
+	}
+	if tlsCfg.CertFile == "" || tlsCfg.KeyFile == "" {
+		return nil, fmt.Errorf("TLS requires both server.tls.certFile and server.tls.keyFile")
+	}
+
+	reloader, err := tlscert.NewReloader(tlsCfg.CertFile, tlsCfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	go reloader.Watch(ctx, orDefault(tlsCfg.ReloadInterval, defaultTLSReloadInterval))
+	logrus.Infof("TLS enabled with certificate %s", tlsCfg.CertFile)
+
+	server.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	return server, nil
+}
+
+// limitRequestBody rejects request bodies larger than maxBytes; reading past
+// the limit fails with an *http.MaxBytesError
+func limitRequestBody(next http.Handler, maxBytes int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func getShutdownTimeout(cfg Config) time.Duration {
+	return orDefault(cfg.Server.ShutdownTimeout, defaultShutdownTimeout)
+}
+
+func orDefault[T time.Duration | int | int64](value, fallback T) T {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 and
+// returns the certificate and key paths
+func writeTestCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "user_service"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestNewHTTPServer_Defaults(t *testing.T) {
+	cfg := Config{}
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = 8081
+
+	server, err := newHTTPServer(context.Background(), cfg, http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if server.Addr != "127.0.0.1:8081" {
+		t.Errorf("Expected address 127.0.0.1:8081, got %s", server.Addr)
+	}
+	if server.ReadHeaderTimeout != defaultReadHeaderTimeout {
+		t.Errorf("Expected ReadHeaderTimeout %v, got %v", defaultReadHeaderTimeout, server.ReadHeaderTimeout)
+	}
+	if server.ReadTimeout != defaultReadTimeout {
+		t.Errorf("Expected ReadTimeout %v, got %v", defaultReadTimeout, server.ReadTimeout)
+	}
+	if server.WriteTimeout != defaultWriteTimeout {
+		t.Errorf("Expected WriteTimeout %v, got %v", defaultWriteTimeout, server.WriteTimeout)
+	}
+	if server.IdleTimeout != defaultIdleTimeout {
+		t.Errorf("Expected IdleTimeout %v, got %v", defaultIdleTimeout, server.IdleTimeout)
+	}
+	if server.MaxHeaderBytes != defaultMaxHeaderBytes {
+		t.Errorf("Expected MaxHeaderBytes %d, got %d", defaultMaxHeaderBytes, server.MaxHeaderBytes)
+	}
+	if server.TLSConfig != nil {
+		t.Error("Expected TLS to be disabled without a certificate")
+	}
+}
+
+func TestNewHTTPServer_Configured(t *testing.T) {
+	cfg := Config{}
+	cfg.Server.ReadHeaderTimeout = 2 * time.Second
+	cfg.Server.ReadTimeout = 5 * time.Second
+	cfg.Server.WriteTimeout = 10 * time.Second
+	cfg.Server.IdleTimeout = time.Minute
+	cfg.Server.MaxHeaderBytes = 8 << 10
+	cfg.Server.MaxBodyBytes = 16
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	server, err := newHTTPServer(context.Background(), cfg, handler)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if server.ReadHeaderTimeout != 2*time.Second || server.ReadTimeout != 5*time.Second ||
+		server.WriteTimeout != 10*time.Second || server.IdleTimeout != time.Minute {
+		t.Errorf("Expected configured timeouts, got header=%v read=%v write=%v idle=%v",
+			server.ReadHeaderTimeout, server.ReadTimeout, server.WriteTimeout, server.IdleTimeout)
+	}
+	if server.MaxHeaderBytes != 8<<10 {
+		t.Errorf("Expected MaxHeaderBytes %d, got %d", 8<<10, server.MaxHeaderBytes)
+	}
+
+	small := httptest.NewRecorder()
+	server.Handler.ServeHTTP(small, httptest.NewRequest("POST", "/", strings.NewReader("short body")))
+	if small.Code != http.StatusOK {
+		t.Errorf("Expected body within the limit to be accepted, got %d", small.Code)
+	}
+
+	large := httptest.NewRecorder()
+	server.Handler.ServeHTTP(large, httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("a", 17))))
+	if large.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected body over the limit to be rejected, got %d", large.Code)
+	}
+}
+
+func TestNewHTTPServer_TLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+
+	t.Run("Certificate And Key", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		cfg := Config{}
+		cfg.Server.TLS.CertFile = certFile
+		cfg.Server.TLS.KeyFile = keyFile
+
+		server, err := newHTTPServer(ctx, cfg, http.NotFoundHandler())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if server.TLSConfig == nil || server.TLSConfig.GetCertificate == nil {
+			t.Fatal("Expected TLS to serve the configured certificate")
+		}
+		if server.TLSConfig.MinVersion != tls.VersionTLS12 {
+			t.Errorf("Expected minimum version TLS 1.2, got %x", server.TLSConfig.MinVersion)
+		}
+	})
+
+	t.Run("Missing Key", func(t *testing.T) {
+		cfg := Config{}
+		cfg.Server.TLS.CertFile = certFile
+
+		if _, err := newHTTPServer(context.Background(), cfg, http.NotFoundHandler()); err == nil {
+			t.Error("Expected error when the key file is missing, got nil")
+		}
+	})
+
+	t.Run("Unreadable Certificate", func(t *testing.T) {
+		cfg := Config{}
+		cfg.Server.TLS.CertFile = filepath.Join(t.TempDir(), "missing.crt")
+		cfg.Server.TLS.KeyFile = keyFile
+
+		if _, err := newHTTPServer(context.Background(), cfg, http.NotFoundHandler()); err == nil {
+			t.Error("Expected error for a missing certificate, got nil")
+		}
+	})
+}
+
+func TestRealStarter_ServesTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+	addr := freeAddr(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	cfg := Config{}
+	cfg.Server.Host = host
+	cfg.Server.Port, _ = net.LookupPort("tcp", port)
+	cfg.Server.TLS.CertFile = certFile
+	cfg.Server.TLS.KeyFile = keyFile
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server, err := newHTTPServer(ctx, cfg, handler)
+	if err != nil {
+		t.Fatalf("Failed to build server: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- (&RealStarter{}).Start(ctx, server, time.Second)
+	}()
+	waitForServer(t, addr)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	resp, err := client.Get("https://" + addr)
+	if err != nil {
+		t.Fatalf("Expected HTTPS request to succeed, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.TLS == nil {
+		t.Error("Expected the response to be served over TLS")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+}
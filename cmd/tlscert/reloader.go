@@ -0,0 +1,126 @@
+package tlscert
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reloader serves a TLS certificate loaded from disk and picks up a new one
+// when the certificate or key file changes, so certificates can be rotated
+// without restarting the server
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certStat fileStamp
+	keyStat  fileStamp
+}
+
+// fileStamp identifies a version of a file by its modification time and size
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewReloader loads the certificate and key, failing if they cannot be used
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate; use it as tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Watch checks the files for changes every interval until ctx is cancelled.
+// A certificate that fails to load is logged and the previous one kept.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.ReloadIfChanged(); err != nil {
+				logrus.Errorf("Failed to reload TLS certificate: %v", err)
+			}
+		}
+	}
+}
+
+// ReloadIfChanged reloads the certificate if either file changed since it was
+// last loaded and reports whether it did
+func (r *Reloader) ReloadIfChanged() (bool, error) {
+	certStat, keyStat, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	changed := certStat != r.certStat || keyStat != r.keyStat
+	r.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	if err := r.load(); err != nil {
+		return false, err
+	}
+	logrus.Infof("Reloaded TLS certificate from %s", r.certFile)
+	return true, nil
+}
+
+func (r *Reloader) load() error {
+	certStat, keyStat, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.certStat = certStat
+	r.keyStat = keyStat
+	return nil
+}
+
+func (r *Reloader) stat() (fileStamp, fileStamp, error) {
+	certStat, err := statFile(r.certFile)
+	if err != nil {
+		return fileStamp{}, fileStamp{}, err
+	}
+	keyStat, err := statFile(r.keyFile)
+	if err != nil {
+		return fileStamp{}, fileStamp{}, err
+	}
+	return certStat, keyStat, nil
+}
+
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
@@ -0,0 +1,156 @@
+package tlscert
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate for commonName to the
+// given paths and moves their modification time to modTime
+func writeCertificate(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	writePEM(t, certFile, "CERTIFICATE", der, modTime)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER, modTime)
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set modification time of %s: %v", path, err)
+	}
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	t.Helper()
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("Failed to get certificate: %v", err)
+	}
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestNewReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, "first", time.Now())
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Failed to create reloader: %v", err)
+	}
+	if got := commonName(t, r); got != "first" {
+		t.Errorf("Expected certificate for first, got %s", got)
+	}
+
+	if _, err := NewReloader(filepath.Join(dir, "missing.crt"), keyFile); err == nil {
+		t.Error("Expected error for missing certificate, got nil")
+	}
+}
+
+func TestReloader_ReloadIfChanged(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	loadedAt := time.Now().Add(-time.Minute)
+	writeCertificate(t, certFile, keyFile, "first", loadedAt)
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Failed to create reloader: %v", err)
+	}
+
+	reloaded, err := r.ReloadIfChanged()
+	if err != nil || reloaded {
+		t.Errorf("Expected no reload for unchanged files, got reloaded=%v err=%v", reloaded, err)
+	}
+
+	writeCertificate(t, certFile, keyFile, "second", time.Now())
+	reloaded, err = r.ReloadIfChanged()
+	if err != nil || !reloaded {
+		t.Fatalf("Expected reload after files changed, got reloaded=%v err=%v", reloaded, err)
+	}
+	if got := commonName(t, r); got != "second" {
+		t.Errorf("Expected certificate for second, got %s", got)
+	}
+
+	// A broken certificate is rejected and the previous one stays in use
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("Failed to corrupt certificate: %v", err)
+	}
+	if _, err := r.ReloadIfChanged(); err == nil {
+		t.Error("Expected error for invalid certificate, got nil")
+	}
+	if got := commonName(t, r); got != "second" {
+		t.Errorf("Expected previous certificate to be kept, got %s", got)
+	}
+}
+
+func TestReloader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, "first", time.Now().Add(-time.Minute))
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Failed to create reloader: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Watch(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	writeCertificate(t, certFile, keyFile, "second", time.Now())
+	deadline := time.Now().Add(2 * time.Second)
+	for commonName(t, r) != "second" {
+		if time.Now().After(deadline) {
+			t.Fatal("Watch did not pick up the new certificate")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Watch did not stop after cancellation")
+	}
+}
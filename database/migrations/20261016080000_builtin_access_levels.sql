@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Built-in access levels referenced by the route authorization rules
+INSERT INTO access_levels (name, description) VALUES
+    ('admin', 'Full access, including granting access levels and permissions'),
+    ('user-manager', 'Create, view, update and delete user accounts')
+ON CONFLICT (name) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM access_levels WHERE name IN ('admin', 'user-manager');
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Refresh tokens table (only the SHA-256 hash of each token is stored)
+CREATE TABLE refresh_tokens (
+                                id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                family_id UUID NOT NULL,
+                                token_hash VARCHAR(64) UNIQUE NOT NULL,
+                                expires_at TIMESTAMPTZ NOT NULL,
+                                revoked_at TIMESTAMPTZ,
+                                replaced_by_id UUID,
+                                created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS refresh_tokens;
+-- +goose StatementEnd
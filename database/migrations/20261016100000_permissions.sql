@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Permissions table
+CREATE TABLE permissions (
+                             id SERIAL PRIMARY KEY,
+                             name VARCHAR(100) UNIQUE NOT NULL,
+                             description TEXT,
+                             created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                             updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                             deleted_at TIMESTAMPTZ
+);
+
+-- Access level permissions table (many-to-many relationship)
+CREATE TABLE access_level_permissions (
+                                          access_level_id INTEGER NOT NULL REFERENCES access_levels(id) ON DELETE CASCADE,
+                                          permission_id INTEGER NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+                                          created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                          updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                          deleted_at TIMESTAMPTZ,
+                                          PRIMARY KEY (access_level_id, permission_id)
+);
+
+CREATE INDEX idx_access_level_permissions_access_level_id ON access_level_permissions(access_level_id);
+CREATE INDEX idx_access_level_permissions_permission_id ON access_level_permissions(permission_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'View user accounts'),
+    ('users:write', 'Create and update user accounts'),
+    ('users:delete', 'Delete user accounts'),
+    ('access-levels:manage', 'Update and delete access levels and remove their permissions');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS access_level_permissions;
+DROP TABLE IF EXISTS permissions;
+-- +goose StatementEnd
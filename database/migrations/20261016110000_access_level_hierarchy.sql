@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Access level parents table: holders of access_level_id inherit parent_id
+CREATE TABLE access_level_parents (
+                                      access_level_id INTEGER NOT NULL REFERENCES access_levels(id) ON DELETE CASCADE,
+                                      parent_id INTEGER NOT NULL REFERENCES access_levels(id) ON DELETE CASCADE,
+                                      created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                      PRIMARY KEY (access_level_id, parent_id),
+                                      CHECK (access_level_id <> parent_id)
+);
+
+CREATE INDEX idx_access_level_parents_parent_id ON access_level_parents(parent_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS access_level_parents;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Names only need to be unique among access levels that have not been
+-- deleted, so a deleted level's name can be used again
+ALTER TABLE access_levels DROP CONSTRAINT IF EXISTS access_levels_name_key;
+CREATE UNIQUE INDEX idx_access_levels_name_active ON access_levels(name) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Fails while a deleted access level shares its name with another level
+DROP INDEX IF EXISTS idx_access_levels_name_active;
+ALTER TABLE access_levels ADD CONSTRAINT access_levels_name_key UNIQUE (name);
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Password reset tokens table (only the SHA-256 hash of each token is stored)
+CREATE TABLE password_reset_tokens (
+                                       id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                       token_hash VARCHAR(64) UNIQUE NOT NULL,
+                                       expires_at TIMESTAMPTZ NOT NULL,
+                                       used_at TIMESTAMPTZ,
+                                       created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS password_reset_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_authentications ADD COLUMN password_changed_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_authentications DROP COLUMN IF EXISTS password_changed_at;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Previous password hashes, kept so users cannot cycle back to recent passwords
+CREATE TABLE password_history (
+                                  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                  password_hash VARCHAR(255) NOT NULL,
+                                  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_history_user_id_created_at ON password_history(user_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS password_history;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Failed login tracking, kept in the database so lockouts survive restarts
+-- and apply across replicas
+ALTER TABLE user_authentications
+    ADD COLUMN failed_login_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_failed_login_at TIMESTAMPTZ,
+    ADD COLUMN locked_until TIMESTAMPTZ;
+
+-- Security relevant events such as account lockouts, kept for audit. user_id
+-- is not a foreign key so events outlive a purged user and stay attributable.
+CREATE TABLE audit_events (
+                              id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                              user_id UUID NOT NULL,
+                              actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+                              event VARCHAR(50) NOT NULL,
+                              ip_address VARCHAR(45),
+                              detail TEXT,
+                              created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_user_id_created_at ON audit_events(user_id, created_at DESC);
+CREATE INDEX idx_audit_events_event ON audit_events(event);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_events;
+
+ALTER TABLE user_authentications
+    DROP COLUMN IF EXISTS locked_until,
+    DROP COLUMN IF EXISTS last_failed_login_at,
+    DROP COLUMN IF EXISTS failed_login_count;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- TOTP secret of users enrolling in or using multi-factor authentication,
+-- encrypted with the configured MFA secret key. MFA is enabled once
+-- mfa_enabled_at is set; totp_last_step is the last accepted time step, so a
+-- code cannot be used twice.
+ALTER TABLE user_authentications
+    ADD COLUMN totp_secret VARCHAR(128),
+    ADD COLUMN mfa_enabled_at TIMESTAMPTZ,
+    ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- One-time recovery codes for users who lose their authenticator
+CREATE TABLE mfa_recovery_codes (
+                                    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                    code_hash VARCHAR(64) NOT NULL UNIQUE,
+                                    used_at TIMESTAMPTZ,
+                                    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
+
+-- Challenges handed out by a password login that still needs a second
+-- factor, or that first has to enroll one when the user's access levels
+-- require MFA (purpose 'enroll')
+CREATE TABLE mfa_challenges (
+                                id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                token_hash VARCHAR(64) NOT NULL UNIQUE,
+                                purpose VARCHAR(20) NOT NULL DEFAULT 'verify',
+                                expires_at TIMESTAMPTZ NOT NULL,
+                                failed_attempts INTEGER NOT NULL DEFAULT 0,
+                                used_at TIMESTAMPTZ,
+                                created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_mfa_challenges_user_id ON mfa_challenges(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mfa_challenges;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+
+ALTER TABLE user_authentications
+    DROP COLUMN IF EXISTS totp_last_step,
+    DROP COLUMN IF EXISTS mfa_enabled_at,
+    DROP COLUMN IF EXISTS totp_secret;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Time the user's current email address was confirmed; cleared when the
+-- address changes. Existing accounts predate verification and count as
+-- verified, so requiring verification does not lock them out.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+UPDATE users SET email_verified_at = created_at;
+
+-- Email verification tokens table (only the SHA-256 hash of each token is
+-- stored). email is the address the token confirms.
+CREATE TABLE email_verification_tokens (
+                                           id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                           user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                           email VARCHAR(255) NOT NULL,
+                                           token_hash VARCHAR(64) UNIQUE NOT NULL,
+                                           expires_at TIMESTAMPTZ NOT NULL,
+                                           used_at TIMESTAMPTZ,
+                                           created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_verification_tokens_user_id ON email_verification_tokens(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS email_verification_tokens;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Server-side sessions, one per login. The ID is shared with the refresh
+-- token family issued by the login.
+CREATE TABLE sessions (
+                          id UUID PRIMARY KEY,
+                          user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                          user_agent VARCHAR(512),
+                          ip_address VARCHAR(45),
+                          created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                          last_seen_at TIMESTAMPTZ NOT NULL,
+                          expires_at TIMESTAMPTZ NOT NULL,
+                          revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sessions;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Supports keyset pagination of users by creation time with the ID as
+-- tiebreaker, in either direction
+CREATE INDEX idx_users_created_at_id ON users(created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_created_at_id;
+-- +goose StatementEnd
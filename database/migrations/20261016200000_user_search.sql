@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Fuzzy user search: trigram indexes find names, emails and phone numbers
+-- with typos or given in part, and the full-text index finds users by the
+-- words of their name and email. The indexed expressions must match the ones
+-- in repository/user_search.go.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_users_name_trgm ON users USING GIN ((lower(first_name || ' ' || last_name)) gin_trgm_ops);
+CREATE INDEX idx_users_email_trgm ON users USING GIN ((lower(email)) gin_trgm_ops);
+CREATE INDEX idx_users_phone_trgm ON users USING GIN (phone_number gin_trgm_ops);
+CREATE INDEX idx_users_search_document ON users USING GIN ((to_tsvector('simple', first_name || ' ' || last_name || ' ' || email)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_search_document;
+DROP INDEX IF EXISTS idx_users_phone_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+DROP INDEX IF EXISTS idx_users_name_trgm;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Emails only need to be unique among users that have not been deleted, so a
+-- soft-deleted user's address can be registered again
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX idx_users_email_active ON users(email) WHERE deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Fails while a deleted user shares an email with another user; purge one of
+-- them first
+DROP INDEX IF EXISTS idx_users_email_active;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+-- +goose StatementEnd
@@ -12,7 +12,7 @@ type CreateUserRequest struct {
 	LastName    string `json:"last_name" validate:"required,min=1,max=50"`
 	Email       string `json:"email" validate:"required,email,max=255"`
 	PhoneNumber string `json:"phone_number,omitempty" validate:"omitempty,max=20"`
-	Password    string `json:"password" validate:"required,min=8"`
+	Password    string `json:"password" validate:"required"`
 }
 
 // UpdateUserRequest represents the request to update an existing user
@@ -25,14 +25,16 @@ type UpdateUserRequest struct {
 
 // UserResponse represents the user data returned in API responses
 type UserResponse struct {
-	ID           uuid.UUID             `json:"id"`
-	FirstName    string                `json:"first_name"`
-	LastName     string                `json:"last_name"`
-	Email        string                `json:"email"`
-	PhoneNumber  string                `json:"phone_number,omitempty"`
-	AccessLevels []AccessLevelResponse `json:"access_levels,omitempty"`
-	CreatedAt    time.Time             `json:"created_at"`
-	UpdatedAt    time.Time             `json:"updated_at"`
+	ID              uuid.UUID             `json:"id"`
+	FirstName       string                `json:"first_name"`
+	LastName        string                `json:"last_name"`
+	Email           string                `json:"email"`
+	EmailVerifiedAt *time.Time            `json:"email_verified_at"`
+	PhoneNumber     string                `json:"phone_number,omitempty"`
+	AccessLevels    []AccessLevelResponse `json:"access_levels,omitempty"`
+	CreatedAt       time.Time             `json:"created_at"`
+	UpdatedAt       time.Time             `json:"updated_at"`
+	DeletedAt       *time.Time            `json:"deleted_at,omitempty"`
 }
 
 // LoginRequest represents authentication credentials
@@ -41,10 +43,108 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
-// LoginResponse represents the response after successful authentication
+// LoginResponse represents the response after successful authentication.
+// When the user has MFA enabled a password login only returns MFARequired
+// and an MFAToken to complete with a second factor; User and the tokens are
+// returned once that succeeds. When the user's access levels require MFA but
+// they have not enabled it, the login returns MFAEnrollmentRequired with a
+// new secret in TOTPEnrollment instead, and completing it also returns the
+// user's RecoveryCodes.
 type LoginResponse struct {
-	User    UserResponse `json:"user"`
-	Message string       `json:"message"`
+	User                  *UserResponse           `json:"user,omitempty"`
+	Message               string                  `json:"message"`
+	MFARequired           bool                    `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool                    `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string                  `json:"mfa_token,omitempty"`
+	TOTPEnrollment        *TOTPEnrollmentResponse `json:"totp_enrollment,omitempty"`
+	RecoveryCodes         []string                `json:"recovery_codes,omitempty"`
+	SessionID             string                  `json:"session_id,omitempty"`
+	TokenResponse
+}
+
+// MFAVerifyRequest completes a login with the MFA token it returned and either
+// a code from the user's authenticator app or one of their recovery codes
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required,max=32"`
+}
+
+// TOTPEnrollmentResponse carries a new TOTP secret to add to an authenticator
+// app, either typed in or scanned as a QR code of OTPAuthURI
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// ActivateTOTPRequest confirms TOTP enrollment with a code from the authenticator app
+type ActivateTOTPRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// MFACodeRequest confirms a change to a user's MFA settings with a code from
+// their authenticator app or one of their recovery codes
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// RecoveryCodesResponse lists one-time recovery codes. They are not stored in
+// readable form and cannot be shown again.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TokenResponse represents the tokens issued after authentication or refresh
+type TokenResponse struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	TokenType    string `json:"token_type,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// RefreshTokenRequest represents the request to exchange a refresh token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// ChangePasswordRequest replaces a user's password. NewPassword is checked
+// against the same password policy as the password given on creation.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+// PasswordResetRequest asks for a password reset link to be sent to an email
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// PasswordResetConfirmRequest sets a new password using a reset token
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+// VerifyEmailRequest confirms an email address using a verification token
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendVerificationEmailRequest asks for a new verification link to be sent
+// to an email
+type ResendVerificationEmailRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// SessionResponse describes one of a user's active sessions. Current marks
+// the session of the request that listed them.
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
 }
 
 // AssignAccessLevelRequest represents the request to assign access levels to a user
@@ -52,29 +152,107 @@ type AssignAccessLevelRequest struct {
 	AccessLevelIDs []int `json:"access_level_ids" validate:"required,min=1"`
 }
 
+// ReplaceAccessLevelsRequest represents the request to replace a user's full
+// set of access levels; an empty list removes them all
+type ReplaceAccessLevelsRequest struct {
+	AccessLevelIDs []int `json:"access_level_ids" validate:"required"`
+}
+
 // AccessLevelResponse represents an access level in API responses
 type AccessLevelResponse struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
+	ParentIDs   []int  `json:"parent_ids,omitempty"`
+}
+
+// UserAccessLevelsResponse contrasts the access levels assigned to a user with
+// the full set they hold once inherited levels are included
+type UserAccessLevelsResponse struct {
+	Direct    []AccessLevelResponse `json:"direct"`
+	Effective []AccessLevelResponse `json:"effective"`
 }
 
-// ErrorResponse represents an error response
+// ErrorResponse represents an error response. Code is a stable
+// machine-readable identifier for the failure, such as "user_not_found".
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	Error   string       `json:"error"`
+	Code    string       `json:"code"`
+	Message string       `json:"message,omitempty"`
+	Details []FieldError `json:"details,omitempty"`
 }
 
-// ListUsersResponse represents paginated list of users
+// FieldError describes a request field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ListUsersQuery holds the paging, filtering and sorting parameters of a user
+// listing. Sort is a comma-separated list of fields, each optionally prefixed
+// with "-" for descending order. Listings are paged by page number unless
+// UseCursor is set or a Cursor from a previous response is given.
+type ListUsersQuery struct {
+	Page           int
+	PageSize       int
+	Cursor         string
+	UseCursor      bool
+	SkipTotal      bool
+	Email          string
+	Name           string
+	Phone          string
+	AccessLevel    string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	IncludeDeleted bool
+	OnlyDeleted    bool
+	Sort           string
+}
+
+// ListUsersResponse represents paginated list of users. Total is omitted when
+// counting was skipped, Page when paging by cursor.
 type ListUsersResponse struct {
-	Users    []UserResponse `json:"users"`
-	Total    int            `json:"total"`
-	Page     int            `json:"page"`
-	PageSize int            `json:"page_size"`
+	Users      []UserResponse `json:"users"`
+	Total      *int           `json:"total,omitempty"`
+	Page       int            `json:"page,omitempty"`
+	PageSize   int            `json:"page_size"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	PrevCursor string         `json:"prev_cursor,omitempty"`
+}
+
+// SearchUsersResponse lists the users matching a search, most relevant first
+type SearchUsersResponse struct {
+	Users []UserResponse `json:"users"`
 }
 
 // CreateAccessLevelRequest represents the request to create an access level
 type CreateAccessLevelRequest struct {
 	Name        string `json:"name" validate:"required,min=1,max=50"`
 	Description string `json:"description,omitempty"`
+	ParentIDs   []int  `json:"parent_ids,omitempty"`
+}
+
+// UpdateAccessLevelRequest represents the request to update an access level.
+// Omitted fields are left unchanged; an empty description clears it.
+type UpdateAccessLevelRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=50"`
+	Description *string `json:"description,omitempty"`
+}
+
+// SetAccessLevelParentsRequest replaces the parents an access level inherits from
+type SetAccessLevelParentsRequest struct {
+	ParentIDs []int `json:"parent_ids"`
+}
+
+// PermissionResponse represents a permission in API responses
+type PermissionResponse struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// AssignPermissionsRequest represents the request to grant permissions to an access level
+type AssignPermissionsRequest struct {
+	Permissions []string `json:"permissions" validate:"required,min=1"`
 }
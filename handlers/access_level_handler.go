@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 
@@ -21,16 +20,14 @@ func NewAccessLevelHandler(service service.AccessLevelServiceInterface) *AccessL
 
 func (h *AccessLevelHandler) CreateAccessLevel(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateAccessLevelRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logrus.Errorf("Failed to decode request: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	accessLevel, err := h.service.CreateAccessLevel(r.Context(), &req)
 	if err != nil {
 		logrus.Errorf("Failed to create access level: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Failed to create access level", err.Error())
+		respondWithServiceError(w, "Failed to create access level", err)
 		return
 	}
 
@@ -48,7 +45,7 @@ func (h *AccessLevelHandler) GetAccessLevel(w http.ResponseWriter, r *http.Reque
 	accessLevel, err := h.service.GetAccessLevel(r.Context(), id)
 	if err != nil {
 		logrus.Errorf("Failed to get access level: %v", err)
-		respondWithError(w, http.StatusNotFound, "Access level not found", err.Error())
+		respondWithServiceError(w, "Failed to get access level", err)
 		return
 	}
 
@@ -59,9 +56,157 @@ func (h *AccessLevelHandler) ListAccessLevels(w http.ResponseWriter, r *http.Req
 	accessLevels, err := h.service.ListAccessLevels(r.Context())
 	if err != nil {
 		logrus.Errorf("Failed to list access levels: %v", err)
-		respondWithError(w, http.StatusInternalServerError, "Failed to list access levels", err.Error())
+		respondWithServiceError(w, "Failed to list access levels", err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, accessLevels)
 }
+
+func (h *AccessLevelHandler) UpdateAccessLevel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid access level ID", err.Error())
+		return
+	}
+
+	var req dto.UpdateAccessLevelRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	accessLevel, err := h.service.UpdateAccessLevel(r.Context(), id, &req)
+	if err != nil {
+		logrus.Errorf("Failed to update access level: %v", err)
+		respondWithServiceError(w, "Failed to update access level", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, accessLevel)
+}
+
+func (h *AccessLevelHandler) DeleteAccessLevel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid access level ID", err.Error())
+		return
+	}
+
+	force := false
+	if value := r.URL.Query().Get("force"); value != "" {
+		force, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid force parameter", err.Error())
+			return
+		}
+	}
+
+	err = h.service.DeleteAccessLevel(r.Context(), id, force)
+	if err != nil {
+		logrus.Errorf("Failed to delete access level: %v", err)
+		respondWithServiceError(w, "Failed to delete access level", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Access level deleted successfully"})
+}
+
+func (h *AccessLevelHandler) SetAccessLevelParents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid access level ID", err.Error())
+		return
+	}
+
+	var req dto.SetAccessLevelParentsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	accessLevel, err := h.service.SetAccessLevelParents(r.Context(), id, &req)
+	if err != nil {
+		logrus.Errorf("Failed to set access level parents: %v", err)
+		respondWithServiceError(w, "Failed to set access level parents", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, accessLevel)
+}
+
+func (h *AccessLevelHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.service.ListPermissions(r.Context())
+	if err != nil {
+		logrus.Errorf("Failed to list permissions: %v", err)
+		respondWithServiceError(w, "Failed to list permissions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, permissions)
+}
+
+func (h *AccessLevelHandler) GetAccessLevelPermissions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid access level ID", err.Error())
+		return
+	}
+
+	permissions, err := h.service.GetAccessLevelPermissions(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("Failed to get access level permissions: %v", err)
+		respondWithServiceError(w, "Failed to get access level permissions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, permissions)
+}
+
+func (h *AccessLevelHandler) AssignPermissions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid access level ID", err.Error())
+		return
+	}
+
+	var req dto.AssignPermissionsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	permissions, err := h.service.AssignPermissions(r.Context(), id, &req)
+	if err != nil {
+		logrus.Errorf("Failed to assign permissions: %v", err)
+		respondWithServiceError(w, "Failed to assign permissions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, permissions)
+}
+
+func (h *AccessLevelHandler) RemovePermission(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid access level ID", err.Error())
+		return
+	}
+
+	permissionID, err := strconv.Atoi(vars["permissionId"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid permission ID", err.Error())
+		return
+	}
+
+	if err := h.service.RemovePermission(r.Context(), id, permissionID); err != nil {
+		logrus.Errorf("Failed to remove permission: %v", err)
+		respondWithServiceError(w, "Failed to remove permission", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Permission removed successfully"})
+}
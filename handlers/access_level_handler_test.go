@@ -12,6 +12,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/wabtcdi/user_service/apperrors"
 	"github.com/wabtcdi/user_service/dto"
 	"github.com/wabtcdi/user_service/service"
 )
@@ -48,7 +49,77 @@ func (m *MockAccessLevelService) ListAccessLevels(ctx context.Context) ([]dto.Ac
 	return args.Get(0).([]dto.AccessLevelResponse), args.Error(1)
 }
 
+func (m *MockAccessLevelService) UpdateAccessLevel(ctx context.Context, id int, req *dto.UpdateAccessLevelRequest) (*dto.AccessLevelResponse, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.AccessLevelResponse), args.Error(1)
+}
+
+func (m *MockAccessLevelService) DeleteAccessLevel(ctx context.Context, id int, force bool) error {
+	args := m.Called(ctx, id, force)
+	return args.Error(0)
+}
+
+func (m *MockAccessLevelService) SetAccessLevelParents(ctx context.Context, id int, req *dto.SetAccessLevelParentsRequest) (*dto.AccessLevelResponse, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.AccessLevelResponse), args.Error(1)
+}
+
+func (m *MockAccessLevelService) ListPermissions(ctx context.Context) ([]dto.PermissionResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dto.PermissionResponse), args.Error(1)
+}
+
+func (m *MockAccessLevelService) GetAccessLevelPermissions(ctx context.Context, accessLevelID int) ([]dto.PermissionResponse, error) {
+	args := m.Called(ctx, accessLevelID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dto.PermissionResponse), args.Error(1)
+}
+
+func (m *MockAccessLevelService) AssignPermissions(ctx context.Context, accessLevelID int, req *dto.AssignPermissionsRequest) ([]dto.PermissionResponse, error) {
+	args := m.Called(ctx, accessLevelID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dto.PermissionResponse), args.Error(1)
+}
+
+func (m *MockAccessLevelService) RemovePermission(ctx context.Context, accessLevelID, permissionID int) error {
+	args := m.Called(ctx, accessLevelID, permissionID)
+	return args.Error(0)
+}
+
 func TestCreateAccessLevel(t *testing.T) {
+	t.Run("Missing Name", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		handler := NewAccessLevelHandler(mockService)
+
+		body := []byte(`{"description":"No name given"}`)
+		request := httptest.NewRequest(http.MethodPost, "/access-levels", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.CreateAccessLevel(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "validation_failed", response.Code)
+		assert.Equal(t, []dto.FieldError{
+			{Field: "name", Rule: "required", Message: "name is required"},
+		}, response.Details)
+		mockService.AssertNotCalled(t, "CreateAccessLevel", mock.Anything, mock.Anything)
+	})
+
 	t.Run("Success", func(t *testing.T) {
 		mockService := new(MockAccessLevelService)
 		handler := NewAccessLevelHandler(mockService)
@@ -134,7 +205,7 @@ func TestCreateAccessLevel(t *testing.T) {
 			Description: "Administrator access level",
 		}
 
-		mockService.On("CreateAccessLevel", mock.Anything, req).Return(nil, errors.New("access level with name Admin already exists"))
+		mockService.On("CreateAccessLevel", mock.Anything, req).Return(nil, apperrors.Conflict("access_level_name_taken", "access level with name Admin already exists"))
 
 		body, _ := json.Marshal(req)
 		request := httptest.NewRequest(http.MethodPost, "/access-levels", bytes.NewReader(body))
@@ -142,10 +213,11 @@ func TestCreateAccessLevel(t *testing.T) {
 
 		handler.CreateAccessLevel(recorder, request)
 
-		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, http.StatusConflict, recorder.Code)
 		var response dto.ErrorResponse
 		json.Unmarshal(recorder.Body.Bytes(), &response)
 		assert.Equal(t, "Failed to create access level", response.Error)
+		assert.Equal(t, "access_level_name_taken", response.Code)
 		assert.Contains(t, response.Message, "already exists")
 		mockService.AssertExpectations(t)
 	})
@@ -166,7 +238,7 @@ func TestCreateAccessLevel(t *testing.T) {
 
 		handler.CreateAccessLevel(recorder, request)
 
-		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
 		var response dto.ErrorResponse
 		json.Unmarshal(recorder.Body.Bytes(), &response)
 		assert.Equal(t, "Failed to create access level", response.Error)
@@ -255,7 +327,7 @@ func TestGetAccessLevel(t *testing.T) {
 
 		// Note: strconv.Atoi will parse -1 successfully, but the service should handle validation
 		accessLevelID := -1
-		mockService.On("GetAccessLevel", mock.Anything, accessLevelID).Return(nil, errors.New("invalid access level ID"))
+		mockService.On("GetAccessLevel", mock.Anything, accessLevelID).Return(nil, apperrors.NotFound("access_level_not_found", "access level not found"))
 
 		request := httptest.NewRequest(http.MethodGet, "/access-levels/-1", nil)
 		recorder := httptest.NewRecorder()
@@ -273,7 +345,7 @@ func TestGetAccessLevel(t *testing.T) {
 		handler := NewAccessLevelHandler(mockService)
 
 		accessLevelID := 999
-		mockService.On("GetAccessLevel", mock.Anything, accessLevelID).Return(nil, errors.New("access level not found"))
+		mockService.On("GetAccessLevel", mock.Anything, accessLevelID).Return(nil, apperrors.NotFound("access_level_not_found", "access level not found"))
 
 		request := httptest.NewRequest(http.MethodGet, "/access-levels/999", nil)
 		recorder := httptest.NewRecorder()
@@ -285,7 +357,7 @@ func TestGetAccessLevel(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, recorder.Code)
 		var response dto.ErrorResponse
 		json.Unmarshal(recorder.Body.Bytes(), &response)
-		assert.Equal(t, "Access level not found", response.Error)
+		assert.Equal(t, "Failed to get access level", response.Error)
 		mockService.AssertExpectations(t)
 	})
 }
@@ -392,7 +464,8 @@ func TestListAccessLevels(t *testing.T) {
 		var response dto.ErrorResponse
 		json.Unmarshal(recorder.Body.Bytes(), &response)
 		assert.Equal(t, "Failed to list access levels", response.Error)
-		assert.Contains(t, response.Message, "database")
+		assert.Equal(t, "internal_error", response.Code)
+		assert.NotContains(t, response.Message, "database")
 		mockService.AssertExpectations(t)
 	})
 
@@ -415,6 +488,273 @@ func TestListAccessLevels(t *testing.T) {
 	})
 }
 
+func TestUpdateAccessLevel(t *testing.T) {
+	newRouter := func(handler *AccessLevelHandler) *mux.Router {
+		router := mux.NewRouter()
+		router.HandleFunc("/access-levels/{id}", handler.UpdateAccessLevel).Methods(http.MethodPut, http.MethodPatch)
+		return router
+	}
+	name := "editor"
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		req := &dto.UpdateAccessLevelRequest{Name: &name}
+		expectedResponse := &dto.AccessLevelResponse{ID: 2, Name: name}
+		mockService.On("UpdateAccessLevel", mock.Anything, 2, req).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(req)
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPatch, "/access-levels/2", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response dto.AccessLevelResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, name, response.Name)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Name Conflict", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		req := &dto.UpdateAccessLevelRequest{Name: &name}
+		mockService.On("UpdateAccessLevel", mock.Anything, 3, req).
+			Return(nil, apperrors.Conflict("access_level_name_taken", "access level with name editor already exists"))
+
+		body, _ := json.Marshal(req)
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/access-levels/3", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Body", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/access-levels/3", bytes.NewReader([]byte("{"))))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockService.AssertNotCalled(t, "UpdateAccessLevel")
+	})
+}
+
+func TestDeleteAccessLevel(t *testing.T) {
+	newRouter := func(handler *AccessLevelHandler) *mux.Router {
+		router := mux.NewRouter()
+		router.HandleFunc("/access-levels/{id}", handler.DeleteAccessLevel).Methods(http.MethodDelete)
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		mockService.On("DeleteAccessLevel", mock.Anything, 1, false).Return(nil)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/access-levels/1", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Force", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		mockService.On("DeleteAccessLevel", mock.Anything, 1, true).Return(nil)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/access-levels/1?force=true", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("In Use", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		mockService.On("DeleteAccessLevel", mock.Anything, 1, false).
+			Return(apperrors.Conflict("access_level_in_use", "access level is still assigned to users"))
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/access-levels/1", nil))
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		mockService.On("DeleteAccessLevel", mock.Anything, 99, false).Return(apperrors.NotFound("access_level_not_found", "access level not found"))
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/access-levels/99", nil))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Force", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/access-levels/1?force=maybe", nil))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockService.AssertNotCalled(t, "DeleteAccessLevel")
+	})
+}
+
+func TestSetAccessLevelParents(t *testing.T) {
+	newRouter := func(handler *AccessLevelHandler) *mux.Router {
+		router := mux.NewRouter()
+		router.HandleFunc("/access-levels/{id}/parents", handler.SetAccessLevelParents).Methods(http.MethodPut)
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		req := &dto.SetAccessLevelParentsRequest{ParentIDs: []int{2}}
+		expectedResponse := &dto.AccessLevelResponse{ID: 3, Name: "admin", ParentIDs: []int{2}}
+		mockService.On("SetAccessLevelParents", mock.Anything, 3, req).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(req)
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/access-levels/3/parents", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response dto.AccessLevelResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, []int{2}, response.ParentIDs)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		req := &dto.SetAccessLevelParentsRequest{ParentIDs: []int{3}}
+		mockService.On("SetAccessLevelParents", mock.Anything, 1, req).
+			Return(nil, apperrors.Validation("access_level_cycle", "access level hierarchy would contain a cycle: 1 -> 3 -> 2 -> 1"))
+
+		body, _ := json.Marshal(req)
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/access-levels/1/parents", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Access Level ID", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/access-levels/abc/parents", bytes.NewReader([]byte(`{"parent_ids":[]}`))))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockService.AssertNotCalled(t, "SetAccessLevelParents")
+	})
+}
+
+func TestAccessLevelPermissions(t *testing.T) {
+	newRouter := func(handler *AccessLevelHandler) *mux.Router {
+		router := mux.NewRouter()
+		router.HandleFunc("/permissions", handler.ListPermissions).Methods(http.MethodGet)
+		router.HandleFunc("/access-levels/{id}/permissions", handler.GetAccessLevelPermissions).Methods(http.MethodGet)
+		router.HandleFunc("/access-levels/{id}/permissions", handler.AssignPermissions).Methods(http.MethodPost)
+		router.HandleFunc("/access-levels/{id}/permissions/{permissionId}", handler.RemovePermission).Methods(http.MethodDelete)
+		return router
+	}
+	permissions := []dto.PermissionResponse{{ID: 1, Name: "users:read"}, {ID: 2, Name: "users:write"}}
+
+	t.Run("List Permissions", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		mockService.On("ListPermissions", mock.Anything).Return(permissions, nil)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/permissions", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response []dto.PermissionResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, permissions, response)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Get Access Level Permissions", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		mockService.On("GetAccessLevelPermissions", mock.Anything, 1).Return(permissions, nil)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/access-levels/1/permissions", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Get Access Level Permissions - Not Found", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		mockService.On("GetAccessLevelPermissions", mock.Anything, 99).Return(nil, apperrors.NotFound("access_level_not_found", "access level not found"))
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/access-levels/99/permissions", nil))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Assign Permissions", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		req := &dto.AssignPermissionsRequest{Permissions: []string{"users:read", "users:write"}}
+		mockService.On("AssignPermissions", mock.Anything, 1, req).Return(permissions, nil)
+
+		body, _ := json.Marshal(req)
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/access-levels/1/permissions", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Assign Permissions - Unknown Permission", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		req := &dto.AssignPermissionsRequest{Permissions: []string{"bogus"}}
+		mockService.On("AssignPermissions", mock.Anything, 1, req).Return(nil, apperrors.Validation("unknown_permissions", "unknown permissions: bogus"))
+
+		body, _ := json.Marshal(req)
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/access-levels/1/permissions", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Assign Permissions - Invalid Body", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/access-levels/1/permissions", bytes.NewReader([]byte("{"))))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockService.AssertNotCalled(t, "AssignPermissions")
+	})
+
+	t.Run("Remove Permission", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+		mockService.On("RemovePermission", mock.Anything, 1, 2).Return(nil)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/access-levels/1/permissions/2", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Remove Permission - Invalid Permission ID", func(t *testing.T) {
+		mockService := new(MockAccessLevelService)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewAccessLevelHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/access-levels/1/permissions/abc", nil))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockService.AssertNotCalled(t, "RemovePermission")
+	})
+}
+
 func TestAccessLevelHandlerIntegration(t *testing.T) {
 	t.Run("Create Then Get Access Level", func(t *testing.T) {
 		mockService := new(MockAccessLevelService)
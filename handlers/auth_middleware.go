@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wabtcdi/user_service/auth"
+)
+
+// TokenVerifier validates bearer tokens presented to the API
+type TokenVerifier interface {
+	VerifyAccessToken(token string) (*auth.Claims, error)
+}
+
+// AuthMiddleware rejects requests without a valid bearer token, except for
+// the explicitly allow-listed public paths
+type AuthMiddleware struct {
+	verifier    TokenVerifier
+	publicPaths map[string]bool
+}
+
+func NewAuthMiddleware(verifier TokenVerifier, publicPaths ...string) *AuthMiddleware {
+	paths := make(map[string]bool, len(publicPaths))
+	for _, path := range publicPaths {
+		if path != "" {
+			paths[path] = true
+		}
+	}
+	return &AuthMiddleware{verifier: verifier, publicPaths: paths}
+}
+
+// Authenticate validates the bearer token and stores the caller's principal in the request context
+func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="user_service"`)
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized", "missing bearer token")
+			return
+		}
+
+		claims, err := m.verifier.VerifyAccessToken(token)
+		if err != nil {
+			logrus.Debugf("Rejected bearer token: %v", err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="user_service", error="invalid_token"`)
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized", "invalid or expired token")
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="user_service", error="invalid_token"`)
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized", "invalid token subject")
+			return
+		}
+
+		principal := &auth.Principal{
+			UserID:       userID,
+			AccessLevels: claims.AccessLevels,
+			TokenID:      claims.ID,
+			SessionID:    claims.SessionID,
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/wabtcdi/user_service/auth"
+	"github.com/wabtcdi/user_service/dto"
+)
+
+// stubTokenVerifier accepts a single known token
+type stubTokenVerifier struct {
+	token  string
+	claims *auth.Claims
+}
+
+func (v *stubTokenVerifier) VerifyAccessToken(token string) (*auth.Claims, error) {
+	if token != v.token {
+		return nil, errors.New("invalid token")
+	}
+	return v.claims, nil
+}
+
+func newStubVerifier(userID string, accessLevels ...string) *stubTokenVerifier {
+	return &stubTokenVerifier{
+		token: "valid-token",
+		claims: &auth.Claims{
+			AccessLevels:     accessLevels,
+			RegisteredClaims: jwt.RegisteredClaims{Subject: userID, ID: "token-id"},
+		},
+	}
+}
+
+func TestAuthMiddleware_Authenticate(t *testing.T) {
+	userID := uuid.New()
+	middleware := NewAuthMiddleware(newStubVerifier(userID.String(), "admin"), "/health", "/auth/login")
+
+	var captured *auth.Principal
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured, _ = auth.PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := middleware.Authenticate(next)
+
+	t.Run("Valid Token", func(t *testing.T) {
+		captured = nil
+		request := httptest.NewRequest(http.MethodGet, "/users", nil)
+		request.Header.Set("Authorization", "Bearer valid-token")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		if assert.NotNil(t, captured) {
+			assert.Equal(t, userID, captured.UserID)
+			assert.Equal(t, []string{"admin"}, captured.AccessLevels)
+			assert.Equal(t, "token-id", captured.TokenID)
+		}
+	})
+
+	t.Run("Missing Token", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodDelete, "/users/"+userID.String(), nil)
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Contains(t, recorder.Header().Get("WWW-Authenticate"), "Bearer")
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "Unauthorized", response.Error)
+	})
+
+	t.Run("Wrong Scheme", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/users", nil)
+		request.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+
+	t.Run("Invalid Token", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/users", nil)
+		request.Header.Set("Authorization", "Bearer forged-token")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Contains(t, recorder.Header().Get("WWW-Authenticate"), "invalid_token")
+	})
+
+	t.Run("Public Paths", func(t *testing.T) {
+		for _, path := range []string{"/health", "/auth/login"} {
+			captured = nil
+			request := httptest.NewRequest(http.MethodGet, path, nil)
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusOK, recorder.Code, path)
+			assert.Nil(t, captured, path)
+		}
+	})
+}
+
+func TestAuthMiddleware_InvalidSubject(t *testing.T) {
+	middleware := NewAuthMiddleware(newStubVerifier("not-a-uuid"))
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Next handler should not be called")
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, "/users", nil)
+	request.Header.Set("Authorization", "Bearer valid-token")
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
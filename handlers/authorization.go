@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/auth"
+	"github.com/wabtcdi/user_service/models"
+)
+
+// UserLoader loads a user that has not been deleted
+type UserLoader interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+}
+
+// AccessLevelLoader loads the access levels currently held by a user,
+// including those inherited from parent levels
+type AccessLevelLoader interface {
+	GetEffectiveUserAccessLevels(ctx context.Context, userID uuid.UUID) ([]*models.AccessLevel, error)
+}
+
+// PermissionLoader loads the permissions granted by any of the given access
+// levels
+type PermissionLoader interface {
+	GetByAccessLevels(ctx context.Context, accessLevelIDs []int) ([]*models.Permission, error)
+}
+
+// Grants are the access levels and permissions currently held by the caller
+type Grants struct {
+	AccessLevels map[string]bool
+	Permissions  map[string]bool
+}
+
+// Rule decides whether a caller holding the given grants may perform a request.
+// A denied request is described by the returned reason.
+type Rule func(r *http.Request, principal *auth.Principal, grants *Grants) (allowed bool, reason string)
+
+// RequireAccessLevel allows callers holding any of the named access levels
+func RequireAccessLevel(names ...string) Rule {
+	return func(r *http.Request, principal *auth.Principal, grants *Grants) (bool, string) {
+		for _, name := range names {
+			if grants.AccessLevels[name] {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("requires access level %s", strings.Join(names, " or "))
+	}
+}
+
+// RequirePermission allows callers holding any of the named permissions
+func RequirePermission(names ...string) Rule {
+	return func(r *http.Request, principal *auth.Principal, grants *Grants) (bool, string) {
+		for _, name := range names {
+			if grants.Permissions[name] {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("requires permission %s", strings.Join(names, " or "))
+	}
+}
+
+// AnyOf allows the request when at least one of the rules allows it
+func AnyOf(rules ...Rule) Rule {
+	return func(r *http.Request, principal *auth.Principal, grants *Grants) (bool, string) {
+		reasons := make([]string, 0, len(rules))
+		for _, rule := range rules {
+			allowed, reason := rule(r, principal, grants)
+			if allowed {
+				return true, ""
+			}
+			reasons = append(reasons, reason)
+		}
+		return false, strings.Join(reasons, ", or ")
+	}
+}
+
+// AllOf allows the request only when every rule allows it
+func AllOf(rules ...Rule) Rule {
+	return func(r *http.Request, principal *auth.Principal, grants *Grants) (bool, string) {
+		for _, rule := range rules {
+			if allowed, reason := rule(r, principal, grants); !allowed {
+				return false, reason
+			}
+		}
+		return true, ""
+	}
+}
+
+// SelfOr allows callers whose user ID matches the named path variable, and
+// otherwise falls back to rule
+func SelfOr(pathVar string, rule Rule) Rule {
+	return func(r *http.Request, principal *auth.Principal, grants *Grants) (bool, string) {
+		if id, err := uuid.Parse(mux.Vars(r)[pathVar]); err == nil && id == principal.UserID {
+			return true, ""
+		}
+		return rule(r, principal, grants)
+	}
+}
+
+// Self only allows callers whose user ID matches the named path variable
+func Self(pathVar string) Rule {
+	return SelfOr(pathVar, func(r *http.Request, principal *auth.Principal, grants *Grants) (bool, string) {
+		return false, "only the user themselves may do this"
+	})
+}
+
+// Authorizer evaluates route rules against the caller's current access levels
+// and, when a permission loader is configured, the permissions those levels
+// grant. The levels are loaded once per request and reused for both. When a
+// user loader is configured, callers whose account has since been deleted are
+// turned away before any rule is evaluated.
+type Authorizer struct {
+	users       UserLoader
+	loader      AccessLevelLoader
+	permissions PermissionLoader
+}
+
+func NewAuthorizer(users UserLoader, loader AccessLevelLoader, permissions PermissionLoader) *Authorizer {
+	return &Authorizer{users: users, loader: loader, permissions: permissions}
+}
+
+// HoldsLevelsOf allows callers who hold every access level, inherited ones
+// included, of the user named by the path variable, so that managing users
+// does not extend to accounts more privileged than the caller's own
+func (a *Authorizer) HoldsLevelsOf(pathVar string) Rule {
+	return func(r *http.Request, principal *auth.Principal, grants *Grants) (bool, string) {
+		userID, err := uuid.Parse(mux.Vars(r)[pathVar])
+		if err != nil {
+			// The handler rejects the malformed ID
+			return true, ""
+		}
+		levels, err := a.loader.GetEffectiveUserAccessLevels(r.Context(), userID)
+		if err != nil {
+			logrus.Errorf("Failed to load access levels for %s: %v", userID, err)
+			return false, "could not load the user's access levels"
+		}
+		for _, level := range levels {
+			if !grants.AccessLevels[level.Name] {
+				return false, fmt.Sprintf("the user holds access level %s", level.Name)
+			}
+		}
+		return true, ""
+	}
+}
+
+// Require wraps next so that it only runs when rule allows the request
+func (a *Authorizer) Require(rule Rule, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized", "authentication required")
+			return
+		}
+
+		if a.users != nil {
+			_, err := a.users.GetByID(r.Context(), principal.UserID)
+			if errors.Is(err, apperrors.ErrNotFound) {
+				respondWithError(w, http.StatusUnauthorized, "Unauthorized", "the account no longer exists")
+				return
+			}
+			if err != nil {
+				logrus.Errorf("Failed to load user %s: %v", principal.UserID, err)
+				respondWithError(w, http.StatusInternalServerError, "Authorization failed", "could not load the user")
+				return
+			}
+		}
+
+		levels, err := a.loader.GetEffectiveUserAccessLevels(r.Context(), principal.UserID)
+		if err != nil {
+			logrus.Errorf("Failed to load access levels for %s: %v", principal.UserID, err)
+			respondWithError(w, http.StatusInternalServerError, "Authorization failed", "could not load access levels")
+			return
+		}
+
+		grants := &Grants{
+			AccessLevels: make(map[string]bool, len(levels)),
+			Permissions:  map[string]bool{},
+		}
+		ids := make([]int, 0, len(levels))
+		for _, level := range levels {
+			grants.AccessLevels[level.Name] = true
+			ids = append(ids, level.ID)
+		}
+
+		if a.permissions != nil && len(ids) > 0 {
+			permissions, err := a.permissions.GetByAccessLevels(r.Context(), ids)
+			if err != nil {
+				logrus.Errorf("Failed to load permissions for %s: %v", principal.UserID, err)
+				respondWithError(w, http.StatusInternalServerError, "Authorization failed", "could not load permissions")
+				return
+			}
+			for _, permission := range permissions {
+				grants.Permissions[permission.Name] = true
+			}
+		}
+
+		if allowed, reason := rule(r, principal, grants); !allowed {
+			logrus.Infof("Denied %s %s for user %s: %s", r.Method, r.URL.Path, principal.UserID, reason)
+			respondWithError(w, http.StatusForbidden, "Forbidden", reason)
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/auth"
+	"github.com/wabtcdi/user_service/dto"
+	"github.com/wabtcdi/user_service/models"
+)
+
+// stubAccessLevelLoader returns fixed access levels per user
+type stubAccessLevelLoader struct {
+	levels map[uuid.UUID][]string
+	err    error
+}
+
+func (l *stubAccessLevelLoader) GetEffectiveUserAccessLevels(ctx context.Context, userID uuid.UUID) ([]*models.AccessLevel, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	var result []*models.AccessLevel
+	for i, name := range l.levels[userID] {
+		result = append(result, &models.AccessLevel{ID: i + 1, Name: name})
+	}
+	return result, nil
+}
+
+// stubUserLoader knows every user except the deleted ones
+type stubUserLoader struct {
+	deleted map[uuid.UUID]bool
+	err     error
+}
+
+func (l *stubUserLoader) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	if l.deleted[id] {
+		return nil, apperrors.NotFound("user_not_found", "user not found")
+	}
+	return &models.User{ID: id}, nil
+}
+
+func serveAuthorized(t *testing.T, authz *Authorizer, rule Rule, caller *uuid.UUID, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", authz.Require(rule, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	if caller != nil {
+		request = request.WithContext(auth.WithPrincipal(request.Context(), &auth.Principal{UserID: *caller}))
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAuthorizer_RequireAccessLevel(t *testing.T) {
+	admin := uuid.New()
+	viewer := uuid.New()
+	authz := NewAuthorizer(nil, &stubAccessLevelLoader{levels: map[uuid.UUID][]string{
+		admin:  {"admin"},
+		viewer: {"viewer"},
+	}}, nil)
+	rule := RequireAccessLevel("admin", "user-manager")
+
+	t.Run("Allowed", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, &admin, "/users/"+uuid.NewString())
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, &viewer, "/users/"+uuid.NewString())
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "Forbidden", response.Error)
+		assert.Equal(t, "requires access level admin or user-manager", response.Message)
+	})
+
+	t.Run("No Principal", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, nil, "/users/"+uuid.NewString())
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
+
+func TestAuthorizer_SelfOr(t *testing.T) {
+	manager := uuid.New()
+	member := uuid.New()
+	authz := NewAuthorizer(nil, &stubAccessLevelLoader{levels: map[uuid.UUID][]string{
+		manager: {"user-manager"},
+	}}, nil)
+	rule := SelfOr("id", RequireAccessLevel("user-manager"))
+
+	t.Run("Own Record", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, &member, "/users/"+member.String())
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Other Record", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, &member, "/users/"+manager.String())
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("Manager On Other Record", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, &manager, "/users/"+member.String())
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
+
+func TestAuthorizer_Self(t *testing.T) {
+	admin := uuid.New()
+	member := uuid.New()
+	authz := NewAuthorizer(nil, &stubAccessLevelLoader{levels: map[uuid.UUID][]string{
+		admin: {"admin"},
+	}}, nil)
+	rule := Self("id")
+
+	t.Run("Own Record", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, &member, "/users/"+member.String())
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Admin On Other Record", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, &admin, "/users/"+member.String())
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+}
+
+func TestAuthorizer_HoldsLevelsOf(t *testing.T) {
+	admin := uuid.New()
+	manager := uuid.New()
+	member := uuid.New()
+	authz := NewAuthorizer(nil, &stubAccessLevelLoader{levels: map[uuid.UUID][]string{
+		admin:   {"admin"},
+		manager: {"user-manager"},
+		member:  {"user-manager"},
+	}}, nil)
+	rule := AllOf(RequireAccessLevel("user-manager"), authz.HoldsLevelsOf("id"))
+
+	t.Run("Peer Account", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, &manager, "/users/"+member.String())
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Account With Levels The Caller Lacks", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, &manager, "/users/"+admin.String())
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "the user holds access level admin", response.Message)
+	})
+
+	t.Run("All Of Stops At First Denial", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, rule, &admin, "/users/"+member.String())
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "requires access level user-manager", response.Message)
+	})
+}
+
+// stubPermissionLoader returns fixed permissions per access level ID and
+// records the IDs it was asked for
+type stubPermissionLoader struct {
+	permissions map[int][]string
+	requested   [][]int
+	err         error
+}
+
+func (l *stubPermissionLoader) GetByAccessLevels(ctx context.Context, accessLevelIDs []int) ([]*models.Permission, error) {
+	l.requested = append(l.requested, accessLevelIDs)
+	if l.err != nil {
+		return nil, l.err
+	}
+	var result []*models.Permission
+	for _, id := range accessLevelIDs {
+		for _, name := range l.permissions[id] {
+			result = append(result, &models.Permission{Name: name})
+		}
+	}
+	return result, nil
+}
+
+func TestAuthorizer_RequirePermission(t *testing.T) {
+	reader := uuid.New()
+	admin := uuid.New()
+	// The stub numbers each user's levels from 1, so the readers level is 2
+	permissions := &stubPermissionLoader{permissions: map[int][]string{2: {"users:read"}}}
+	authz := NewAuthorizer(nil,
+		&stubAccessLevelLoader{levels: map[uuid.UUID][]string{admin: {"admin"}, reader: {"viewer", "readers"}}},
+		permissions,
+	)
+
+	t.Run("Has Permission", func(t *testing.T) {
+		permissions.requested = nil
+		recorder := serveAuthorized(t, authz, RequirePermission("users:read"), &reader, "/users/"+admin.String())
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		// Permissions come from the access levels already loaded for the caller
+		assert.Equal(t, [][]int{{1, 2}}, permissions.requested)
+	})
+
+	t.Run("Missing Permission", func(t *testing.T) {
+		recorder := serveAuthorized(t, authz, RequirePermission("users:delete"), &reader, "/users/"+admin.String())
+
+		assert.Equal(t, http.StatusForbidden, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "requires permission users:delete", response.Message)
+	})
+
+	t.Run("Any Of", func(t *testing.T) {
+		rule := AnyOf(RequireAccessLevel("admin"), RequirePermission("users:delete"))
+
+		assert.Equal(t, http.StatusOK, serveAuthorized(t, authz, rule, &admin, "/users/"+reader.String()).Code)
+		assert.Equal(t, http.StatusForbidden, serveAuthorized(t, authz, rule, &reader, "/users/"+admin.String()).Code)
+	})
+
+	t.Run("Loader Error", func(t *testing.T) {
+		failing := NewAuthorizer(nil, &stubAccessLevelLoader{levels: map[uuid.UUID][]string{reader: {"readers"}}},
+			&stubPermissionLoader{err: errors.New("database unavailable")})
+		recorder := serveAuthorized(t, failing, RequirePermission("users:read"), &reader, "/users/"+admin.String())
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
+
+func TestAuthorizer_LoaderError(t *testing.T) {
+	caller := uuid.New()
+	authz := NewAuthorizer(nil, &stubAccessLevelLoader{err: errors.New("database unavailable")}, nil)
+
+	recorder := serveAuthorized(t, authz, RequireAccessLevel("admin"), &caller, "/users/"+caller.String())
+
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestAuthorizer_DeletedCaller(t *testing.T) {
+	active := uuid.New()
+	deleted := uuid.New()
+	levels := &stubAccessLevelLoader{levels: map[uuid.UUID][]string{active: {"admin"}, deleted: {"admin"}}}
+	authz := NewAuthorizer(&stubUserLoader{deleted: map[uuid.UUID]bool{deleted: true}}, levels, nil)
+	rule := RequireAccessLevel("admin")
+
+	assert.Equal(t, http.StatusOK, serveAuthorized(t, authz, rule, &active, "/users/"+active.String()).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveAuthorized(t, authz, rule, &deleted, "/users/"+active.String()).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveAuthorized(t, authz, Self("id"), &deleted, "/users/"+deleted.String()).Code)
+
+	failing := NewAuthorizer(&stubUserLoader{err: errors.New("database unavailable")}, levels, nil)
+	assert.Equal(t, http.StatusInternalServerError, serveAuthorized(t, failing, rule, &active, "/users/"+active.String()).Code)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/wabtcdi/user_service/auth"
+)
+
+// ClientIPResolver stores the IP address of the client that sent each request
+// in the request context
+type ClientIPResolver struct {
+	trustForwardedFor bool
+}
+
+// NewClientIPResolver resolves client IPs from the connection's remote
+// address. With trustForwardedFor the last address in X-Forwarded-For is used
+// instead, which is the client as seen by the proxy in front of the service;
+// only enable it behind a proxy that sets the header.
+func NewClientIPResolver(trustForwardedFor bool) *ClientIPResolver {
+	return &ClientIPResolver{trustForwardedFor: trustForwardedFor}
+}
+
+// Resolve stores the client IP in the request context
+func (c *ClientIPResolver) Resolve(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(auth.WithClientIP(r.Context(), c.clientIP(r))))
+	})
+}
+
+func (c *ClientIPResolver) clientIP(r *http.Request) string {
+	if c.trustForwardedFor {
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	return remoteIP(r)
+}
+
+// remoteIP returns the IP address of the connection the request arrived on
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wabtcdi/user_service/auth"
+)
+
+func TestClientIPResolver_Resolve(t *testing.T) {
+	tests := []struct {
+		name              string
+		trustForwardedFor bool
+		forwardedFor      []string
+		expectedIP        string
+	}{
+		{name: "Remote Address", expectedIP: "192.0.2.10"},
+		{name: "Forwarded For Ignored", forwardedFor: []string{"203.0.113.5"}, expectedIP: "192.0.2.10"},
+		{name: "Forwarded For Trusted", trustForwardedFor: true, forwardedFor: []string{"203.0.113.5"}, expectedIP: "203.0.113.5"},
+		{name: "Last Hop Wins", trustForwardedFor: true, forwardedFor: []string{"198.51.100.1, 203.0.113.5"}, expectedIP: "203.0.113.5"},
+		{name: "Last Header Wins", trustForwardedFor: true, forwardedFor: []string{"198.51.100.1", "203.0.113.5"}, expectedIP: "203.0.113.5"},
+		{name: "Trusted Without Header", trustForwardedFor: true, expectedIP: "192.0.2.10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured string
+			handler := NewClientIPResolver(tt.trustForwardedFor).Resolve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				captured, _ = auth.ClientIPFromContext(r.Context())
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "/users", nil)
+			request.RemoteAddr = "192.0.2.10:54321"
+			for _, value := range tt.forwardedFor {
+				request.Header.Add("X-Forwarded-For", value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+
+			assert.Equal(t, tt.expectedIP, captured)
+		})
+	}
+}
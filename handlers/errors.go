@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/dto"
+)
+
+// internalErrorMessage replaces the details of unexpected failures so database
+// and other internal errors are never exposed to clients
+const internalErrorMessage = "an unexpected error occurred"
+
+// respondWithServiceError reports an error returned by the service layer,
+// choosing the status from its domain kind and the code from the error itself.
+// Errors of no known kind are reported as internal server errors.
+func respondWithServiceError(w http.ResponseWriter, error string, err error) {
+	status := statusForError(err)
+	if status == http.StatusInternalServerError {
+		respondWithError(w, status, error, internalErrorMessage)
+		return
+	}
+
+	if retryAfter := apperrors.RetryAfter(err); retryAfter > 0 {
+		setRetryAfter(w, retryAfter)
+	}
+
+	code := apperrors.Code(err)
+	if code == "" {
+		code = defaultErrorCode(status)
+	}
+	respondWithJSON(w, status, dto.ErrorResponse{
+		Error:   error,
+		Code:    code,
+		Message: err.Error(),
+		Details: fieldErrorResponses(apperrors.Fields(err)),
+	})
+}
+
+// setRetryAfter sets the Retry-After header in whole seconds, rounding up so
+// clients never retry early
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+}
+
+func fieldErrorResponses(fields []apperrors.FieldError) []dto.FieldError {
+	if len(fields) == 0 {
+		return nil
+	}
+	details := make([]dto.FieldError, 0, len(fields))
+	for _, f := range fields {
+		details = append(details, dto.FieldError{Field: f.Field, Rule: f.Rule, Message: f.Message})
+	}
+	return details
+}
+
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apperrors.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, apperrors.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, apperrors.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, apperrors.ErrRateLimited):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// defaultErrorCode is the code used for errors that do not carry their own
+func defaultErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusUnprocessableEntity:
+		return "validation_failed"
+	case http.StatusTooManyRequests:
+		return "too_many_requests"
+	default:
+		return "internal_error"
+	}
+}
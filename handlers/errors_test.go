@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/dto"
+)
+
+func TestRespondWithServiceError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedMsg    string
+	}{
+		{
+			name:           "Not Found",
+			err:            apperrors.NotFound("user_not_found", "user not found"),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "user_not_found",
+			expectedMsg:    "user not found",
+		},
+		{
+			name:           "Wrapped Not Found",
+			err:            fmt.Errorf("failed to update user: %w", apperrors.NotFound("user_not_found", "user not found")),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "user_not_found",
+			expectedMsg:    "failed to update user: user not found",
+		},
+		{
+			name:           "Conflict",
+			err:            apperrors.Conflict("email_taken", "email a@b.c is already taken"),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "email_taken",
+			expectedMsg:    "email a@b.c is already taken",
+		},
+		{
+			name:           "Validation",
+			err:            apperrors.Validation("invalid_email", "invalid email format"),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "invalid_email",
+			expectedMsg:    "invalid email format",
+		},
+		{
+			name:           "Unauthorized",
+			err:            apperrors.Unauthorized("invalid_credentials", "invalid email or password"),
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "invalid_credentials",
+			expectedMsg:    "invalid email or password",
+		},
+		{
+			name:           "Rate Limited",
+			err:            apperrors.RateLimited("account_locked", time.Minute, "account is locked"),
+			expectedStatus: http.StatusTooManyRequests,
+			expectedCode:   "account_locked",
+			expectedMsg:    "account is locked",
+		},
+		{
+			name:           "Bare Kind",
+			err:            fmt.Errorf("%w: no such thing", apperrors.ErrNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "not_found",
+			expectedMsg:    "not found: no such thing",
+		},
+		{
+			name:           "Internal",
+			err:            errors.New("pq: connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "internal_error",
+			expectedMsg:    internalErrorMessage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			respondWithServiceError(recorder, "Request failed", tt.err)
+
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+			var response dto.ErrorResponse
+			err := json.Unmarshal(recorder.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, "Request failed", response.Error)
+			assert.Equal(t, tt.expectedCode, response.Code)
+			assert.Equal(t, tt.expectedMsg, response.Message)
+		})
+	}
+}
+
+func TestRespondWithServiceError_RetryAfter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+
+	respondWithServiceError(recorder, "Login failed",
+		apperrors.RateLimited("login_throttled", 1500*time.Millisecond, "too many failed logins"))
+
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "2", recorder.Header().Get("Retry-After"))
+}
+
+func TestRespondWithError_DefaultCode(t *testing.T) {
+	recorder := httptest.NewRecorder()
+
+	respondWithError(recorder, http.StatusForbidden, "Forbidden", "missing permission")
+
+	var response dto.ErrorResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Equal(t, "forbidden", response.Code)
+}
+
+func TestDecodeRequest_BodyTooLarge(t *testing.T) {
+	body := `{"first_name":"` + strings.Repeat("a", 64) + `"}`
+	request := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	request.Body = http.MaxBytesReader(recorder, request.Body, 16)
+
+	var req dto.CreateUserRequest
+	ok := decodeRequest(recorder, request, &req)
+
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	var response dto.ErrorResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "request_too_large", response.Code)
+	assert.Equal(t, "request body must not exceed 16 bytes", response.Message)
+}
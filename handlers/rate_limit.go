@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wabtcdi/user_service/auth"
+)
+
+// RateLimiter caps how many requests each client IP may make to the routes it
+// guards within a fixed window. Counts are kept in memory, so each replica
+// limits independently.
+type RateLimiter struct {
+	limit     int
+	window    time.Duration
+	now       func() time.Time
+	mu        sync.Mutex
+	clients   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+// rateWindow counts a client's requests since start
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		now:     time.Now,
+		clients: make(map[string]*rateWindow),
+	}
+}
+
+// Limit rejects requests over the limit with 429 Too Many Requests and a
+// Retry-After header giving the seconds until the client's window resets
+func (l *RateLimiter) Limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip, ok := auth.ClientIPFromContext(r.Context())
+		if !ok {
+			ip = remoteIP(r)
+		}
+
+		if retryAfter, allowed := l.allow(ip); !allowed {
+			logrus.Warnf("Rate limit exceeded for %s on %s", ip, r.URL.Path)
+			setRetryAfter(w, retryAfter)
+			respondWithError(w, http.StatusTooManyRequests, "Too many requests",
+				"too many attempts from this address, try again later")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// allow counts a request from ip and reports whether it is within the limit,
+// or how long the client must wait when it is not
+func (l *RateLimiter) allow(ip string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	client, ok := l.clients[ip]
+	if !ok || now.Sub(client.start) >= l.window {
+		client = &rateWindow{start: now}
+		l.clients[ip] = client
+	}
+	if client.count >= l.limit {
+		return client.start.Add(l.window).Sub(now), false
+	}
+	client.count++
+	return 0, true
+}
+
+// sweep forgets clients whose window has ended, at most once per window
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	for ip, client := range l.clients {
+		if now.Sub(client.start) >= l.window {
+			delete(l.clients, ip)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wabtcdi/user_service/auth"
+)
+
+func TestRateLimiter_Limit(t *testing.T) {
+	limiter := NewRateLimiter(2, time.Minute)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	handler := limiter.Limit(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	send := func(ip string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+		request = request.WithContext(auth.WithClientIP(request.Context(), ip))
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusOK, send("203.0.113.1").Code)
+	assert.Equal(t, http.StatusOK, send("203.0.113.1").Code)
+
+	now = now.Add(20 * time.Second)
+	limited := send("203.0.113.1")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "40", limited.Header().Get("Retry-After"))
+	assert.Contains(t, limited.Body.String(), `"code":"too_many_requests"`)
+
+	assert.Equal(t, http.StatusOK, send("203.0.113.2").Code, "other clients are limited separately")
+
+	now = now.Add(40 * time.Second)
+	assert.Equal(t, http.StatusOK, send("203.0.113.1").Code, "the limit resets after the window")
+}
+
+func TestRateLimiter_Sweep(t *testing.T) {
+	limiter := NewRateLimiter(1, time.Minute)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	limiter.allow("203.0.113.1")
+	limiter.allow("203.0.113.2")
+	assert.Len(t, limiter.clients, 2)
+
+	now = now.Add(2 * time.Minute)
+	limiter.allow("203.0.113.3")
+	assert.Len(t, limiter.clients, 1, "expired clients are forgotten")
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wabtcdi/user_service/validation"
+)
+
+// decodeRequest decodes the JSON request body into req and checks it against
+// its validate tags. It writes a 400 for malformed JSON, a 413 for a body over
+// the server's size limit or a 422 listing the invalid fields, and reports
+// whether the handler should continue.
+func decodeRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		logrus.Errorf("Failed to decode request: %v", err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Request body too large",
+				fmt.Sprintf("request body must not exceed %d bytes", tooLarge.Limit))
+			return false
+		}
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return false
+	}
+
+	if err := validation.Struct(req); err != nil {
+		respondWithServiceError(w, "Invalid request", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/wabtcdi/user_service/auth"
+)
+
+const (
+	// SessionHeader and SessionCookie carry the session ID returned by login
+	SessionHeader = "X-Session-ID"
+	SessionCookie = "session_id"
+)
+
+// SessionValidator checks that a session is still active for a user
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) error
+}
+
+// SessionMiddleware rejects authenticated requests whose session has been
+// revoked or has expired. It must run after AuthMiddleware; requests without
+// a principal are passed through.
+type SessionMiddleware struct {
+	validator SessionValidator
+}
+
+func NewSessionMiddleware(validator SessionValidator) *SessionMiddleware {
+	return &SessionMiddleware{validator: validator}
+}
+
+// Check validates the session named by the X-Session-ID header or the
+// session_id cookie, falling back to the session of the access token. When
+// both are present they must agree.
+func (m *SessionMiddleware) Check(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		presented := requestSessionID(r)
+		if presented != "" && principal.SessionID != "" && presented != principal.SessionID {
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized", "session does not match the access token")
+			return
+		}
+		if presented == "" {
+			presented = principal.SessionID
+		}
+		sessionID, err := uuid.Parse(presented)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized", "missing or invalid session")
+			return
+		}
+
+		if err := m.validator.ValidateSession(r.Context(), principal.UserID, sessionID); err != nil {
+			logrus.Debugf("Rejected session %s: %v", sessionID, err)
+			respondWithServiceError(w, "Unauthorized", err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func requestSessionID(r *http.Request) string {
+	if id := r.Header.Get(SessionHeader); id != "" {
+		return id
+	}
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// setSessionCookie hands the session ID of a login to browsers
+func setSessionCookie(w http.ResponseWriter, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    sessionID,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// withUserAgent stores the request's User-Agent in its context so a session
+// opened by the request can record it
+func withUserAgent(r *http.Request) context.Context {
+	return auth.WithUserAgent(r.Context(), r.UserAgent())
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/auth"
+	"github.com/wabtcdi/user_service/dto"
+)
+
+// stubSessionValidator accepts a single known session
+type stubSessionValidator struct {
+	sessionID uuid.UUID
+	err       error
+	checked   []uuid.UUID
+}
+
+func (v *stubSessionValidator) ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	v.checked = append(v.checked, sessionID)
+	if sessionID != v.sessionID {
+		return apperrors.Unauthorized("session_revoked", "session has been revoked")
+	}
+	return v.err
+}
+
+func TestSessionMiddleware_Check(t *testing.T) {
+	sessionID := uuid.New()
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(validator *stubSessionValidator, principal *auth.Principal, configure func(*http.Request)) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/users", nil)
+		if principal != nil {
+			request = request.WithContext(auth.WithPrincipal(request.Context(), principal))
+		}
+		if configure != nil {
+			configure(request)
+		}
+		recorder := httptest.NewRecorder()
+		NewSessionMiddleware(validator).Check(next).ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("Session From Token", func(t *testing.T) {
+		validator := &stubSessionValidator{sessionID: sessionID}
+		recorder := serve(validator, &auth.Principal{UserID: uuid.New(), SessionID: sessionID.String()}, nil)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, []uuid.UUID{sessionID}, validator.checked)
+	})
+
+	t.Run("Session From Header", func(t *testing.T) {
+		validator := &stubSessionValidator{sessionID: sessionID}
+		recorder := serve(validator, &auth.Principal{UserID: uuid.New()}, func(r *http.Request) {
+			r.Header.Set(SessionHeader, sessionID.String())
+		})
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Session From Cookie", func(t *testing.T) {
+		validator := &stubSessionValidator{sessionID: sessionID}
+		recorder := serve(validator, &auth.Principal{UserID: uuid.New(), SessionID: sessionID.String()}, func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: SessionCookie, Value: sessionID.String()})
+		})
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Mismatched Session", func(t *testing.T) {
+		validator := &stubSessionValidator{sessionID: sessionID}
+		recorder := serve(validator, &auth.Principal{UserID: uuid.New(), SessionID: sessionID.String()}, func(r *http.Request) {
+			r.Header.Set(SessionHeader, uuid.New().String())
+		})
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Empty(t, validator.checked)
+	})
+
+	t.Run("Missing Session", func(t *testing.T) {
+		validator := &stubSessionValidator{sessionID: sessionID}
+		recorder := serve(validator, &auth.Principal{UserID: uuid.New()}, nil)
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Empty(t, validator.checked)
+	})
+
+	t.Run("Revoked Session", func(t *testing.T) {
+		validator := &stubSessionValidator{sessionID: sessionID}
+		recorder := serve(validator, &auth.Principal{UserID: uuid.New(), SessionID: uuid.New().String()}, nil)
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "session_revoked", response.Code)
+	})
+
+	t.Run("Expired Session", func(t *testing.T) {
+		validator := &stubSessionValidator{
+			sessionID: sessionID,
+			err:       apperrors.Unauthorized("session_expired", "session has expired"),
+		}
+		recorder := serve(validator, &auth.Principal{UserID: uuid.New(), SessionID: sessionID.String()}, nil)
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "session_expired", response.Code)
+	})
+
+	t.Run("Public Request", func(t *testing.T) {
+		validator := &stubSessionValidator{sessionID: sessionID}
+		recorder := serve(validator, nil, nil)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, validator.checked)
+	})
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -22,16 +24,14 @@ func NewUserHandler(userService service.UserServiceInterface) *UserHandler {
 
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logrus.Errorf("Failed to decode request: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	user, err := h.userService.CreateUser(r.Context(), &req)
 	if err != nil {
 		logrus.Errorf("Failed to create user: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Failed to create user", err.Error())
+		respondWithServiceError(w, "Failed to create user", err)
 		return
 	}
 
@@ -49,7 +49,7 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	user, err := h.userService.GetUser(r.Context(), id)
 	if err != nil {
 		logrus.Errorf("Failed to get user: %v", err)
-		respondWithError(w, http.StatusNotFound, "User not found", err.Error())
+		respondWithServiceError(w, "Failed to get user", err)
 		return
 	}
 
@@ -65,16 +65,14 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req dto.UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logrus.Errorf("Failed to decode request: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	user, err := h.userService.UpdateUser(r.Context(), id, &req)
 	if err != nil {
 		logrus.Errorf("Failed to update user: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Failed to update user", err.Error())
+		respondWithServiceError(w, "Failed to update user", err)
 		return
 	}
 
@@ -89,19 +87,57 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	purge := false
+	if value := r.URL.Query().Get("purge"); value != "" {
+		if purge, err = strconv.ParseBool(value); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid purge parameter", err.Error())
+			return
+		}
+	}
+
+	if purge {
+		if err := h.userService.PurgeUser(r.Context(), id); err != nil {
+			logrus.Errorf("Failed to purge user: %v", err)
+			respondWithServiceError(w, "Failed to purge user", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, map[string]string{"message": "User purged successfully"})
+		return
+	}
+
 	err = h.userService.DeleteUser(r.Context(), id)
 	if err != nil {
 		logrus.Errorf("Failed to delete user: %v", err)
-		respondWithError(w, http.StatusNotFound, "User not found", err.Error())
+		respondWithServiceError(w, "Failed to delete user", err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, map[string]string{"message": "User deleted successfully"})
 }
 
+// RestoreUser undoes the soft delete of a user
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	user, err := h.userService.RestoreUser(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("Failed to restore user: %v", err)
+		respondWithServiceError(w, "Failed to restore user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, user)
+}
+
 func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+	params := r.URL.Query()
+	page, _ := strconv.Atoi(params.Get("page"))
+	pageSize, _ := strconv.Atoi(params.Get("page_size"))
 
 	if page < 1 {
 		page = 1
@@ -110,34 +146,433 @@ func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 		pageSize = 10
 	}
 
-	response, err := h.userService.ListUsers(r.Context(), page, pageSize)
+	query := &dto.ListUsersQuery{
+		Page:        page,
+		PageSize:    pageSize,
+		Email:       params.Get("email"),
+		Name:        params.Get("name"),
+		Phone:       params.Get("phone"),
+		AccessLevel: params.Get("access_level"),
+		Sort:        params.Get("sort"),
+		Cursor:      params.Get("cursor"),
+	}
+	switch params.Get("pagination") {
+	case "", "offset":
+	case "cursor":
+		query.UseCursor = true
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid pagination parameter", `pagination must be "offset" or "cursor"`)
+		return
+	}
+	var err error
+	if query.CreatedAfter, err = timeParam(params, "created_after"); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid created_after parameter", err.Error())
+		return
+	}
+	if query.CreatedBefore, err = timeParam(params, "created_before"); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid created_before parameter", err.Error())
+		return
+	}
+	// include_deleted=true is the older spelling of deleted=include
+	deleted := params.Get("deleted")
+	if value := params.Get("include_deleted"); value != "" {
+		if deleted != "" {
+			respondWithError(w, http.StatusBadRequest, "Invalid include_deleted parameter", "use either deleted or include_deleted, not both")
+			return
+		}
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid include_deleted parameter", err.Error())
+			return
+		}
+		if includeDeleted {
+			deleted = "include"
+		}
+	}
+	switch deleted {
+	case "", "exclude":
+	case "include":
+		query.IncludeDeleted = true
+	case "only":
+		query.OnlyDeleted = true
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid deleted parameter", `deleted must be "exclude", "include" or "only"`)
+		return
+	}
+	if value := params.Get("include_total"); value != "" {
+		includeTotal, err := strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid include_total parameter", err.Error())
+			return
+		}
+		query.SkipTotal = !includeTotal
+	}
+
+	response, err := h.userService.ListUsers(r.Context(), query)
 	if err != nil {
 		logrus.Errorf("Failed to list users: %v", err)
-		respondWithError(w, http.StatusInternalServerError, "Failed to list users", err.Error())
+		respondWithServiceError(w, "Failed to list users", err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, response)
 }
 
+// SearchUsers finds users by partial or misspelt name, email or phone number
+func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	limit, _ := strconv.Atoi(params.Get("limit"))
+
+	response, err := h.userService.SearchUsers(r.Context(), params.Get("q"), limit)
+	if err != nil {
+		logrus.Errorf("Failed to search users: %v", err)
+		respondWithServiceError(w, "Failed to search users", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// timeParam parses the RFC 3339 timestamp in the named query parameter, if set
+func timeParam(params url.Values, name string) (*time.Time, error) {
+	value := params.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &at, nil
+}
+
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req dto.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logrus.Errorf("Failed to decode request: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
-	response, err := h.userService.AuthenticateUser(r.Context(), &req)
+	response, err := h.userService.AuthenticateUser(withUserAgent(r), &req)
 	if err != nil {
 		logrus.Errorf("Authentication failed: %v", err)
-		respondWithError(w, http.StatusUnauthorized, "Authentication failed", err.Error())
+		respondWithServiceError(w, "Authentication failed", err)
+		return
+	}
+
+	setSessionCookie(w, response.SessionID)
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshTokenRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	response, err := h.userService.RefreshTokens(r.Context(), &req)
+	if err != nil {
+		logrus.Errorf("Token refresh failed: %v", err)
+		respondWithServiceError(w, "Token refresh failed", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.userService.ChangePassword(r.Context(), id, &req); err != nil {
+		logrus.Errorf("Failed to change password: %v", err)
+		respondWithServiceError(w, "Failed to change password", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Password changed successfully"})
+}
+
+// RequestPasswordReset answers 202 Accepted whether or not the email belongs
+// to an account, so the endpoint cannot be used to discover users
+func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req dto.PasswordResetRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.userService.RequestPasswordReset(r.Context(), &req); err != nil {
+		logrus.Errorf("Failed to process password reset request: %v", err)
+	}
+
+	respondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+func (h *UserHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req dto.PasswordResetConfirmRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.userService.ConfirmPasswordReset(r.Context(), &req); err != nil {
+		logrus.Errorf("Password reset failed: %v", err)
+		respondWithServiceError(w, "Password reset failed", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Password has been reset"})
+}
+
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req dto.VerifyEmailRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.userService.VerifyEmail(r.Context(), &req); err != nil {
+		logrus.Errorf("Email verification failed: %v", err)
+		respondWithServiceError(w, "Email verification failed", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Email address has been verified"})
+}
+
+// ResendVerificationEmail answers 202 Accepted whether or not the email
+// belongs to an unverified account, so the endpoint cannot be used to
+// discover users
+func (h *UserHandler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResendVerificationEmailRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.userService.ResendVerificationEmail(r.Context(), &req); err != nil {
+		logrus.Errorf("Failed to resend verification email: %v", err)
+	}
+
+	respondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "If an unverified account exists for this email, a verification link has been sent",
+	})
+}
+
+// VerifyMFA completes a login that returned an MFA challenge
+func (h *UserHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req dto.MFAVerifyRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
+	response, err := h.userService.VerifyMFA(withUserAgent(r), &req)
+	if err != nil {
+		logrus.Errorf("MFA verification failed: %v", err)
+		respondWithServiceError(w, "MFA verification failed", err)
+		return
+	}
+
+	setSessionCookie(w, response.SessionID)
 	respondWithJSON(w, http.StatusOK, response)
 }
 
+// EnrollTOTP starts TOTP enrollment and returns the secret for the user's
+// authenticator app
+func (h *UserHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	enrollment, err := h.userService.EnrollTOTP(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("Failed to enroll TOTP: %v", err)
+		respondWithServiceError(w, "Failed to enroll TOTP", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, enrollment)
+}
+
+// ActivateTOTP confirms TOTP enrollment and returns the user's recovery codes
+func (h *UserHandler) ActivateTOTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req dto.ActivateTOTPRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	codes, err := h.userService.ActivateTOTP(r.Context(), id, &req)
+	if err != nil {
+		logrus.Errorf("Failed to activate TOTP: %v", err)
+		respondWithServiceError(w, "Failed to activate TOTP", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, codes)
+}
+
+// DisableTOTP turns off MFA once the user confirms it with a current code
+func (h *UserHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.userService.DisableTOTP(r.Context(), id, &req); err != nil {
+		logrus.Errorf("Failed to disable TOTP: %v", err)
+		respondWithServiceError(w, "Failed to disable TOTP", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "MFA disabled successfully"})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes once they
+// confirm it with a current code
+func (h *UserHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(r.Context(), id, &req)
+	if err != nil {
+		logrus.Errorf("Failed to regenerate recovery codes: %v", err)
+		respondWithServiceError(w, "Failed to regenerate recovery codes", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, codes)
+}
+
+// ResetMFA turns off MFA for a user who lost their authenticator
+func (h *UserHandler) ResetMFA(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	if err := h.userService.ResetMFA(r.Context(), id); err != nil {
+		logrus.Errorf("Failed to reset MFA: %v", err)
+		respondWithServiceError(w, "Failed to reset MFA", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "MFA reset successfully"})
+}
+
+// UnlockUser lifts a lockout caused by failed logins
+func (h *UserHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	if err := h.userService.UnlockUser(r.Context(), id); err != nil {
+		logrus.Errorf("Failed to unlock user: %v", err)
+		respondWithServiceError(w, "Failed to unlock user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "User unlocked successfully"})
+}
+
+// ListSessions returns the user's active sessions
+func (h *UserHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	sessions, err := h.userService.ListSessions(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("Failed to list sessions: %v", err)
+		respondWithServiceError(w, "Failed to list sessions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession ends one of the user's sessions
+func (h *UserHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	sessionID, err := uuid.Parse(vars["sid"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid session ID", err.Error())
+		return
+	}
+
+	if err := h.userService.RevokeSession(r.Context(), id, sessionID); err != nil {
+		logrus.Errorf("Failed to revoke session: %v", err)
+		respondWithServiceError(w, "Failed to revoke session", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Session revoked successfully"})
+}
+
+// RevokeAllSessions logs the user out of every session
+func (h *UserHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	if err := h.userService.RevokeAllSessions(r.Context(), id); err != nil {
+		logrus.Errorf("Failed to revoke sessions: %v", err)
+		respondWithServiceError(w, "Failed to revoke sessions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "All sessions revoked successfully"})
+}
+
 func (h *UserHandler) AssignAccessLevels(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
@@ -147,22 +582,66 @@ func (h *UserHandler) AssignAccessLevels(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req dto.AssignAccessLevelRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logrus.Errorf("Failed to decode request: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+	if !decodeRequest(w, r, &req) {
 		return
 	}
 
 	err = h.userService.AssignAccessLevels(r.Context(), id, &req)
 	if err != nil {
 		logrus.Errorf("Failed to assign access levels: %v", err)
-		respondWithError(w, http.StatusBadRequest, "Failed to assign access levels", err.Error())
+		respondWithServiceError(w, "Failed to assign access levels", err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Access levels assigned successfully"})
 }
 
+func (h *UserHandler) ReplaceAccessLevels(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req dto.ReplaceAccessLevelsRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	accessLevels, err := h.userService.ReplaceAccessLevels(r.Context(), id, &req)
+	if err != nil {
+		logrus.Errorf("Failed to replace access levels: %v", err)
+		respondWithServiceError(w, "Failed to replace access levels", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, accessLevels)
+}
+
+func (h *UserHandler) RemoveAccessLevel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	accessLevelID, err := strconv.Atoi(vars["levelId"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid access level ID", err.Error())
+		return
+	}
+
+	if err := h.userService.RemoveAccessLevel(r.Context(), id, accessLevelID); err != nil {
+		logrus.Errorf("Failed to remove access level: %v", err)
+		respondWithServiceError(w, "Failed to remove access level", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Access level removed successfully"})
+}
+
 func (h *UserHandler) GetUserAccessLevels(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
@@ -174,7 +653,25 @@ func (h *UserHandler) GetUserAccessLevels(w http.ResponseWriter, r *http.Request
 	accessLevels, err := h.userService.GetUserAccessLevels(r.Context(), id)
 	if err != nil {
 		logrus.Errorf("Failed to get user access levels: %v", err)
-		respondWithError(w, http.StatusNotFound, "Failed to get access levels", err.Error())
+		respondWithServiceError(w, "Failed to get user access levels", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, accessLevels)
+}
+
+func (h *UserHandler) GetUserEffectiveAccessLevels(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	accessLevels, err := h.userService.GetUserEffectiveAccessLevels(r.Context(), id)
+	if err != nil {
+		logrus.Errorf("Failed to get effective user access levels: %v", err)
+		respondWithServiceError(w, "Failed to get effective user access levels", err)
 		return
 	}
 
@@ -197,6 +694,7 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 func respondWithError(w http.ResponseWriter, code int, error string, message string) {
 	respondWithJSON(w, code, dto.ErrorResponse{
 		Error:   error,
+		Code:    defaultErrorCode(code),
 		Message: message,
 	})
 }
@@ -8,11 +8,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/auth"
 	"github.com/wabtcdi/user_service/dto"
 	"github.com/wabtcdi/user_service/service"
 )
@@ -54,14 +57,35 @@ func (m *MockUserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return args.Error(0)
 }
 
-func (m *MockUserService) ListUsers(ctx context.Context, page, pageSize int) (*dto.ListUsersResponse, error) {
-	args := m.Called(ctx, page, pageSize)
+func (m *MockUserService) RestoreUser(ctx context.Context, id uuid.UUID) (*dto.UserResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) PurgeUser(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ListUsers(ctx context.Context, query *dto.ListUsersQuery) (*dto.ListUsersResponse, error) {
+	args := m.Called(ctx, query)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*dto.ListUsersResponse), args.Error(1)
 }
 
+func (m *MockUserService) SearchUsers(ctx context.Context, query string, limit int) (*dto.SearchUsersResponse, error) {
+	args := m.Called(ctx, query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.SearchUsersResponse), args.Error(1)
+}
+
 func (m *MockUserService) AuthenticateUser(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error) {
 	args := m.Called(ctx, req)
 	if args.Get(0) == nil {
@@ -70,6 +94,109 @@ func (m *MockUserService) AuthenticateUser(ctx context.Context, req *dto.LoginRe
 	return args.Get(0).(*dto.LoginResponse), args.Error(1)
 }
 
+func (m *MockUserService) RefreshTokens(ctx context.Context, req *dto.RefreshTokenRequest) (*dto.TokenResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.TokenResponse), args.Error(1)
+}
+
+func (m *MockUserService) ChangePassword(ctx context.Context, userID uuid.UUID, req *dto.ChangePasswordRequest) error {
+	args := m.Called(ctx, userID, req)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RequestPasswordReset(ctx context.Context, req *dto.PasswordResetRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ConfirmPasswordReset(ctx context.Context, req *dto.PasswordResetConfirmRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUserService) VerifyEmail(ctx context.Context, req *dto.VerifyEmailRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResendVerificationEmail(ctx context.Context, req *dto.ResendVerificationEmailRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUserService) UnlockUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) EnrollTOTP(ctx context.Context, userID uuid.UUID) (*dto.TOTPEnrollmentResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.TOTPEnrollmentResponse), args.Error(1)
+}
+
+func (m *MockUserService) ActivateTOTP(ctx context.Context, userID uuid.UUID, req *dto.ActivateTOTPRequest) (*dto.RecoveryCodesResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.RecoveryCodesResponse), args.Error(1)
+}
+
+func (m *MockUserService) VerifyMFA(ctx context.Context, req *dto.MFAVerifyRequest) (*dto.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.LoginResponse), args.Error(1)
+}
+
+func (m *MockUserService) DisableTOTP(ctx context.Context, userID uuid.UUID, req *dto.MFACodeRequest) error {
+	args := m.Called(ctx, userID, req)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req *dto.MFACodeRequest) (*dto.RecoveryCodesResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.RecoveryCodesResponse), args.Error(1)
+}
+
+func (m *MockUserService) ResetMFA(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ListSessions(ctx context.Context, userID uuid.UUID) ([]dto.SessionResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dto.SessionResponse), args.Error(1)
+}
+
+func (m *MockUserService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
 func (m *MockUserService) AssignAccessLevels(ctx context.Context, userID uuid.UUID, req *dto.AssignAccessLevelRequest) error {
 	args := m.Called(ctx, userID, req)
 	return args.Error(0)
@@ -83,6 +210,35 @@ func (m *MockUserService) GetUserAccessLevels(ctx context.Context, userID uuid.U
 	return args.Get(0).([]dto.AccessLevelResponse), args.Error(1)
 }
 
+func (m *MockUserService) ReplaceAccessLevels(ctx context.Context, userID uuid.UUID, req *dto.ReplaceAccessLevelsRequest) ([]dto.AccessLevelResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]dto.AccessLevelResponse), args.Error(1)
+}
+
+func (m *MockUserService) RemoveAccessLevel(ctx context.Context, userID uuid.UUID, accessLevelID int) error {
+	args := m.Called(ctx, userID, accessLevelID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) GetUserEffectiveAccessLevels(ctx context.Context, userID uuid.UUID) (*dto.UserAccessLevelsResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.UserAccessLevelsResponse), args.Error(1)
+}
+
+func (m *MockUserService) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func TestCreateUser(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockService := new(MockUserService)
@@ -135,6 +291,28 @@ func TestCreateUser(t *testing.T) {
 		assert.Equal(t, "Invalid request body", response.Error)
 	})
 
+	t.Run("Invalid Fields", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		body := []byte(`{"first_name":"","last_name":"Doe","email":"not-an-email"}`)
+		request := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.CreateUser(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "validation_failed", response.Code)
+		assert.Equal(t, []dto.FieldError{
+			{Field: "first_name", Rule: "required", Message: "first_name is required"},
+			{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+			{Field: "password", Rule: "required", Message: "password is required"},
+		}, response.Details)
+		mockService.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+	})
+
 	t.Run("Service Error", func(t *testing.T) {
 		mockService := new(MockUserService)
 		handler := NewUserHandler(mockService)
@@ -146,7 +324,7 @@ func TestCreateUser(t *testing.T) {
 			Password:  "password123",
 		}
 
-		mockService.On("CreateUser", mock.Anything, req).Return(nil, errors.New("user already exists"))
+		mockService.On("CreateUser", mock.Anything, req).Return(nil, apperrors.Conflict("email_taken", "user with email john.doe@example.com already exists"))
 
 		body, _ := json.Marshal(req)
 		request := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
@@ -154,7 +332,7 @@ func TestCreateUser(t *testing.T) {
 
 		handler.CreateUser(recorder, request)
 
-		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, http.StatusConflict, recorder.Code)
 		var response dto.ErrorResponse
 		json.Unmarshal(recorder.Body.Bytes(), &response)
 		assert.Equal(t, "Failed to create user", response.Error)
@@ -214,7 +392,7 @@ func TestGetUser(t *testing.T) {
 		handler := NewUserHandler(mockService)
 
 		userID := uuid.New()
-		mockService.On("GetUser", mock.Anything, userID).Return(nil, errors.New("user not found"))
+		mockService.On("GetUser", mock.Anything, userID).Return(nil, apperrors.NotFound("user_not_found", "user not found"))
 
 		request := httptest.NewRequest(http.MethodGet, "/users/"+userID.String(), nil)
 		recorder := httptest.NewRecorder()
@@ -226,7 +404,7 @@ func TestGetUser(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, recorder.Code)
 		var response dto.ErrorResponse
 		json.Unmarshal(recorder.Body.Bytes(), &response)
-		assert.Equal(t, "User not found", response.Error)
+		assert.Equal(t, "Failed to get user", response.Error)
 		mockService.AssertExpectations(t)
 	})
 }
@@ -313,7 +491,7 @@ func TestUpdateUser(t *testing.T) {
 			FirstName: "Jane",
 		}
 
-		mockService.On("UpdateUser", mock.Anything, userID, req).Return(nil, errors.New("update failed"))
+		mockService.On("UpdateUser", mock.Anything, userID, req).Return(nil, apperrors.Conflict("email_taken", "email taken@example.com is already taken"))
 
 		body, _ := json.Marshal(req)
 		request := httptest.NewRequest(http.MethodPut, "/users/"+userID.String(), bytes.NewReader(body))
@@ -323,7 +501,7 @@ func TestUpdateUser(t *testing.T) {
 		router.HandleFunc("/users/{id}", handler.UpdateUser)
 		router.ServeHTTP(recorder, request)
 
-		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, http.StatusConflict, recorder.Code)
 		mockService.AssertExpectations(t)
 	})
 }
@@ -369,7 +547,7 @@ func TestDeleteUser(t *testing.T) {
 		handler := NewUserHandler(mockService)
 
 		userID := uuid.New()
-		mockService.On("DeleteUser", mock.Anything, userID).Return(errors.New("user not found"))
+		mockService.On("DeleteUser", mock.Anything, userID).Return(apperrors.NotFound("user_not_found", "user not found"))
 
 		request := httptest.NewRequest(http.MethodDelete, "/users/"+userID.String(), nil)
 		recorder := httptest.NewRecorder()
@@ -381,6 +559,90 @@ func TestDeleteUser(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, recorder.Code)
 		mockService.AssertExpectations(t)
 	})
+
+	t.Run("Purge", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		mockService.On("PurgeUser", mock.Anything, userID).Return(nil)
+
+		request := httptest.NewRequest(http.MethodDelete, "/users/"+userID.String()+"?purge=true", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}", handler.DeleteUser)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response map[string]string
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "User purged successfully", response["message"])
+		mockService.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Purge", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		request := httptest.NewRequest(http.MethodDelete, "/users/"+uuid.NewString()+"?purge=yes", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}", handler.DeleteUser)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockService.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+		mockService.AssertNotCalled(t, "PurgeUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRestoreUser(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		mockService.On("RestoreUser", mock.Anything, userID).
+			Return(&dto.UserResponse{ID: userID, Email: "john@example.com"}, nil)
+
+		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/restore", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/restore", handler.RestoreUser)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response dto.UserResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, userID, response.ID)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Email Taken", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		mockService.On("RestoreUser", mock.Anything, userID).
+			Return(nil, apperrors.Conflict("email_taken", "email john@example.com is already taken"))
+
+		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/restore", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/restore", handler.RestoreUser)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "email_taken", response.Code)
+		mockService.AssertExpectations(t)
+	})
 }
 
 func TestListUsers(t *testing.T) {
@@ -388,6 +650,7 @@ func TestListUsers(t *testing.T) {
 		mockService := new(MockUserService)
 		handler := NewUserHandler(mockService)
 
+		total := 1
 		expectedResponse := &dto.ListUsersResponse{
 			Users: []dto.UserResponse{
 				{
@@ -397,12 +660,12 @@ func TestListUsers(t *testing.T) {
 					Email:     "john@example.com",
 				},
 			},
-			Total:    1,
+			Total:    &total,
 			Page:     1,
 			PageSize: 10,
 		}
 
-		mockService.On("ListUsers", mock.Anything, 1, 10).Return(expectedResponse, nil)
+		mockService.On("ListUsers", mock.Anything, &dto.ListUsersQuery{Page: 1, PageSize: 10}).Return(expectedResponse, nil)
 
 		request := httptest.NewRequest(http.MethodGet, "/users?page=1&page_size=10", nil)
 		recorder := httptest.NewRecorder()
@@ -422,12 +685,11 @@ func TestListUsers(t *testing.T) {
 
 		expectedResponse := &dto.ListUsersResponse{
 			Users:    []dto.UserResponse{},
-			Total:    0,
 			Page:     1,
 			PageSize: 10,
 		}
 
-		mockService.On("ListUsers", mock.Anything, 1, 10).Return(expectedResponse, nil)
+		mockService.On("ListUsers", mock.Anything, &dto.ListUsersQuery{Page: 1, PageSize: 10}).Return(expectedResponse, nil)
 
 		request := httptest.NewRequest(http.MethodGet, "/users", nil)
 		recorder := httptest.NewRecorder()
@@ -442,7 +704,7 @@ func TestListUsers(t *testing.T) {
 		mockService := new(MockUserService)
 		handler := NewUserHandler(mockService)
 
-		mockService.On("ListUsers", mock.Anything, 1, 10).Return(nil, errors.New("database error"))
+		mockService.On("ListUsers", mock.Anything, &dto.ListUsersQuery{Page: 1, PageSize: 10}).Return(nil, errors.New("database error"))
 
 		request := httptest.NewRequest(http.MethodGet, "/users", nil)
 		recorder := httptest.NewRecorder()
@@ -455,92 +717,1096 @@ func TestListUsers(t *testing.T) {
 		assert.Equal(t, "Failed to list users", response.Error)
 		mockService.AssertExpectations(t)
 	})
-}
 
-func TestLogin(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
+	t.Run("Filters And Sort", func(t *testing.T) {
 		mockService := new(MockUserService)
 		handler := NewUserHandler(mockService)
 
-		req := &dto.LoginRequest{
-			Email:    "john.doe@example.com",
-			Password: "password123",
-		}
-
-		expectedResponse := &dto.LoginResponse{
-			User: dto.UserResponse{
-				ID:        uuid.New(),
-				FirstName: "John",
-				LastName:  "Doe",
-				Email:     "john.doe@example.com",
-			},
-			Message: "Login successful",
+		after := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		before := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+		expectedQuery := &dto.ListUsersQuery{
+			Page:           2,
+			PageSize:       20,
+			Email:          "jo",
+			Name:           "Do",
+			Phone:          "+1234567890",
+			AccessLevel:    "admin",
+			CreatedAfter:   &after,
+			CreatedBefore:  &before,
+			IncludeDeleted: true,
+			Sort:           "last_name,-created_at",
 		}
+		mockService.On("ListUsers", mock.Anything, expectedQuery).
+			Return(&dto.ListUsersResponse{Users: []dto.UserResponse{}, Page: 2, PageSize: 20}, nil)
 
-		mockService.On("AuthenticateUser", mock.Anything, req).Return(expectedResponse, nil)
-
-		body, _ := json.Marshal(req)
-		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		request := httptest.NewRequest(http.MethodGet, "/users?page=2&page_size=20&email=jo&name=Do&phone=%2B1234567890"+
+			"&access_level=admin&created_after=2026-01-01T00:00:00Z&created_before=2026-02-01T00:00:00Z"+
+			"&include_deleted=true&sort=last_name,-created_at", nil)
 		recorder := httptest.NewRecorder()
 
-		handler.Login(recorder, request)
+		handler.ListUsers(recorder, request)
 
 		assert.Equal(t, http.StatusOK, recorder.Code)
-		var response dto.LoginResponse
-		json.Unmarshal(recorder.Body.Bytes(), &response)
-		assert.Equal(t, expectedResponse.Message, response.Message)
 		mockService.AssertExpectations(t)
 	})
 
-	t.Run("Invalid Request Body", func(t *testing.T) {
+	t.Run("Invalid Parameters", func(t *testing.T) {
+		for _, query := range []string{"created_after=yesterday", "created_before=2026-01-01", "include_deleted=maybe",
+			"pagination=keyset", "include_total=sometimes", "deleted=all", "deleted=only&include_deleted=true",
+			"deleted=exclude&include_deleted=false"} {
+			mockService := new(MockUserService)
+			handler := NewUserHandler(mockService)
+
+			request := httptest.NewRequest(http.MethodGet, "/users?"+query, nil)
+			recorder := httptest.NewRecorder()
+
+			handler.ListUsers(recorder, request)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code, query)
+			mockService.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
 		mockService := new(MockUserService)
 		handler := NewUserHandler(mockService)
 
-		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader([]byte("invalid json")))
+		expectedQuery := &dto.ListUsersQuery{Page: 1, PageSize: 10, Cursor: "abc.def", SkipTotal: true}
+		mockService.On("ListUsers", mock.Anything, expectedQuery).
+			Return(&dto.ListUsersResponse{Users: []dto.UserResponse{}, PageSize: 10, NextCursor: "next", PrevCursor: "prev"}, nil)
+
+		request := httptest.NewRequest(http.MethodGet, "/users?cursor=abc.def&include_total=false", nil)
 		recorder := httptest.NewRecorder()
 
-		handler.Login(recorder, request)
+		handler.ListUsers(recorder, request)
 
-		assert.Equal(t, http.StatusBadRequest, recorder.Code)
-		var response dto.ErrorResponse
-		json.Unmarshal(recorder.Body.Bytes(), &response)
-		assert.Equal(t, "Invalid request body", response.Error)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var body map[string]any
+		json.Unmarshal(recorder.Body.Bytes(), &body)
+		assert.Equal(t, "next", body["next_cursor"])
+		assert.Equal(t, "prev", body["prev_cursor"])
+		assert.NotContains(t, body, "total")
+		assert.NotContains(t, body, "page")
+		mockService.AssertExpectations(t)
 	})
 
-	t.Run("Authentication Failed", func(t *testing.T) {
+	t.Run("Only Deleted", func(t *testing.T) {
 		mockService := new(MockUserService)
 		handler := NewUserHandler(mockService)
 
-		req := &dto.LoginRequest{
-			Email:    "john.doe@example.com",
-			Password: "wrongpassword",
-		}
-
-		mockService.On("AuthenticateUser", mock.Anything, req).Return(nil, errors.New("invalid credentials"))
+		mockService.On("ListUsers", mock.Anything, &dto.ListUsersQuery{Page: 1, PageSize: 10, OnlyDeleted: true}).
+			Return(&dto.ListUsersResponse{Users: []dto.UserResponse{}, Page: 1, PageSize: 10}, nil)
 
-		body, _ := json.Marshal(req)
-		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		request := httptest.NewRequest(http.MethodGet, "/users?deleted=only", nil)
 		recorder := httptest.NewRecorder()
 
-		handler.Login(recorder, request)
+		handler.ListUsers(recorder, request)
 
-		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
-		var response dto.ErrorResponse
-		json.Unmarshal(recorder.Body.Bytes(), &response)
-		assert.Equal(t, "Authentication failed", response.Error)
+		assert.Equal(t, http.StatusOK, recorder.Code)
 		mockService.AssertExpectations(t)
 	})
-}
 
-func TestAssignAccessLevels(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
+	t.Run("Start Cursor Pagination", func(t *testing.T) {
 		mockService := new(MockUserService)
 		handler := NewUserHandler(mockService)
 
-		userID := uuid.New()
-		req := &dto.AssignAccessLevelRequest{
-			AccessLevelIDs: []int{1, 2, 3},
-		}
+		mockService.On("ListUsers", mock.Anything, &dto.ListUsersQuery{Page: 1, PageSize: 10, UseCursor: true}).
+			Return(&dto.ListUsersResponse{Users: []dto.UserResponse{}, PageSize: 10}, nil)
+
+		request := httptest.NewRequest(http.MethodGet, "/users?pagination=cursor", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.ListUsers(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Sort", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		mockService.On("ListUsers", mock.Anything, &dto.ListUsersQuery{Page: 1, PageSize: 10, Sort: "password"}).
+			Return(nil, apperrors.Validation("invalid_sort", "cannot sort users by \"password\""))
+
+		request := httptest.NewRequest(http.MethodGet, "/users?sort=password", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.ListUsers(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "invalid_sort", response.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestSearchUsers(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		expectedResponse := &dto.SearchUsersResponse{Users: []dto.UserResponse{
+			{ID: uuid.New(), FirstName: "John", LastName: "Smith", Email: "john.smith@example.com"},
+		}}
+		mockService.On("SearchUsers", mock.Anything, "jon smith", 5).Return(expectedResponse, nil)
+
+		request := httptest.NewRequest(http.MethodGet, "/users/search?q=jon+smith&limit=5", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.SearchUsers(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response dto.SearchUsersResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, expectedResponse.Users[0].ID, response.Users[0].ID)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Query", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		mockService.On("SearchUsers", mock.Anything, "", 0).
+			Return(nil, apperrors.Validation("invalid_search_query", "search query must be between 2 and 100 characters"))
+
+		request := httptest.NewRequest(http.MethodGet, "/users/search", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.SearchUsers(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "invalid_search_query", response.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestLogin(t *testing.T) {
+	t.Run("Missing Password", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		body := []byte(`{"email":"john.doe@example.com"}`)
+		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.Login(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, []dto.FieldError{
+			{Field: "password", Rule: "required", Message: "password is required"},
+		}, response.Details)
+		mockService.AssertNotCalled(t, "AuthenticateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.LoginRequest{
+			Email:    "john.doe@example.com",
+			Password: "password123",
+		}
+
+		expectedResponse := &dto.LoginResponse{
+			User: &dto.UserResponse{
+				ID:        uuid.New(),
+				FirstName: "John",
+				LastName:  "Doe",
+				Email:     "john.doe@example.com",
+			},
+			Message: "Login successful",
+		}
+
+		mockService.On("AuthenticateUser", mock.Anything, req).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.Login(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response dto.LoginResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, expectedResponse.Message, response.Message)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Request Body", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader([]byte("invalid json")))
+		recorder := httptest.NewRecorder()
+
+		handler.Login(recorder, request)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "Invalid request body", response.Error)
+	})
+
+	t.Run("Authentication Failed", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.LoginRequest{
+			Email:    "john.doe@example.com",
+			Password: "wrongpassword",
+		}
+
+		mockService.On("AuthenticateUser", mock.Anything, req).Return(nil, apperrors.Unauthorized("invalid_credentials", "invalid email or password"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.Login(recorder, request)
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "Authentication failed", response.Error)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Account Locked", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.LoginRequest{
+			Email:    "john.doe@example.com",
+			Password: "password123",
+		}
+
+		mockService.On("AuthenticateUser", mock.Anything, req).
+			Return(nil, apperrors.RateLimited("account_locked", 10*time.Minute, "account is locked after too many failed logins"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.Login(recorder, request)
+
+		assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		assert.Equal(t, "600", recorder.Header().Get("Retry-After"))
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "account_locked", response.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Session Cookie", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.LoginRequest{
+			Email:    "john.doe@example.com",
+			Password: "password123",
+		}
+		sessionID := uuid.New().String()
+		hasUserAgent := mock.MatchedBy(func(ctx context.Context) bool {
+			userAgent, _ := auth.UserAgentFromContext(ctx)
+			return userAgent == "test-agent/1.0"
+		})
+		mockService.On("AuthenticateUser", hasUserAgent, req).
+			Return(&dto.LoginResponse{Message: "Login successful", SessionID: sessionID}, nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		request.Header.Set("User-Agent", "test-agent/1.0")
+		recorder := httptest.NewRecorder()
+
+		handler.Login(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		cookies := recorder.Result().Cookies()
+		if assert.Len(t, cookies, 1) {
+			assert.Equal(t, SessionCookie, cookies[0].Name)
+			assert.Equal(t, sessionID, cookies[0].Value)
+			assert.True(t, cookies[0].HttpOnly)
+			assert.True(t, cookies[0].Secure)
+			assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+		}
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestRefreshToken(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.RefreshTokenRequest{RefreshToken: "refresh-token"}
+		expectedResponse := &dto.TokenResponse{
+			AccessToken:  "access-token",
+			TokenType:    "Bearer",
+			ExpiresIn:    900,
+			RefreshToken: "rotated-token",
+		}
+
+		mockService.On("RefreshTokens", mock.Anything, req).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.RefreshToken(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response dto.TokenResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, *expectedResponse, response)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Request Body", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		request := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewReader([]byte("invalid json")))
+		recorder := httptest.NewRecorder()
+
+		handler.RefreshToken(recorder, request)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Refresh Failed", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.RefreshTokenRequest{RefreshToken: "revoked-token"}
+		mockService.On("RefreshTokens", mock.Anything, req).Return(nil, apperrors.Unauthorized("refresh_token_reused", "refresh token reuse detected"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.RefreshToken(recorder, request)
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "Token refresh failed", response.Error)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		req := &dto.ChangePasswordRequest{CurrentPassword: "oldpassword123", NewPassword: "newpassword123"}
+		mockService.On("ChangePassword", mock.Anything, userID, req).Return(nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPut, "/users/"+userID.String()+"/password", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/password", handler.ChangePassword)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid User ID", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		request := httptest.NewRequest(http.MethodPut, "/users/invalid-uuid/password", bytes.NewReader([]byte(`{}`)))
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/password", handler.ChangePassword)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Missing Current Password", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		request := httptest.NewRequest(http.MethodPut, "/users/"+userID.String()+"/password",
+			bytes.NewReader([]byte(`{"new_password":"newpassword123"}`)))
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/password", handler.ChangePassword)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, []dto.FieldError{
+			{Field: "current_password", Rule: "required", Message: "current_password is required"},
+		}, response.Details)
+		mockService.AssertNotCalled(t, "ChangePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong Current Password", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		req := &dto.ChangePasswordRequest{CurrentPassword: "wrongpassword", NewPassword: "newpassword123"}
+		mockService.On("ChangePassword", mock.Anything, userID, req).
+			Return(apperrors.Validation("invalid_current_password", "current password is incorrect"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPut, "/users/"+userID.String()+"/password", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/password", handler.ChangePassword)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "invalid_current_password", response.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	t.Run("Accepted", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.PasswordResetRequest{Email: "john.doe@example.com"}
+		mockService.On("RequestPasswordReset", mock.Anything, req).Return(nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/password-reset/request", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.RequestPasswordReset(recorder, request)
+
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Failure Is Not Revealed", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.PasswordResetRequest{Email: "john.doe@example.com"}
+		mockService.On("RequestPasswordReset", mock.Anything, req).Return(errors.New("smtp unavailable"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/password-reset/request", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.RequestPasswordReset(recorder, request)
+
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Email", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		request := httptest.NewRequest(http.MethodPost, "/auth/password-reset/request", bytes.NewReader([]byte(`{"email":"not-an-email"}`)))
+		recorder := httptest.NewRecorder()
+
+		handler.RequestPasswordReset(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		mockService.AssertNotCalled(t, "RequestPasswordReset", mock.Anything, mock.Anything)
+	})
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.PasswordResetConfirmRequest{Token: "reset-token", NewPassword: "newpassword123"}
+		mockService.On("ConfirmPasswordReset", mock.Anything, req).Return(nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/password-reset/confirm", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.ConfirmPasswordReset(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Missing Password", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		request := httptest.NewRequest(http.MethodPost, "/auth/password-reset/confirm",
+			bytes.NewReader([]byte(`{"token":"reset-token"}`)))
+		recorder := httptest.NewRecorder()
+
+		handler.ConfirmPasswordReset(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		mockService.AssertNotCalled(t, "ConfirmPasswordReset", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid Token", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.PasswordResetConfirmRequest{Token: "spent-token", NewPassword: "newpassword123"}
+		mockService.On("ConfirmPasswordReset", mock.Anything, req).
+			Return(apperrors.Validation("invalid_reset_token", "password reset token is invalid or has expired"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/password-reset/confirm", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.ConfirmPasswordReset(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "invalid_reset_token", response.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.VerifyEmailRequest{Token: "verify-token"}
+		mockService.On("VerifyEmail", mock.Anything, req).Return(nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/verify-email", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.VerifyEmail(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Missing Token", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		request := httptest.NewRequest(http.MethodPost, "/auth/verify-email", bytes.NewReader([]byte(`{}`)))
+		recorder := httptest.NewRecorder()
+
+		handler.VerifyEmail(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		mockService.AssertNotCalled(t, "VerifyEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Invalid Token", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.VerifyEmailRequest{Token: "expired-token"}
+		mockService.On("VerifyEmail", mock.Anything, req).
+			Return(apperrors.Validation("invalid_verification_token", "email verification token is invalid or has expired"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/verify-email", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.VerifyEmail(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "invalid_verification_token", response.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	t.Run("Accepted", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.ResendVerificationEmailRequest{Email: "john.doe@example.com"}
+		mockService.On("ResendVerificationEmail", mock.Anything, req).Return(nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/verify-email/resend", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.ResendVerificationEmail(recorder, request)
+
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Failure Is Not Revealed", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.ResendVerificationEmailRequest{Email: "john.doe@example.com"}
+		mockService.On("ResendVerificationEmail", mock.Anything, req).Return(errors.New("smtp unavailable"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/verify-email/resend", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.ResendVerificationEmail(recorder, request)
+
+		assert.Equal(t, http.StatusAccepted, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestVerifyMFA(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.MFAVerifyRequest{MFAToken: "challenge-token", Code: "123456"}
+		expectedResponse := &dto.LoginResponse{
+			User:          &dto.UserResponse{ID: uuid.New(), Email: "john.doe@example.com"},
+			Message:       "Login successful",
+			TokenResponse: dto.TokenResponse{AccessToken: "access-token", TokenType: "Bearer"},
+		}
+		mockService.On("VerifyMFA", mock.Anything, req).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/mfa/verify", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.VerifyMFA(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response dto.LoginResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "access-token", response.AccessToken)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Missing Code", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		body := []byte(`{"mfa_token":"challenge-token"}`)
+		request := httptest.NewRequest(http.MethodPost, "/auth/mfa/verify", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.VerifyMFA(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		mockService.AssertNotCalled(t, "VerifyMFA", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong Code", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		req := &dto.MFAVerifyRequest{MFAToken: "challenge-token", Code: "000000"}
+		mockService.On("VerifyMFA", mock.Anything, req).
+			Return(nil, apperrors.Unauthorized("invalid_mfa_code", "MFA code is incorrect"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/auth/mfa/verify", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		handler.VerifyMFA(recorder, request)
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "invalid_mfa_code", response.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		enrollment := &dto.TOTPEnrollmentResponse{
+			Secret:     "JBSWY3DPEHPK3PXP",
+			OTPAuthURI: "otpauth://totp/user_service:john@example.com?secret=JBSWY3DPEHPK3PXP",
+		}
+		mockService.On("EnrollTOTP", mock.Anything, userID).Return(enrollment, nil)
+
+		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/mfa/totp", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/mfa/totp", handler.EnrollTOTP)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		var response dto.TOTPEnrollmentResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, *enrollment, response)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Already Enabled", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		mockService.On("EnrollTOTP", mock.Anything, userID).
+			Return(nil, apperrors.Conflict("mfa_already_enabled", "MFA is already enabled"))
+
+		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/mfa/totp", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/mfa/totp", handler.EnrollTOTP)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestActivateTOTP(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		req := &dto.ActivateTOTPRequest{Code: "123456"}
+		codes := &dto.RecoveryCodesResponse{RecoveryCodes: []string{"abcde-fghij", "klmno-pqrst"}}
+		mockService.On("ActivateTOTP", mock.Anything, userID, req).Return(codes, nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/mfa/totp/activate", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/mfa/totp/activate", handler.ActivateTOTP)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response dto.RecoveryCodesResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, codes.RecoveryCodes, response.RecoveryCodes)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Wrong Code", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		req := &dto.ActivateTOTPRequest{Code: "000000"}
+		mockService.On("ActivateTOTP", mock.Anything, userID, req).
+			Return(nil, apperrors.Validation("invalid_mfa_code", "MFA code is incorrect"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/mfa/totp/activate", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/mfa/totp/activate", handler.ActivateTOTP)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestDisableTOTP(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		req := &dto.MFACodeRequest{Code: "123456"}
+		mockService.On("DisableTOTP", mock.Anything, userID, req).Return(nil)
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/mfa/totp/disable", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/mfa/totp/disable", handler.DisableTOTP)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Required", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		req := &dto.MFACodeRequest{Code: "123456"}
+		mockService.On("DisableTOTP", mock.Anything, userID, req).
+			Return(apperrors.Conflict("mfa_required", "MFA is required for the user's access levels"))
+
+		body, _ := json.Marshal(req)
+		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/mfa/totp/disable", bytes.NewReader(body))
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/mfa/totp/disable", handler.DisableTOTP)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	userID := uuid.New()
+	req := &dto.MFACodeRequest{Code: "123456"}
+	codes := &dto.RecoveryCodesResponse{RecoveryCodes: []string{"abcde-fghij"}}
+	mockService.On("RegenerateRecoveryCodes", mock.Anything, userID, req).Return(codes, nil)
+
+	body, _ := json.Marshal(req)
+	request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/mfa/recovery-codes", bytes.NewReader(body))
+	recorder := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}/mfa/recovery-codes", handler.RegenerateRecoveryCodes)
+	router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response dto.RecoveryCodesResponse
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, codes.RecoveryCodes, response.RecoveryCodes)
+	mockService.AssertExpectations(t)
+}
+
+func TestResetMFA(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	userID := uuid.New()
+	mockService.On("ResetMFA", mock.Anything, userID).Return(nil)
+
+	request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/mfa/reset", nil)
+	recorder := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}/mfa/reset", handler.ResetMFA)
+	router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestUnlockUser(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		mockService.On("UnlockUser", mock.Anything, userID).Return(nil)
+
+		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/unlock", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/unlock", handler.UnlockUser)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response map[string]string
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "User unlocked successfully", response["message"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid User ID", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		request := httptest.NewRequest(http.MethodPost, "/users/invalid-id/unlock", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/unlock", handler.UnlockUser)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockService.AssertNotCalled(t, "UnlockUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("User Not Found", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		mockService.On("UnlockUser", mock.Anything, userID).Return(apperrors.NotFound("user_not_found", "user not found"))
+
+		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/unlock", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/unlock", handler.UnlockUser)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestListSessions(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		sessions := []dto.SessionResponse{
+			{ID: uuid.New(), UserAgent: "test-agent/1.0", IPAddress: "203.0.113.7", Current: true},
+			{ID: uuid.New()},
+		}
+		mockService.On("ListSessions", mock.Anything, userID).Return(sessions, nil)
+
+		request := httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/sessions", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/sessions", handler.ListSessions)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response []dto.SessionResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Len(t, response, 2)
+		assert.True(t, response[0].Current)
+		assert.Equal(t, "203.0.113.7", response[0].IPAddress)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("User Not Found", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		mockService.On("ListSessions", mock.Anything, userID).Return(nil, apperrors.NotFound("user_not_found", "user not found"))
+
+		request := httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/sessions", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/sessions", handler.ListSessions)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID, sessionID := uuid.New(), uuid.New()
+		mockService.On("RevokeSession", mock.Anything, userID, sessionID).Return(nil)
+
+		request := httptest.NewRequest(http.MethodDelete, "/users/"+userID.String()+"/sessions/"+sessionID.String(), nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/sessions/{sid}", handler.RevokeSession)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response map[string]string
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "Session revoked successfully", response["message"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Session ID", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		request := httptest.NewRequest(http.MethodDelete, "/users/"+uuid.New().String()+"/sessions/invalid-id", nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/sessions/{sid}", handler.RevokeSession)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockService.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Session Not Found", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID, sessionID := uuid.New(), uuid.New()
+		mockService.On("RevokeSession", mock.Anything, userID, sessionID).
+			Return(apperrors.NotFound("session_not_found", "session not found"))
+
+		request := httptest.NewRequest(http.MethodDelete, "/users/"+userID.String()+"/sessions/"+sessionID.String(), nil)
+		recorder := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/sessions/{sid}", handler.RevokeSession)
+		router.ServeHTTP(recorder, request)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, "session_not_found", response.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	userID := uuid.New()
+	mockService.On("RevokeAllSessions", mock.Anything, userID).Return(nil)
+
+	request := httptest.NewRequest(http.MethodDelete, "/users/"+userID.String()+"/sessions", nil)
+	recorder := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}/sessions", handler.RevokeAllSessions)
+	router.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var response map[string]string
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	assert.Equal(t, "All sessions revoked successfully", response["message"])
+	mockService.AssertExpectations(t)
+}
+
+func TestAssignAccessLevels(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		handler := NewUserHandler(mockService)
+
+		userID := uuid.New()
+		req := &dto.AssignAccessLevelRequest{
+			AccessLevelIDs: []int{1, 2, 3},
+		}
 
 		mockService.On("AssignAccessLevels", mock.Anything, userID, req).Return(nil)
 
@@ -602,7 +1868,7 @@ func TestAssignAccessLevels(t *testing.T) {
 			AccessLevelIDs: []int{1, 2, 3},
 		}
 
-		mockService.On("AssignAccessLevels", mock.Anything, userID, req).Return(errors.New("assignment failed"))
+		mockService.On("AssignAccessLevels", mock.Anything, userID, req).Return(apperrors.Validation("access_levels_not_found", "access levels not found: 99"))
 
 		body, _ := json.Marshal(req)
 		request := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/access-levels", bytes.NewReader(body))
@@ -612,7 +1878,7 @@ func TestAssignAccessLevels(t *testing.T) {
 		router.HandleFunc("/users/{id}/access-levels", handler.AssignAccessLevels)
 		router.ServeHTTP(recorder, request)
 
-		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
 		mockService.AssertExpectations(t)
 	})
 }
@@ -663,7 +1929,7 @@ func TestGetUserAccessLevels(t *testing.T) {
 		handler := NewUserHandler(mockService)
 
 		userID := uuid.New()
-		mockService.On("GetUserAccessLevels", mock.Anything, userID).Return(nil, errors.New("user not found"))
+		mockService.On("GetUserAccessLevels", mock.Anything, userID).Return(nil, apperrors.NotFound("user_not_found", "user not found"))
 
 		request := httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/access-levels", nil)
 		recorder := httptest.NewRecorder()
@@ -718,3 +1984,148 @@ func TestRespondWithError(t *testing.T) {
 		assert.Equal(t, "Error message", response.Message)
 	})
 }
+
+func TestGetUserEffectiveAccessLevels(t *testing.T) {
+	newRouter := func(handler *UserHandler) *mux.Router {
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/access-levels/effective", handler.GetUserEffectiveAccessLevels)
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		userID := uuid.New()
+		expectedResponse := &dto.UserAccessLevelsResponse{
+			Direct: []dto.AccessLevelResponse{{ID: 3, Name: "admin"}},
+			Effective: []dto.AccessLevelResponse{
+				{ID: 3, Name: "admin"},
+				{ID: 2, Name: "editor"},
+				{ID: 1, Name: "viewer"},
+			},
+		}
+		mockService.On("GetUserEffectiveAccessLevels", mock.Anything, userID).Return(expectedResponse, nil)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewUserHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/access-levels/effective", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response dto.UserAccessLevelsResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Len(t, response.Direct, 1)
+		assert.Len(t, response.Effective, 3)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		mockService := new(MockUserService)
+		userID := uuid.New()
+		mockService.On("GetUserEffectiveAccessLevels", mock.Anything, userID).Return(nil, errors.New("database error"))
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewUserHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/"+userID.String()+"/access-levels/effective", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid User ID", func(t *testing.T) {
+		mockService := new(MockUserService)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewUserHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/invalid-id/access-levels/effective", nil))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestReplaceAccessLevels(t *testing.T) {
+	newRouter := func(handler *UserHandler) *mux.Router {
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/access-levels", handler.ReplaceAccessLevels).Methods(http.MethodPut)
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		userID := uuid.New()
+		req := &dto.ReplaceAccessLevelsRequest{AccessLevelIDs: []int{2, 3}}
+		expectedResponse := []dto.AccessLevelResponse{{ID: 3, Name: "admin"}, {ID: 2, Name: "editor"}}
+		mockService.On("ReplaceAccessLevels", mock.Anything, userID, req).Return(expectedResponse, nil)
+
+		body, _ := json.Marshal(req)
+		recorder := httptest.NewRecorder()
+		newRouter(NewUserHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/users/"+userID.String()+"/access-levels", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var response []dto.AccessLevelResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+		assert.Equal(t, expectedResponse, response)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Unknown Access Level", func(t *testing.T) {
+		mockService := new(MockUserService)
+		userID := uuid.New()
+		req := &dto.ReplaceAccessLevelsRequest{AccessLevelIDs: []int{99}}
+		mockService.On("ReplaceAccessLevels", mock.Anything, userID, req).Return(nil, apperrors.Validation("access_levels_not_found", "access levels not found: 99"))
+
+		body, _ := json.Marshal(req)
+		recorder := httptest.NewRecorder()
+		newRouter(NewUserHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/users/"+userID.String()+"/access-levels", bytes.NewReader(body)))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Body", func(t *testing.T) {
+		mockService := new(MockUserService)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewUserHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/users/"+uuid.NewString()+"/access-levels", bytes.NewReader([]byte("{"))))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockService.AssertNotCalled(t, "ReplaceAccessLevels")
+	})
+}
+
+func TestRemoveAccessLevel(t *testing.T) {
+	newRouter := func(handler *UserHandler) *mux.Router {
+		router := mux.NewRouter()
+		router.HandleFunc("/users/{id}/access-levels/{levelId}", handler.RemoveAccessLevel).Methods(http.MethodDelete)
+		return router
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockService := new(MockUserService)
+		userID := uuid.New()
+		mockService.On("RemoveAccessLevel", mock.Anything, userID, 2).Return(nil)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewUserHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/users/"+userID.String()+"/access-levels/2", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Not Assigned", func(t *testing.T) {
+		mockService := new(MockUserService)
+		userID := uuid.New()
+		mockService.On("RemoveAccessLevel", mock.Anything, userID, 2).Return(apperrors.NotFound("user_access_level_not_found", "user access level not found"))
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewUserHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/users/"+userID.String()+"/access-levels/2", nil))
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Access Level ID", func(t *testing.T) {
+		mockService := new(MockUserService)
+
+		recorder := httptest.NewRecorder()
+		newRouter(NewUserHandler(mockService)).ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/users/"+uuid.NewString()+"/access-levels/abc", nil))
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		mockService.AssertNotCalled(t, "RemoveAccessLevel")
+	})
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/wabtcdi/user_service/cmd"
@@ -16,7 +19,11 @@ func main() {
 	configName := flag.String("config", "local", "config file to use (local or cloud)")
 	flag.Parse()
 
-	if err := run(*configName, gormOpener, &cmd.RealStarter{}); err != nil {
+	// Serve until interrupted, then shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, *configName, gormOpener, &cmd.RealStarter{}); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -38,10 +45,10 @@ func gormOpener(dsn string) (*gorm.DB, error) {
 	})
 }
 
-func run(configName string, openFunc cmd.DBOpener, starter cmd.ServerStarter) error {
+func run(ctx context.Context, configName string, openFunc cmd.DBOpener, starter cmd.ServerStarter) error {
 	err := os.Chdir("cmd")
 	if err != nil {
 		return err
 	}
-	return cmd.Init(configName, openFunc, starter)
+	return cmd.Init(ctx, configName, openFunc, starter)
 }
@@ -7,10 +7,12 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 	models "github.com/wabtcdi/user_service/models"
+	repository "github.com/wabtcdi/user_service/repository"
 )
 
 // MockUserRepository is a mock of UserRepository interface.
@@ -36,6 +38,21 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Count mocks base method.
+func (m *MockUserRepository) Count(ctx context.Context, filter repository.UserFilter) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count", ctx, filter)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockUserRepositoryMockRecorder) Count(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockUserRepository)(nil).Count), ctx, filter)
+}
+
 // Create mocks base method.
 func (m *MockUserRepository) Create(ctx context.Context, user *models.User, auth *models.UserAuthentication) error {
 	m.ctrl.T.Helper()
@@ -94,6 +111,21 @@ func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), ctx, id)
 }
 
+// GetPasswordHistory mocks base method.
+func (m *MockUserRepository) GetPasswordHistory(ctx context.Context, userID uuid.UUID, limit int) ([]*models.PasswordHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordHistory", ctx, userID, limit)
+	ret0, _ := ret[0].([]*models.PasswordHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasswordHistory indicates an expected call of GetPasswordHistory.
+func (mr *MockUserRepositoryMockRecorder) GetPasswordHistory(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordHistory", reflect.TypeOf((*MockUserRepository)(nil).GetPasswordHistory), ctx, userID, limit)
+}
+
 // GetUserAuthentication mocks base method.
 func (m *MockUserRepository) GetUserAuthentication(ctx context.Context, userID uuid.UUID) (*models.UserAuthentication, error) {
 	m.ctrl.T.Helper()
@@ -110,19 +142,134 @@ func (mr *MockUserRepositoryMockRecorder) GetUserAuthentication(ctx, userID inte
 }
 
 // List mocks base method.
-func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]*models.User, int, error) {
+func (m *MockUserRepository) List(ctx context.Context, filter repository.UserFilter, limit, offset int) ([]*models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", ctx, limit, offset)
+	ret := m.ctrl.Call(m, "List", ctx, filter, limit, offset)
 	ret0, _ := ret[0].([]*models.User)
-	ret1, _ := ret[1].(int)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockUserRepositoryMockRecorder) List(ctx, limit, offset interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) List(ctx, filter, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserRepository)(nil).List), ctx, filter, limit, offset)
+}
+
+// ListAfter mocks base method.
+func (m *MockUserRepository) ListAfter(ctx context.Context, filter repository.UserFilter, after *repository.UserCursor, ascending bool, limit int) ([]*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAfter", ctx, filter, after, ascending, limit)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAfter indicates an expected call of ListAfter.
+func (mr *MockUserRepositoryMockRecorder) ListAfter(ctx, filter, after, ascending, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAfter", reflect.TypeOf((*MockUserRepository)(nil).ListAfter), ctx, filter, after, ascending, limit)
+}
+
+// LockUntil mocks base method.
+func (m *MockUserRepository) LockUntil(ctx context.Context, userID uuid.UUID, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockUntil", ctx, userID, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockUntil indicates an expected call of LockUntil.
+func (mr *MockUserRepositoryMockRecorder) LockUntil(ctx, userID, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockUntil", reflect.TypeOf((*MockUserRepository)(nil).LockUntil), ctx, userID, until)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, userID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUserRepositoryMockRecorder) MarkEmailVerified(ctx, userID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUserRepository)(nil).MarkEmailVerified), ctx, userID, email)
+}
+
+// Purge mocks base method.
+func (m *MockUserRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockUserRepositoryMockRecorder) Purge(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockUserRepository)(nil).List), ctx, limit, offset)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockUserRepository)(nil).Purge), ctx, id)
+}
+
+// RecordFailedLogin mocks base method.
+func (m *MockUserRepository) RecordFailedLogin(ctx context.Context, userID uuid.UUID, at time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailedLogin", ctx, userID, at)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordFailedLogin indicates an expected call of RecordFailedLogin.
+func (mr *MockUserRepositoryMockRecorder) RecordFailedLogin(ctx, userID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailedLogin", reflect.TypeOf((*MockUserRepository)(nil).RecordFailedLogin), ctx, userID, at)
+}
+
+// ResetFailedLogins mocks base method.
+func (m *MockUserRepository) ResetFailedLogins(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetFailedLogins", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetFailedLogins indicates an expected call of ResetFailedLogins.
+func (mr *MockUserRepositoryMockRecorder) ResetFailedLogins(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetFailedLogins", reflect.TypeOf((*MockUserRepository)(nil).ResetFailedLogins), ctx, userID)
+}
+
+// Restore mocks base method.
+func (m *MockUserRepository) Restore(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUserRepositoryMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUserRepository)(nil).Restore), ctx, id)
+}
+
+// Search mocks base method.
+func (m *MockUserRepository) Search(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query, limit)
+	ret0, _ := ret[0].([]*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockUserRepositoryMockRecorder) Search(ctx, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockUserRepository)(nil).Search), ctx, query, limit)
 }
 
 // Update mocks base method.
@@ -139,6 +286,20 @@ func (mr *MockUserRepositoryMockRecorder) Update(ctx, user interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), ctx, user)
 }
 
+// UpdatePassword mocks base method.
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, userID, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserRepositoryMockRecorder) UpdatePassword(ctx, userID, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserRepository)(nil).UpdatePassword), ctx, userID, passwordHash)
+}
+
 // MockAccessLevelRepository is a mock of AccessLevelRepository interface.
 type MockAccessLevelRepository struct {
 	ctrl     *gomock.Controller
@@ -176,6 +337,21 @@ func (mr *MockAccessLevelRepositoryMockRecorder) AssignToUser(ctx, userID, acces
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignToUser", reflect.TypeOf((*MockAccessLevelRepository)(nil).AssignToUser), ctx, userID, accessLevelID)
 }
 
+// CountUsers mocks base method.
+func (m *MockAccessLevelRepository) CountUsers(ctx context.Context, id int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsers", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUsers indicates an expected call of CountUsers.
+func (mr *MockAccessLevelRepositoryMockRecorder) CountUsers(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsers", reflect.TypeOf((*MockAccessLevelRepository)(nil).CountUsers), ctx, id)
+}
+
 // Create mocks base method.
 func (m *MockAccessLevelRepository) Create(ctx context.Context, accessLevel *models.AccessLevel) error {
 	m.ctrl.T.Helper()
@@ -190,6 +366,20 @@ func (mr *MockAccessLevelRepositoryMockRecorder) Create(ctx, accessLevel interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAccessLevelRepository)(nil).Create), ctx, accessLevel)
 }
 
+// Delete mocks base method.
+func (m *MockAccessLevelRepository) Delete(ctx context.Context, id int, removeAssignments bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id, removeAssignments)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAccessLevelRepositoryMockRecorder) Delete(ctx, id, removeAssignments interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAccessLevelRepository)(nil).Delete), ctx, id, removeAssignments)
+}
+
 // GetByID mocks base method.
 func (m *MockAccessLevelRepository) GetByID(ctx context.Context, id int) (*models.AccessLevel, error) {
 	m.ctrl.T.Helper()
@@ -205,6 +395,21 @@ func (mr *MockAccessLevelRepositoryMockRecorder) GetByID(ctx, id interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockAccessLevelRepository)(nil).GetByID), ctx, id)
 }
 
+// GetByIDs mocks base method.
+func (m *MockAccessLevelRepository) GetByIDs(ctx context.Context, ids []int) ([]*models.AccessLevel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids)
+	ret0, _ := ret[0].([]*models.AccessLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockAccessLevelRepositoryMockRecorder) GetByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockAccessLevelRepository)(nil).GetByIDs), ctx, ids)
+}
+
 // GetByName mocks base method.
 func (m *MockAccessLevelRepository) GetByName(ctx context.Context, name string) (*models.AccessLevel, error) {
 	m.ctrl.T.Helper()
@@ -220,6 +425,36 @@ func (mr *MockAccessLevelRepositoryMockRecorder) GetByName(ctx, name interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByName", reflect.TypeOf((*MockAccessLevelRepository)(nil).GetByName), ctx, name)
 }
 
+// GetEffectiveUserAccessLevels mocks base method.
+func (m *MockAccessLevelRepository) GetEffectiveUserAccessLevels(ctx context.Context, userID uuid.UUID) ([]*models.AccessLevel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEffectiveUserAccessLevels", ctx, userID)
+	ret0, _ := ret[0].([]*models.AccessLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEffectiveUserAccessLevels indicates an expected call of GetEffectiveUserAccessLevels.
+func (mr *MockAccessLevelRepositoryMockRecorder) GetEffectiveUserAccessLevels(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEffectiveUserAccessLevels", reflect.TypeOf((*MockAccessLevelRepository)(nil).GetEffectiveUserAccessLevels), ctx, userID)
+}
+
+// GetParentLinks mocks base method.
+func (m *MockAccessLevelRepository) GetParentLinks(ctx context.Context) ([]*models.AccessLevelParent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetParentLinks", ctx)
+	ret0, _ := ret[0].([]*models.AccessLevelParent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetParentLinks indicates an expected call of GetParentLinks.
+func (mr *MockAccessLevelRepositoryMockRecorder) GetParentLinks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetParentLinks", reflect.TypeOf((*MockAccessLevelRepository)(nil).GetParentLinks), ctx)
+}
+
 // GetUserAccessLevels mocks base method.
 func (m *MockAccessLevelRepository) GetUserAccessLevels(ctx context.Context, userID uuid.UUID) ([]*models.AccessLevel, error) {
 	m.ctrl.T.Helper()
@@ -235,6 +470,21 @@ func (mr *MockAccessLevelRepositoryMockRecorder) GetUserAccessLevels(ctx, userID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAccessLevels", reflect.TypeOf((*MockAccessLevelRepository)(nil).GetUserAccessLevels), ctx, userID)
 }
 
+// GetUsersAccessLevels mocks base method.
+func (m *MockAccessLevelRepository) GetUsersAccessLevels(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]*models.AccessLevel, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersAccessLevels", ctx, userIDs)
+	ret0, _ := ret[0].(map[uuid.UUID][]*models.AccessLevel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersAccessLevels indicates an expected call of GetUsersAccessLevels.
+func (mr *MockAccessLevelRepositoryMockRecorder) GetUsersAccessLevels(ctx, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersAccessLevels", reflect.TypeOf((*MockAccessLevelRepository)(nil).GetUsersAccessLevels), ctx, userIDs)
+}
+
 // List mocks base method.
 func (m *MockAccessLevelRepository) List(ctx context.Context) ([]*models.AccessLevel, error) {
 	m.ctrl.T.Helper()
@@ -250,6 +500,20 @@ func (mr *MockAccessLevelRepositoryMockRecorder) List(ctx interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAccessLevelRepository)(nil).List), ctx)
 }
 
+// LockParentLinks mocks base method.
+func (m *MockAccessLevelRepository) LockParentLinks(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockParentLinks", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockParentLinks indicates an expected call of LockParentLinks.
+func (mr *MockAccessLevelRepositoryMockRecorder) LockParentLinks(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockParentLinks", reflect.TypeOf((*MockAccessLevelRepository)(nil).LockParentLinks), ctx)
+}
+
 // RemoveFromUser mocks base method.
 func (m *MockAccessLevelRepository) RemoveFromUser(ctx context.Context, userID uuid.UUID, accessLevelID int) error {
 	m.ctrl.T.Helper()
@@ -263,3 +527,45 @@ func (mr *MockAccessLevelRepositoryMockRecorder) RemoveFromUser(ctx, userID, acc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFromUser", reflect.TypeOf((*MockAccessLevelRepository)(nil).RemoveFromUser), ctx, userID, accessLevelID)
 }
+
+// ReplaceUserAccessLevels mocks base method.
+func (m *MockAccessLevelRepository) ReplaceUserAccessLevels(ctx context.Context, userID uuid.UUID, accessLevelIDs []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceUserAccessLevels", ctx, userID, accessLevelIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceUserAccessLevels indicates an expected call of ReplaceUserAccessLevels.
+func (mr *MockAccessLevelRepositoryMockRecorder) ReplaceUserAccessLevels(ctx, userID, accessLevelIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceUserAccessLevels", reflect.TypeOf((*MockAccessLevelRepository)(nil).ReplaceUserAccessLevels), ctx, userID, accessLevelIDs)
+}
+
+// SetParents mocks base method.
+func (m *MockAccessLevelRepository) SetParents(ctx context.Context, accessLevelID int, parentIDs []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetParents", ctx, accessLevelID, parentIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetParents indicates an expected call of SetParents.
+func (mr *MockAccessLevelRepositoryMockRecorder) SetParents(ctx, accessLevelID, parentIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetParents", reflect.TypeOf((*MockAccessLevelRepository)(nil).SetParents), ctx, accessLevelID, parentIDs)
+}
+
+// Update mocks base method.
+func (m *MockAccessLevelRepository) Update(ctx context.Context, accessLevel *models.AccessLevel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, accessLevel)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockAccessLevelRepositoryMockRecorder) Update(ctx, accessLevel interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockAccessLevelRepository)(nil).Update), ctx, accessLevel)
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit event types
+const (
+	AuditEventAccountLocked            = "account_locked"
+	AuditEventAccountUnlocked          = "account_unlocked"
+	AuditEventUserRestored             = "user_restored"
+	AuditEventUserPurged               = "user_purged"
+	AuditEventMFADisabled              = "mfa_disabled"
+	AuditEventMFAReset                 = "mfa_reset"
+	AuditEventRecoveryCodesRegenerated = "recovery_codes_regenerated"
+)
+
+// AuditEvent records a security relevant change to a user's account. ActorID
+// is the user who made the change when it was not the account holder. Events
+// are kept when the user is purged, so UserID may no longer refer to a user.
+type AuditEvent struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty" gorm:"type:uuid"`
+	Event     string     `json:"event" gorm:"column:event;size:50;not null;index"`
+	IPAddress *string    `json:"ip_address,omitempty" gorm:"column:ip_address;size:45"`
+	Detail    *string    `json:"detail,omitempty" gorm:"column:detail;type:text"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at"`
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
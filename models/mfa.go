@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFARecoveryCode is a hashed, single-use code that stands in for a TOTP code
+// when the user has lost their authenticator. It is spent by setting UsedAt.
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"column:code_hash;size:64;uniqueIndex;not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty" gorm:"column:used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at"`
+	User      *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// MFA challenge purposes
+const (
+	MFAChallengeVerify = "verify"
+	MFAChallengeEnroll = "enroll"
+)
+
+// MFAChallenge is issued by a password login for a user with MFA enabled and
+// is exchanged for tokens once the second factor is verified. A user whose
+// access levels require MFA but who has not enabled it gets an enroll
+// challenge instead, completed by confirming a newly generated secret.
+type MFAChallenge struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash      string     `json:"-" gorm:"column:token_hash;size:64;uniqueIndex;not null"`
+	Purpose        string     `json:"purpose" gorm:"column:purpose;size:20;not null;default:verify"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"column:expires_at;not null"`
+	FailedAttempts int        `json:"failed_attempts" gorm:"column:failed_attempts;not null;default:0"`
+	UsedAt         *time.Time `json:"used_at,omitempty" gorm:"column:used_at"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at"`
+	User           *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (MFAChallenge) TableName() string {
+	return "mfa_challenges"
+}
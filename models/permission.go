@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Permission is a fine-grained capability such as "users:read" that is
+// granted to users through their access levels
+type Permission struct {
+	ID          int            `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string         `json:"name" gorm:"column:name;size:100;uniqueIndex;not null"`
+	Description *string        `json:"description,omitempty" gorm:"column:description;type:text"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+type AccessLevelPermission struct {
+	AccessLevelID int            `json:"access_level_id" gorm:"primaryKey;index"`
+	PermissionID  int            `json:"permission_id" gorm:"primaryKey;index"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
+	AccessLevel   *AccessLevel   `json:"-" gorm:"foreignKey:AccessLevelID;constraint:OnDelete:CASCADE"`
+	Permission    *Permission    `json:"-" gorm:"foreignKey:PermissionID;constraint:OnDelete:CASCADE"`
+}
+
+func (AccessLevelPermission) TableName() string {
+	return "access_level_permissions"
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a hashed, single-use refresh token. Tokens issued from the
+// same login share a FamilyID so that a replayed token can revoke the chain.
+type RefreshToken struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	FamilyID     uuid.UUID  `json:"family_id" gorm:"type:uuid;not null;index"`
+	TokenHash    string     `json:"-" gorm:"column:token_hash;size:64;uniqueIndex;not null"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"column:expires_at;not null"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty" gorm:"column:revoked_at"`
+	ReplacedByID *uuid.UUID `json:"replaced_by_id,omitempty" gorm:"column:replaced_by_id;type:uuid"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"column:updated_at"`
+	User         *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a server-side record of one login. Its ID is shared with the
+// refresh token family issued by that login, and it ends when revoked, when
+// unused for the idle timeout or at ExpiresAt, whichever comes first.
+type Session struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	UserAgent  *string    `json:"user_agent,omitempty" gorm:"column:user_agent;size:512"`
+	IPAddress  *string    `json:"ip_address,omitempty" gorm:"column:ip_address;size:45"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at" gorm:"column:last_seen_at;not null"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"column:expires_at;not null"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"column:revoked_at"`
+	User       *User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
@@ -8,14 +8,15 @@ import (
 )
 
 type User struct {
-	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
-	FirstName   string         `json:"first_name" gorm:"column:first_name;size:50;not null"`
-	LastName    string         `json:"last_name" gorm:"column:last_name;size:50;not null"`
-	Email       string         `json:"email" gorm:"column:email;size:255;uniqueIndex;not null"`
-	PhoneNumber *string        `json:"phone_number,omitempty" gorm:"column:phone_number;size:20"`
-	CreatedAt   time.Time      `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt   time.Time      `json:"updated_at" gorm:"column:updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
+	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	FirstName       string         `json:"first_name" gorm:"column:first_name;size:50;not null"`
+	LastName        string         `json:"last_name" gorm:"column:last_name;size:50;not null"`
+	Email           string         `json:"email" gorm:"column:email;size:255;uniqueIndex:idx_users_email_active,where:deleted_at IS NULL;not null"`
+	PhoneNumber     *string        `json:"phone_number,omitempty" gorm:"column:phone_number;size:20"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty" gorm:"column:email_verified_at"`
+	CreatedAt       time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt       time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
 }
 
 func (User) TableName() string {
@@ -23,22 +24,42 @@ func (User) TableName() string {
 }
 
 type UserAuthentication struct {
-	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
-	UserID       uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
-	PasswordHash string         `json:"-" gorm:"column:password_hash;size:255;not null"`
-	CreatedAt    time.Time      `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt    time.Time      `json:"updated_at" gorm:"column:updated_at"`
-	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
-	User         *User          `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ID                uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	UserID            uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
+	PasswordHash      string         `json:"-" gorm:"column:password_hash;size:255;not null"`
+	PasswordChangedAt *time.Time     `json:"password_changed_at,omitempty" gorm:"column:password_changed_at"`
+	FailedLoginCount  int            `json:"failed_login_count" gorm:"column:failed_login_count;not null;default:0"`
+	LastFailedLoginAt *time.Time     `json:"last_failed_login_at,omitempty" gorm:"column:last_failed_login_at"`
+	LockedUntil       *time.Time     `json:"locked_until,omitempty" gorm:"column:locked_until"`
+	TOTPSecret        *string        `json:"-" gorm:"column:totp_secret;size:128"`
+	MFAEnabledAt      *time.Time     `json:"mfa_enabled_at,omitempty" gorm:"column:mfa_enabled_at"`
+	TOTPLastStep      int64          `json:"-" gorm:"column:totp_last_step;not null;default:0"`
+	CreatedAt         time.Time      `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt         time.Time      `json:"updated_at" gorm:"column:updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
+	User              *User          `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
 func (UserAuthentication) TableName() string {
 	return "user_authentications"
 }
 
+// PasswordHistory is a password hash the user has replaced
+type PasswordHistory struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	UserID       uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	PasswordHash string    `json:"-" gorm:"column:password_hash;size:255;not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
+	User         *User     `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
 type AccessLevel struct {
 	ID          int            `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name        string         `json:"name" gorm:"column:name;size:50;uniqueIndex;not null"`
+	Name        string         `json:"name" gorm:"column:name;size:50;uniqueIndex:idx_access_levels_name_active,where:deleted_at IS NULL;not null"`
 	Description *string        `json:"description,omitempty" gorm:"column:description;type:text"`
 	CreatedAt   time.Time      `json:"created_at" gorm:"column:created_at"`
 	UpdatedAt   time.Time      `json:"updated_at" gorm:"column:updated_at"`
@@ -62,3 +83,17 @@ type UserAccessLevel struct {
 func (UserAccessLevel) TableName() string {
 	return "user_access_levels"
 }
+
+// AccessLevelParent declares that holders of AccessLevelID also hold ParentID
+// and, transitively, every level ParentID inherits
+type AccessLevelParent struct {
+	AccessLevelID int          `json:"access_level_id" gorm:"primaryKey;index"`
+	ParentID      int          `json:"parent_id" gorm:"primaryKey;index"`
+	CreatedAt     time.Time    `json:"created_at" gorm:"column:created_at"`
+	AccessLevel   *AccessLevel `json:"-" gorm:"foreignKey:AccessLevelID;constraint:OnDelete:CASCADE"`
+	Parent        *AccessLevel `json:"-" gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE"`
+}
+
+func (AccessLevelParent) TableName() string {
+	return "access_level_parents"
+}
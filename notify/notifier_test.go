@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileNotifier_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	n := NewFileNotifier(path)
+	ctx := context.Background()
+
+	messages := []Message{
+		{To: "first@example.com", Subject: "Reset your password", Body: "https://example.com/reset?token=a"},
+		{To: "second@example.com", Subject: "Reset your password", Body: "https://example.com/reset?token=b"},
+	}
+	for _, msg := range messages {
+		if err := n.Notify(ctx, msg); err != nil {
+			t.Fatalf("Failed to notify: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open notification file: %v", err)
+	}
+	defer f.Close()
+
+	var got []Message
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("Failed to decode notification line: %v", err)
+		}
+		got = append(got, msg)
+	}
+	if len(got) != len(messages) {
+		t.Fatalf("Expected %d notifications, got %d", len(messages), len(got))
+	}
+	for i := range messages {
+		if got[i] != messages[i] {
+			t.Errorf("Notification %d: expected %+v, got %+v", i, messages[i], got[i])
+		}
+	}
+}
+
+func TestFileNotifier_UnwritablePath(t *testing.T) {
+	n := NewFileNotifier(filepath.Join(t.TempDir(), "missing", "notifications.jsonl"))
+	if err := n.Notify(context.Background(), Message{To: "a@example.com"}); err == nil {
+		t.Error("Expected error for unwritable path, got nil")
+	}
+}
+
+func TestLogNotifier_Notify(t *testing.T) {
+	if err := NewLogNotifier().Notify(context.Background(), Message{To: "a@example.com", Body: "hello"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package pagination
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type position struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+	want := position{CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC), ID: "abc"}
+
+	token, err := codec.Encode(want)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.ContainsAny(token, "+/=") {
+		t.Errorf("Expected a URL-safe token, got %s", token)
+	}
+
+	var got position
+	if err := codec.Decode(token, &got); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID {
+		t.Errorf("Decoded %+v, want %+v", got, want)
+	}
+}
+
+func TestCodec_Decode_Invalid(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+	token, err := codec.Encode(position{ID: "abc"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	payload, sig, _ := strings.Cut(token, ".")
+	forged, _ := NewCodec([]byte("other")).Encode(position{ID: "abc"})
+	edited := encoding.EncodeToString([]byte(`{"i":"xyz"}`)) + "." + sig
+
+	tests := map[string]string{
+		"Empty":         "",
+		"No Signature":  payload,
+		"Bad Encoding":  payload + ".!!!",
+		"Edited":        edited,
+		"Other Key":     forged,
+		"Not JSON":      encoding.EncodeToString([]byte("x")) + "." + encoding.EncodeToString(codec.sign([]byte("x"))),
+		"Trailing Data": token + "x",
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got position
+			if err := codec.Decode(token, &got); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Expected ErrInvalidCursor, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewRandomCodec(t *testing.T) {
+	token, err := NewRandomCodec().Encode(position{ID: "abc"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var got position
+	if err := NewRandomCodec().Decode(token, &got); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected cursors from another random codec to be rejected, got %v", err)
+	}
+}
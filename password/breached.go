@@ -0,0 +1,101 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const sha1HexLength = 40
+
+// BreachedPasswordFile looks passwords up in a local copy of a breached
+// password list in the Have I Been Pwned "ordered by hash" format: one
+// upper-case SHA-1 hash per line, optionally followed by ":count", sorted by
+// hash. Lookups binary search the file, so it is never loaded into memory.
+type BreachedPasswordFile struct {
+	path string
+}
+
+// NewBreachedPasswordFile checks that the file at path can be read
+func NewBreachedPasswordFile(path string) (*BreachedPasswordFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password file: %w", err)
+	}
+	f.Close()
+	return &BreachedPasswordFile{path: path}, nil
+}
+
+// Contains reports whether the SHA-1 hash of password is listed in the file
+func (b *BreachedPasswordFile) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	target := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	f, err := os.Open(b.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open breached password file: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat breached password file: %w", err)
+	}
+
+	// Invariant: a line holding target, if any, starts within [lo, hi)
+	lo, hi := int64(0), info.Size()
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		hash, next, err := firstHashFrom(f, mid, info.Size())
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case hash == "":
+			hi = mid
+		case hash == target:
+			return true, nil
+		case hash < target:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// firstHashFrom returns the hash on the first line starting at or after
+// offset, and the offset of the line following it. The hash is empty when no
+// line starts in that range.
+func firstHashFrom(r io.ReaderAt, offset, size int64) (string, int64, error) {
+	start := offset
+	reader := bufio.NewReader(io.NewSectionReader(r, offset, size-offset))
+	if offset > 0 {
+		// Skip the rest of the line offset falls in, unless a line starts there
+		reader = bufio.NewReader(io.NewSectionReader(r, offset-1, size-offset+1))
+		skipped, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return "", size, nil
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to read breached password file: %w", err)
+		}
+		start = offset - 1 + int64(len(skipped))
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", 0, fmt.Errorf("failed to read breached password file: %w", err)
+	}
+	if line == "" {
+		return "", size, nil
+	}
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	if len(hash) != sha1HexLength {
+		return "", 0, fmt.Errorf("malformed line in breached password file at offset %d", start)
+	}
+	return strings.ToUpper(hash), start + int64(len(line)), nil
+}
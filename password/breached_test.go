@@ -0,0 +1,99 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeBreachedFile writes the hashes of passwords in the ordered-by-hash
+// format, padded with filler hashes so lookups exercise the binary search
+func writeBreachedFile(t *testing.T, passwords []string, lineEnding string) string {
+	t.Helper()
+	var lines []string
+	for _, p := range passwords {
+		lines = append(lines, sha1Hex(p)+":42")
+	}
+	for i := 0; i < 500; i++ {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(fmt.Sprintf("filler-%d", i)), i+1))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, lineEnding)+lineEnding), 0o600); err != nil {
+		t.Fatalf("Failed to write breached password file: %v", err)
+	}
+	return path
+}
+
+func TestBreachedPasswordFile_Contains(t *testing.T) {
+	breached := []string{"password", "123456", "qwerty", "letmein"}
+
+	for _, lineEnding := range []string{"\n", "\r\n"} {
+		t.Run(fmt.Sprintf("Line Ending %q", lineEnding), func(t *testing.T) {
+			file, err := NewBreachedPasswordFile(writeBreachedFile(t, breached, lineEnding))
+			if err != nil {
+				t.Fatalf("Failed to open breached password file: %v", err)
+			}
+
+			for _, p := range append(breached, "filler-0", "filler-499") {
+				found, err := file.Contains(p)
+				if err != nil || !found {
+					t.Errorf("Expected %q to be found, got found=%v err=%v", p, found, err)
+				}
+			}
+			for _, p := range []string{"correct horse battery staple", "filler-500", ""} {
+				found, err := file.Contains(p)
+				if err != nil || found {
+					t.Errorf("Expected %q not to be found, got found=%v err=%v", p, found, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBreachedPasswordFile_SingleLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "single.txt")
+	if err := os.WriteFile(path, []byte(sha1Hex("password")), 0o600); err != nil {
+		t.Fatalf("Failed to write breached password file: %v", err)
+	}
+	file, err := NewBreachedPasswordFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open breached password file: %v", err)
+	}
+
+	if found, err := file.Contains("password"); err != nil || !found {
+		t.Errorf("Expected password to be found, got found=%v err=%v", found, err)
+	}
+	if found, err := file.Contains("other"); err != nil || found {
+		t.Errorf("Expected other not to be found, got found=%v err=%v", found, err)
+	}
+}
+
+func TestBreachedPasswordFile_Errors(t *testing.T) {
+	if _, err := NewBreachedPasswordFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected error for missing file, got nil")
+	}
+
+	path := filepath.Join(t.TempDir(), "malformed.txt")
+	if err := os.WriteFile(path, []byte("not-a-hash\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	file, err := NewBreachedPasswordFile(path)
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	if _, err := file.Contains("password"); err == nil {
+		t.Error("Expected error for malformed file, got nil")
+	}
+}
@@ -0,0 +1,126 @@
+// Package password decides whether a new password is acceptable.
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/wabtcdi/user_service/apperrors"
+)
+
+// BcryptMaxBytes is the longest password bcrypt can hash
+const BcryptMaxBytes = 72
+
+// minPersonalInfoLength keeps very short names and email parts from
+// rejecting unrelated passwords
+const minPersonalInfoLength = 3
+
+// BreachChecker reports whether a password is known to have been leaked
+type BreachChecker interface {
+	Contains(password string) (bool, error)
+}
+
+// Policy describes the passwords users may choose. HistorySize is enforced by
+// the caller, which has access to the stored password hashes.
+type Policy struct {
+	MinLength            int
+	MaxLength            int
+	RequireUppercase     bool
+	RequireLowercase     bool
+	RequireDigit         bool
+	RequireSymbol        bool
+	DisallowPersonalInfo bool
+	// HistorySize is how many recent passwords, the current one included,
+	// a new password must not repeat
+	HistorySize int
+	Breached    BreachChecker
+}
+
+// DefaultPolicy only bounds the password length
+func DefaultPolicy() Policy {
+	return Policy{MinLength: 8, MaxLength: BcryptMaxBytes}
+}
+
+// Check validates password, reported as field, and returns an apperrors
+// validation error listing every rule it breaks. personalInfo holds the
+// user's name and email, which the password must not contain when
+// DisallowPersonalInfo is set. The breach list is only consulted for
+// passwords that pass every other rule.
+func (p Policy) Check(field, password string, personalInfo ...string) error {
+	var fields []apperrors.FieldError
+	fail := func(rule, format string, args ...any) {
+		fields = append(fields, apperrors.FieldError{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		fail("min", "%s must be at least %d characters", field, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		fail("max", "%s must be at most %d characters", field, p.MaxLength)
+	} else if len(password) > BcryptMaxBytes {
+		fail("max", "%s must be at most %d bytes", field, BcryptMaxBytes)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	if p.RequireUppercase && !upper {
+		fail("uppercase", "%s must contain an uppercase letter", field)
+	}
+	if p.RequireLowercase && !lower {
+		fail("lowercase", "%s must contain a lowercase letter", field)
+	}
+	if p.RequireDigit && !digit {
+		fail("digit", "%s must contain a digit", field)
+	}
+	if p.RequireSymbol && !symbol {
+		fail("symbol", "%s must contain a symbol", field)
+	}
+
+	if p.DisallowPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		fail("personal_info", "%s must not contain your name or email", field)
+	}
+
+	if len(fields) == 0 && p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return fmt.Errorf("failed to check breached passwords: %w", err)
+		}
+		if breached {
+			fail("breached", "%s has appeared in a data breach and cannot be used", field)
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperrors.InvalidFields(fields...)
+	}
+	return nil
+}
+
+func containsPersonalInfo(password string, personalInfo []string) bool {
+	password = strings.ToLower(password)
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		// Only the local part of an email is likely to be reused in a password
+		if local, _, ok := strings.Cut(info, "@"); ok {
+			info = local
+		}
+		if utf8.RuneCountInString(info) >= minPersonalInfoLength && strings.Contains(password, info) {
+			return true
+		}
+	}
+	return false
+}
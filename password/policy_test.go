@@ -0,0 +1,96 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wabtcdi/user_service/apperrors"
+)
+
+type fakeBreachChecker struct {
+	breached map[string]bool
+	err      error
+}
+
+func (f fakeBreachChecker) Contains(password string) (bool, error) {
+	return f.breached[password], f.err
+}
+
+func rules(err error) []string {
+	var names []string
+	for _, f := range apperrors.Fields(err) {
+		names = append(names, f.Rule)
+	}
+	return names
+}
+
+func TestPolicy_Check(t *testing.T) {
+	strict := Policy{
+		MinLength:            10,
+		MaxLength:            20,
+		RequireUppercase:     true,
+		RequireLowercase:     true,
+		RequireDigit:         true,
+		RequireSymbol:        true,
+		DisallowPersonalInfo: true,
+		Breached:             fakeBreachChecker{breached: map[string]bool{"Password123!": true}},
+	}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		password string
+		expected []string
+	}{
+		{name: "Default Accepts Long Enough", policy: DefaultPolicy(), password: "password123"},
+		{name: "Default Too Short", policy: DefaultPolicy(), password: "short", expected: []string{"min"}},
+		{name: "Default Over Bcrypt Limit", policy: DefaultPolicy(), password: strings.Repeat("a", 73), expected: []string{"max"}},
+		{name: "Multi-byte Over Bcrypt Limit", policy: Policy{MinLength: 8, MaxLength: 64}, password: strings.Repeat("é", 40), expected: []string{"max"}},
+		{name: "Strict Accepts", policy: strict, password: "Tr0ub4dor&3x"},
+		{name: "Strict Too Long", policy: strict, password: "Tr0ub4dor&3" + strings.Repeat("x", 10), expected: []string{"max"}},
+		{name: "Missing Classes", policy: strict, password: "lowercaseonly", expected: []string{"uppercase", "digit", "symbol"}},
+		{name: "Name Inside", policy: strict, password: "xJohnson#2024", expected: []string{"personal_info"}},
+		{name: "Email Local Part Inside", policy: strict, password: "Jdoe#2024!!", expected: []string{"personal_info"}},
+		{name: "Breached", policy: strict, password: "Password123!", expected: []string{"breached"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check("password", tt.password, "jdoe@example.com", "Al", "Johnson")
+			got := rules(err)
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected rules %v, got %v (err %v)", tt.expected, got, err)
+			}
+			if err != nil && !errors.Is(err, apperrors.ErrValidation) {
+				t.Errorf("Expected a validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPolicy_Check_ReportsField(t *testing.T) {
+	err := DefaultPolicy().Check("new_password", "short")
+	fields := apperrors.Fields(err)
+	if len(fields) != 1 || fields[0].Field != "new_password" {
+		t.Fatalf("Expected one new_password field error, got %+v", fields)
+	}
+	if fields[0].Message != "new_password must be at least 8 characters" {
+		t.Errorf("Unexpected message: %s", fields[0].Message)
+	}
+}
+
+func TestPolicy_Check_ShortPersonalInfoIgnored(t *testing.T) {
+	policy := Policy{MinLength: 8, DisallowPersonalInfo: true}
+	if err := policy.Check("password", "alphabet-soup", "Al", "al@example.com"); err != nil {
+		t.Errorf("Expected short personal info to be ignored, got %v", err)
+	}
+}
+
+func TestPolicy_Check_BreachCheckerError(t *testing.T) {
+	policy := Policy{MinLength: 8, Breached: fakeBreachChecker{err: errors.New("disk failure")}}
+	err := policy.Check("password", "password123")
+	if err == nil || errors.Is(err, apperrors.ErrValidation) {
+		t.Errorf("Expected an internal error, got %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
 	"github.com/wabtcdi/user_service/models"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -33,7 +34,7 @@ func (r *PostgresAccessLevelRepository) GetByID(ctx context.Context, id int) (*m
 	accessLevel := &models.AccessLevel{}
 	err := r.db.WithContext(ctx).Where("id = ?", id).First(accessLevel).Error
 	if err == gorm.ErrRecordNotFound {
-		return nil, fmt.Errorf("access level not found")
+		return nil, apperrors.NotFound("access_level_not_found", "access level not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get access level: %w", err)
@@ -41,11 +42,24 @@ func (r *PostgresAccessLevelRepository) GetByID(ctx context.Context, id int) (*m
 	return accessLevel, nil
 }
 
+// GetByIDs returns the access levels matching ids; unknown IDs are skipped
+func (r *PostgresAccessLevelRepository) GetByIDs(ctx context.Context, ids []int) ([]*models.AccessLevel, error) {
+	var accessLevels []*models.AccessLevel
+	if len(ids) == 0 {
+		return accessLevels, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id ASC").Find(&accessLevels).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access levels: %w", err)
+	}
+	return accessLevels, nil
+}
+
 func (r *PostgresAccessLevelRepository) GetByName(ctx context.Context, name string) (*models.AccessLevel, error) {
 	accessLevel := &models.AccessLevel{}
 	err := r.db.WithContext(ctx).Where("name = ?", name).First(accessLevel).Error
 	if err == gorm.ErrRecordNotFound {
-		return nil, fmt.Errorf("access level not found")
+		return nil, apperrors.NotFound("access_level_not_found", "access level not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get access level: %w", err)
@@ -63,25 +77,66 @@ func (r *PostgresAccessLevelRepository) List(ctx context.Context) ([]*models.Acc
 	return accessLevels, nil
 }
 
-func (r *PostgresAccessLevelRepository) AssignToUser(ctx context.Context, userID uuid.UUID, accessLevelID int) error {
-	now := time.Now()
-	userAccessLevel := &models.UserAccessLevel{
-		UserID:        userID,
-		AccessLevelID: accessLevelID,
-		CreatedAt:     now,
-		UpdatedAt:     now,
+func (r *PostgresAccessLevelRepository) Update(ctx context.Context, accessLevel *models.AccessLevel) error {
+	accessLevel.UpdatedAt = time.Now()
+	result := r.db.WithContext(ctx).Model(accessLevel).Updates(map[string]interface{}{
+		"name":        accessLevel.Name,
+		"description": accessLevel.Description,
+		"updated_at":  accessLevel.UpdatedAt,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update access level: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("access_level_not_found", "access level not found")
 	}
+	return nil
+}
 
-	// Use GORM's Clauses with OnConflict to handle upsert
-	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "user_id"}, {Name: "access_level_id"}},
-		DoUpdates: clause.Assignments(map[string]interface{}{"deleted_at": nil, "updated_at": now}),
-	}).Create(userAccessLevel).Error
+// Delete soft-deletes an access level along with its permission grants and
+// drops it from the hierarchy. When removeAssignments is set the
+// user_access_levels rows that grant it are soft-deleted as well.
+func (r *PostgresAccessLevelRepository) Delete(ctx context.Context, id int, removeAssignments bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if removeAssignments {
+			if err := tx.Where("access_level_id = ?", id).Delete(&models.UserAccessLevel{}).Error; err != nil {
+				return fmt.Errorf("failed to remove access level from users: %w", err)
+			}
+		}
 
+		if err := tx.Where("access_level_id = ? OR parent_id = ?", id, id).Delete(&models.AccessLevelParent{}).Error; err != nil {
+			return fmt.Errorf("failed to remove access level parents: %w", err)
+		}
+
+		if err := tx.Where("access_level_id = ?", id).Delete(&models.AccessLevelPermission{}).Error; err != nil {
+			return fmt.Errorf("failed to remove access level permissions: %w", err)
+		}
+
+		result := tx.Delete(&models.AccessLevel{}, "id = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete access level: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("access_level_not_found", "access level not found")
+		}
+		return nil
+	})
+}
+
+// CountUsers returns how many users are currently assigned the access level directly
+func (r *PostgresAccessLevelRepository) CountUsers(ctx context.Context, id int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.UserAccessLevel{}).
+		Where("access_level_id = ?", id).
+		Count(&count).Error
 	if err != nil {
-		return fmt.Errorf("failed to assign access level to user: %w", err)
+		return 0, fmt.Errorf("failed to count access level users: %w", err)
 	}
-	return nil
+	return count, nil
+}
+
+func (r *PostgresAccessLevelRepository) AssignToUser(ctx context.Context, userID uuid.UUID, accessLevelID int) error {
+	return assignAccessLevelToUser(r.db.WithContext(ctx), userID, accessLevelID)
 }
 
 func (r *PostgresAccessLevelRepository) RemoveFromUser(ctx context.Context, userID uuid.UUID, accessLevelID int) error {
@@ -93,11 +148,58 @@ func (r *PostgresAccessLevelRepository) RemoveFromUser(ctx context.Context, user
 		return fmt.Errorf("failed to remove access level from user: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("user access level not found")
+		return apperrors.NotFound("user_access_level_not_found", "user access level not found")
 	}
 	return nil
 }
 
+// ReplaceUserAccessLevels makes accessLevelIDs the user's complete set of
+// directly assigned access levels, adding and removing assignments in a
+// single transaction
+func (r *PostgresAccessLevelRepository) ReplaceUserAccessLevels(ctx context.Context, userID uuid.UUID, accessLevelIDs []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current []int
+		err := tx.Model(&models.UserAccessLevel{}).
+			Where("user_id = ?", userID).
+			Pluck("access_level_id", &current).Error
+		if err != nil {
+			return fmt.Errorf("failed to get user access levels: %w", err)
+		}
+
+		wanted := make(map[int]bool, len(accessLevelIDs))
+		for _, id := range accessLevelIDs {
+			wanted[id] = true
+		}
+		held := make(map[int]bool, len(current))
+		var removed []int
+		for _, id := range current {
+			held[id] = true
+			if !wanted[id] {
+				removed = append(removed, id)
+			}
+		}
+
+		if len(removed) > 0 {
+			err := tx.Where("user_id = ? AND access_level_id IN ?", userID, removed).
+				Delete(&models.UserAccessLevel{}).Error
+			if err != nil {
+				return fmt.Errorf("failed to remove access levels from user: %w", err)
+			}
+		}
+
+		for _, id := range accessLevelIDs {
+			if held[id] {
+				continue
+			}
+			if err := assignAccessLevelToUser(tx, userID, id); err != nil {
+				return err
+			}
+			held[id] = true
+		}
+		return nil
+	})
+}
+
 func (r *PostgresAccessLevelRepository) GetUserAccessLevels(ctx context.Context, userID uuid.UUID) ([]*models.AccessLevel, error) {
 	var accessLevels []*models.AccessLevel
 	err := r.db.WithContext(ctx).
@@ -112,3 +214,134 @@ func (r *PostgresAccessLevelRepository) GetUserAccessLevels(ctx context.Context,
 
 	return accessLevels, nil
 }
+
+// userAccessLevelRow is an access level together with the user it is
+// assigned to
+type userAccessLevelRow struct {
+	UserID uuid.UUID
+	models.AccessLevel
+}
+
+// GetUsersAccessLevels returns the directly assigned access levels of each of
+// the given users in a single query, keyed by user ID. Users without access
+// levels are absent from the map.
+func (r *PostgresAccessLevelRepository) GetUsersAccessLevels(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]*models.AccessLevel, error) {
+	accessLevels := make(map[uuid.UUID][]*models.AccessLevel, len(userIDs))
+	if len(userIDs) == 0 {
+		return accessLevels, nil
+	}
+
+	var rows []userAccessLevelRow
+	err := r.db.WithContext(ctx).
+		Select("access_levels.*, user_access_levels.user_id").
+		Joins("INNER JOIN user_access_levels ON access_levels.id = user_access_levels.access_level_id").
+		Where("user_access_levels.user_id IN ? AND user_access_levels.deleted_at IS NULL", userIDs).
+		Order("access_levels.name ASC").
+		Find(&rows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user access levels: %w", err)
+	}
+
+	for i := range rows {
+		accessLevels[rows[i].UserID] = append(accessLevels[rows[i].UserID], &rows[i].AccessLevel)
+	}
+	return accessLevels, nil
+}
+
+// GetEffectiveUserAccessLevels returns the user's directly assigned access
+// levels together with every level they inherit through access_level_parents.
+// UNION discards rows already produced, so the recursion also terminates if
+// the hierarchy somehow contains a cycle.
+func (r *PostgresAccessLevelRepository) GetEffectiveUserAccessLevels(ctx context.Context, userID uuid.UUID) ([]*models.AccessLevel, error) {
+	var accessLevels []*models.AccessLevel
+	err := r.db.WithContext(ctx).
+		Raw(`WITH RECURSIVE effective(id) AS (
+				SELECT access_level_id FROM user_access_levels
+				WHERE user_id = ? AND deleted_at IS NULL
+				UNION
+				SELECT access_level_parents.parent_id FROM access_level_parents
+				INNER JOIN effective ON access_level_parents.access_level_id = effective.id
+			)
+			SELECT * FROM access_levels
+			WHERE id IN (SELECT id FROM effective) AND deleted_at IS NULL
+			ORDER BY name ASC`, userID).
+		Scan(&accessLevels).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get effective user access levels: %w", err)
+	}
+
+	return accessLevels, nil
+}
+
+// GetParentLinks returns every parent link in the access level hierarchy
+func (r *PostgresAccessLevelRepository) GetParentLinks(ctx context.Context) ([]*models.AccessLevelParent, error) {
+	var links []*models.AccessLevelParent
+	err := r.db.WithContext(ctx).Order("access_level_id ASC, parent_id ASC").Find(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access level parents: %w", err)
+	}
+	return links, nil
+}
+
+// LockParentLinks keeps other transactions from changing the access level
+// hierarchy until the current transaction ends, so a cycle check made after it
+// still holds when the new links are written. Reads are not blocked. Only
+// Postgres takes the lock; SQLite serializes writers on its own.
+func (r *PostgresAccessLevelRepository) LockParentLinks(ctx context.Context) error {
+	if r.db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Exec("LOCK TABLE access_level_parents IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+		return fmt.Errorf("failed to lock access level parents: %w", err)
+	}
+	return nil
+}
+
+// SetParents replaces the parents of an access level with parentIDs
+func (r *PostgresAccessLevelRepository) SetParents(ctx context.Context, accessLevelID int, parentIDs []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("access_level_id = ?", accessLevelID).Delete(&models.AccessLevelParent{}).Error; err != nil {
+			return fmt.Errorf("failed to clear access level parents: %w", err)
+		}
+		if len(parentIDs) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		links := make([]*models.AccessLevelParent, 0, len(parentIDs))
+		for _, parentID := range parentIDs {
+			links = append(links, &models.AccessLevelParent{
+				AccessLevelID: accessLevelID,
+				ParentID:      parentID,
+				CreatedAt:     now,
+			})
+		}
+		if err := tx.Create(&links).Error; err != nil {
+			return fmt.Errorf("failed to set access level parents: %w", err)
+		}
+		return nil
+	})
+}
+
+func assignAccessLevelToUser(db *gorm.DB, userID uuid.UUID, accessLevelID int) error {
+	now := time.Now()
+	userAccessLevel := &models.UserAccessLevel{
+		UserID:        userID,
+		AccessLevelID: accessLevelID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	// Use GORM's Clauses with OnConflict to handle upsert
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "access_level_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"deleted_at": nil, "updated_at": now}),
+	}).Create(userAccessLevel).Error
+
+	if err != nil {
+		return fmt.Errorf("failed to assign access level to user: %w", err)
+	}
+	return nil
+}
@@ -367,3 +367,346 @@ func TestAccessLevelRepository_GetUserAccessLevels_Multiple(t *testing.T) {
 		t.Errorf("First access level should be 'Alpha' (sorted), got %s", accessLevels[0].Name)
 	}
 }
+
+func TestAccessLevelRepository_GetUsersAccessLevels(t *testing.T) {
+	db := setupTestDB(t)
+	accessLevelRepo := NewPostgresAccessLevelRepository(db)
+	userRepo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	var users []*models.User
+	for _, email := range []string{"first@example.com", "second@example.com", "third@example.com"} {
+		user := &models.User{FirstName: "Batch", LastName: "User", Email: email}
+		if err := userRepo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		users = append(users, user)
+	}
+
+	levels := make(map[string]*models.AccessLevel)
+	for _, name := range []string{"Gamma", "Alpha", "Beta"} {
+		accessLevel := &models.AccessLevel{Name: name}
+		if err := accessLevelRepo.Create(ctx, accessLevel); err != nil {
+			t.Fatalf("Failed to create access level %s: %v", name, err)
+		}
+		levels[name] = accessLevel
+	}
+
+	// The first user has every level, the second one level that is later
+	// removed and the third none
+	for _, name := range []string{"Gamma", "Alpha", "Beta"} {
+		if err := accessLevelRepo.AssignToUser(ctx, users[0].ID, levels[name].ID); err != nil {
+			t.Fatalf("Failed to assign access level %s: %v", name, err)
+		}
+	}
+	if err := accessLevelRepo.AssignToUser(ctx, users[1].ID, levels["Beta"].ID); err != nil {
+		t.Fatalf("Failed to assign access level: %v", err)
+	}
+	if err := accessLevelRepo.RemoveFromUser(ctx, users[1].ID, levels["Beta"].ID); err != nil {
+		t.Fatalf("Failed to remove access level: %v", err)
+	}
+
+	accessLevels, err := accessLevelRepo.GetUsersAccessLevels(ctx, []uuid.UUID{users[0].ID, users[1].ID, users[2].ID})
+	if err != nil {
+		t.Fatalf("Failed to get access levels: %v", err)
+	}
+
+	first := accessLevels[users[0].ID]
+	if len(first) != 3 || first[0].Name != "Alpha" || first[1].Name != "Beta" || first[2].Name != "Gamma" {
+		t.Errorf("Expected Alpha, Beta and Gamma for the first user, got %v", first)
+	}
+	if first[0].ID != levels["Alpha"].ID {
+		t.Errorf("Expected access level ID %d, got %d", levels["Alpha"].ID, first[0].ID)
+	}
+	if len(accessLevels[users[1].ID]) != 0 || len(accessLevels[users[2].ID]) != 0 {
+		t.Errorf("Expected no access levels for the other users, got %v", accessLevels)
+	}
+
+	empty, err := accessLevelRepo.GetUsersAccessLevels(ctx, nil)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Expected an empty result for no users, got %v (err %v)", empty, err)
+	}
+}
+
+func TestAccessLevelRepository_GetEffectiveUserAccessLevels(t *testing.T) {
+	db := setupTestDB(t)
+	accessLevelRepo := NewPostgresAccessLevelRepository(db)
+	userRepo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{
+		FirstName: "Inherit",
+		LastName:  "Levels",
+		Email:     "inherit.levels@example.com",
+	}
+	if err := userRepo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	viewer := createTestAccessLevel(t, accessLevelRepo, "viewer")
+	editor := createTestAccessLevel(t, accessLevelRepo, "editor")
+	admin := createTestAccessLevel(t, accessLevelRepo, "admin")
+	createTestAccessLevel(t, accessLevelRepo, "unrelated")
+
+	if err := accessLevelRepo.SetParents(ctx, editor.ID, []int{viewer.ID}); err != nil {
+		t.Fatalf("Failed to set editor parents: %v", err)
+	}
+	if err := accessLevelRepo.SetParents(ctx, admin.ID, []int{editor.ID}); err != nil {
+		t.Fatalf("Failed to set admin parents: %v", err)
+	}
+	if err := accessLevelRepo.AssignToUser(ctx, user.ID, admin.ID); err != nil {
+		t.Fatalf("Failed to assign access level: %v", err)
+	}
+
+	direct, err := accessLevelRepo.GetUserAccessLevels(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get user access levels: %v", err)
+	}
+	if len(direct) != 1 {
+		t.Errorf("Direct access levels count mismatch: got %d, want 1", len(direct))
+	}
+
+	effective, err := accessLevelRepo.GetEffectiveUserAccessLevels(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get effective access levels: %v", err)
+	}
+	names := make([]string, 0, len(effective))
+	for _, al := range effective {
+		names = append(names, al.Name)
+	}
+	if len(names) != 3 || names[0] != "admin" || names[1] != "editor" || names[2] != "viewer" {
+		t.Errorf("Effective access levels mismatch: got %v, want [admin editor viewer]", names)
+	}
+
+	links, err := accessLevelRepo.GetParentLinks(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get parent links: %v", err)
+	}
+	if len(links) != 2 {
+		t.Errorf("Parent links count mismatch: got %d, want 2", len(links))
+	}
+
+	// Replacing the parents drops the inherited levels
+	if err := accessLevelRepo.SetParents(ctx, admin.ID, nil); err != nil {
+		t.Fatalf("Failed to clear admin parents: %v", err)
+	}
+	effective, err = accessLevelRepo.GetEffectiveUserAccessLevels(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get effective access levels: %v", err)
+	}
+	if len(effective) != 1 {
+		t.Errorf("Effective access levels count mismatch after clearing parents: got %d, want 1", len(effective))
+	}
+}
+
+func TestAccessLevelRepository_Update(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresAccessLevelRepository(db)
+	ctx := context.Background()
+
+	accessLevel := createTestAccessLevel(t, repo, "writer")
+	desc := "Can edit users"
+	accessLevel.Name = "editor"
+	accessLevel.Description = &desc
+
+	if err := repo.Update(ctx, accessLevel); err != nil {
+		t.Fatalf("Failed to update access level: %v", err)
+	}
+
+	retrieved, err := repo.GetByID(ctx, accessLevel.ID)
+	if err != nil {
+		t.Fatalf("Failed to get access level: %v", err)
+	}
+	if retrieved.Name != "editor" || retrieved.Description == nil || *retrieved.Description != desc {
+		t.Errorf("Access level not updated: %+v", retrieved)
+	}
+
+	if err := repo.Update(ctx, &models.AccessLevel{ID: 999, Name: "ghost"}); err == nil {
+		t.Error("Expected error updating unknown access level, got nil")
+	}
+}
+
+func TestAccessLevelRepository_Delete(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresAccessLevelRepository(db)
+	userRepo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{
+		FirstName: "Delete",
+		LastName:  "Levels",
+		Email:     "delete.levels@example.com",
+	}
+	if err := userRepo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	viewer := createTestAccessLevel(t, repo, "viewer")
+	editor := createTestAccessLevel(t, repo, "editor")
+	if err := repo.SetParents(ctx, editor.ID, []int{viewer.ID}); err != nil {
+		t.Fatalf("Failed to set parents: %v", err)
+	}
+	if err := repo.AssignToUser(ctx, user.ID, viewer.ID); err != nil {
+		t.Fatalf("Failed to assign access level: %v", err)
+	}
+	permission := &models.Permission{Name: "users:read"}
+	if err := db.Create(permission).Error; err != nil {
+		t.Fatalf("Failed to create permission: %v", err)
+	}
+	permissionRepo := NewPostgresPermissionRepository(db)
+	if err := permissionRepo.AssignToAccessLevel(ctx, viewer.ID, permission.ID); err != nil {
+		t.Fatalf("Failed to assign permission: %v", err)
+	}
+
+	count, err := repo.CountUsers(ctx, viewer.ID)
+	if err != nil {
+		t.Fatalf("Failed to count users: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("User count mismatch: got %d, want 1", count)
+	}
+
+	if err := repo.Delete(ctx, viewer.ID, true); err != nil {
+		t.Fatalf("Failed to delete access level: %v", err)
+	}
+
+	if _, err := repo.GetByID(ctx, viewer.ID); err == nil {
+		t.Error("Expected deleted access level to be hidden")
+	}
+	count, _ = repo.CountUsers(ctx, viewer.ID)
+	if count != 0 {
+		t.Errorf("Expected assignments to be removed, %d remain", count)
+	}
+	var removed models.UserAccessLevel
+	if err := db.Unscoped().Where("user_id = ? AND access_level_id = ?", user.ID, viewer.ID).First(&removed).Error; err != nil {
+		t.Fatalf("Expected soft-deleted assignment row: %v", err)
+	}
+	if !removed.DeletedAt.Valid {
+		t.Error("Expected assignment to be soft-deleted")
+	}
+	links, _ := repo.GetParentLinks(ctx)
+	if len(links) != 0 {
+		t.Errorf("Expected parent links to be removed, got %d", len(links))
+	}
+	var grants int64
+	db.Model(&models.AccessLevelPermission{}).Where("access_level_id = ?", viewer.ID).Count(&grants)
+	if grants != 0 {
+		t.Errorf("Expected permission grants to be removed, %d remain", grants)
+	}
+
+	if err := repo.Delete(ctx, viewer.ID, false); err == nil {
+		t.Error("Expected error deleting an already deleted access level, got nil")
+	}
+}
+
+func TestAccessLevelRepository_Delete_ReusesName(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresAccessLevelRepository(db)
+	ctx := context.Background()
+
+	viewer := createTestAccessLevel(t, repo, "viewer")
+	reader := createTestAccessLevel(t, repo, "reader")
+	if err := repo.Delete(ctx, viewer.ID, false); err != nil {
+		t.Fatalf("Failed to delete access level: %v", err)
+	}
+	if err := repo.Delete(ctx, reader.ID, false); err != nil {
+		t.Fatalf("Failed to delete access level: %v", err)
+	}
+
+	if err := repo.Create(ctx, &models.AccessLevel{Name: "viewer"}); err != nil {
+		t.Errorf("Expected a deleted access level's name to be reusable, got %v", err)
+	}
+	editor := createTestAccessLevel(t, repo, "editor")
+	editor.Name = "reader"
+	if err := repo.Update(ctx, editor); err != nil {
+		t.Errorf("Expected renaming to a deleted access level's name to succeed, got %v", err)
+	}
+
+	if err := repo.Create(ctx, &models.AccessLevel{Name: "viewer"}); err == nil {
+		t.Error("Expected duplicate name among active access levels to fail, got nil")
+	}
+}
+
+func TestAccessLevelRepository_ReplaceUserAccessLevels(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresAccessLevelRepository(db)
+	userRepo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{
+		FirstName: "Replace",
+		LastName:  "Levels",
+		Email:     "replace.levels@example.com",
+	}
+	if err := userRepo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	alpha := createTestAccessLevel(t, repo, "Alpha")
+	beta := createTestAccessLevel(t, repo, "Beta")
+	gamma := createTestAccessLevel(t, repo, "Gamma")
+
+	userLevelNames := func() []string {
+		t.Helper()
+		levels, err := repo.GetUserAccessLevels(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Failed to get user access levels: %v", err)
+		}
+		names := make([]string, 0, len(levels))
+		for _, al := range levels {
+			names = append(names, al.Name)
+		}
+		return names
+	}
+
+	if err := repo.ReplaceUserAccessLevels(ctx, user.ID, []int{alpha.ID, beta.ID}); err != nil {
+		t.Fatalf("Failed to replace access levels: %v", err)
+	}
+	if names := userLevelNames(); len(names) != 2 || names[0] != "Alpha" || names[1] != "Beta" {
+		t.Errorf("Access levels mismatch: got %v, want [Alpha Beta]", names)
+	}
+
+	if err := repo.ReplaceUserAccessLevels(ctx, user.ID, []int{beta.ID, gamma.ID}); err != nil {
+		t.Fatalf("Failed to replace access levels: %v", err)
+	}
+	if names := userLevelNames(); len(names) != 2 || names[0] != "Beta" || names[1] != "Gamma" {
+		t.Errorf("Access levels mismatch: got %v, want [Beta Gamma]", names)
+	}
+
+	// A previously removed level is restored rather than duplicated
+	if err := repo.ReplaceUserAccessLevels(ctx, user.ID, []int{alpha.ID}); err != nil {
+		t.Fatalf("Failed to replace access levels: %v", err)
+	}
+	if names := userLevelNames(); len(names) != 1 || names[0] != "Alpha" {
+		t.Errorf("Access levels mismatch: got %v, want [Alpha]", names)
+	}
+
+	if err := repo.ReplaceUserAccessLevels(ctx, user.ID, nil); err != nil {
+		t.Fatalf("Failed to clear access levels: %v", err)
+	}
+	if names := userLevelNames(); len(names) != 0 {
+		t.Errorf("Expected no access levels, got %v", names)
+	}
+}
+
+func TestAccessLevelRepository_GetByIDs(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresAccessLevelRepository(db)
+	ctx := context.Background()
+
+	alpha := createTestAccessLevel(t, repo, "Alpha")
+	beta := createTestAccessLevel(t, repo, "Beta")
+
+	accessLevels, err := repo.GetByIDs(ctx, []int{beta.ID, alpha.ID, 999})
+	if err != nil {
+		t.Fatalf("Failed to get access levels: %v", err)
+	}
+	if len(accessLevels) != 2 {
+		t.Errorf("Access levels count mismatch: got %d, want 2", len(accessLevels))
+	}
+
+	empty, err := repo.GetByIDs(ctx, nil)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Expected no access levels for empty IDs, got %v (%v)", empty, err)
+	}
+}
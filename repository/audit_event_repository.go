@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/models"
+	"gorm.io/gorm"
+)
+
+type AuditEventRepository interface {
+	Create(ctx context.Context, event *models.AuditEvent) error
+}
+
+type PostgresAuditEventRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresAuditEventRepository(db *gorm.DB) *PostgresAuditEventRepository {
+	return &PostgresAuditEventRepository{db: db}
+}
+
+func (r *PostgresAuditEventRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	event.ID = uuid.New()
+	event.CreatedAt = time.Now()
+
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	return nil
+}
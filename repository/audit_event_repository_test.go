@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wabtcdi/user_service/models"
+)
+
+func TestAuditEventRepository_Create(t *testing.T) {
+	db := setupTestDB(t)
+	userRepo := NewPostgresUserRepository(db)
+	repo := NewPostgresAuditEventRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{
+		FirstName: "Audrey",
+		LastName:  "Trail",
+		Email:     "audrey.trail@example.com",
+	}
+	if err := userRepo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hash"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	ip := "203.0.113.7"
+	event := &models.AuditEvent{
+		UserID:    user.ID,
+		Event:     models.AuditEventAccountLocked,
+		IPAddress: &ip,
+	}
+	if err := repo.Create(ctx, event); err != nil {
+		t.Fatalf("Failed to record audit event: %v", err)
+	}
+
+	var stored models.AuditEvent
+	if err := db.First(&stored, "id = ?", event.ID).Error; err != nil {
+		t.Fatalf("Failed to load audit event: %v", err)
+	}
+	if stored.UserID != user.ID || stored.Event != models.AuditEventAccountLocked {
+		t.Errorf("Stored event mismatch: got %+v", stored)
+	}
+	if stored.IPAddress == nil || *stored.IPAddress != ip {
+		t.Errorf("Expected IP address %s, got %v", ip, stored.IPAddress)
+	}
+	if stored.ActorID != nil {
+		t.Errorf("Expected no actor, got %v", stored.ActorID)
+	}
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+	"gorm.io/gorm"
+)
+
+// ErrEmailVerificationTokenUsed is returned by MarkUsed when the token was already spent
+var ErrEmailVerificationTokenUsed = errors.New("email verification token has already been used")
+
+type EmailVerificationTokenRepository interface {
+	Create(ctx context.Context, token *models.EmailVerificationToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error)
+	MarkUsed(ctx context.Context, id uuid.UUID) error
+	InvalidateForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type PostgresEmailVerificationTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresEmailVerificationTokenRepository(db *gorm.DB) *PostgresEmailVerificationTokenRepository {
+	return &PostgresEmailVerificationTokenRepository{db: db}
+}
+
+func (r *PostgresEmailVerificationTokenRepository) Create(ctx context.Context, token *models.EmailVerificationToken) error {
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create email verification token: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresEmailVerificationTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.EmailVerificationToken, error) {
+	token := &models.EmailVerificationToken{}
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(token).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, apperrors.NotFound("email_verification_token_not_found", "email verification token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email verification token: %w", err)
+	}
+	return token, nil
+}
+
+// MarkUsed spends the token. The update only applies to an unused token, so
+// two concurrent confirmations with the same token cannot both succeed.
+func (r *PostgresEmailVerificationTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&models.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark email verification token used: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrEmailVerificationTokenUsed
+	}
+	return nil
+}
+
+// InvalidateForUser spends every outstanding token issued to the user
+func (r *PostgresEmailVerificationTokenRepository) InvalidateForUser(ctx context.Context, userID uuid.UUID) error {
+	err := r.db.WithContext(ctx).Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to invalidate email verification tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+)
+
+func createEmailVerificationTestUser(t *testing.T, repo *PostgresUserRepository, email string) *models.User {
+	t.Helper()
+	user := &models.User{
+		FirstName: "Vera",
+		LastName:  "Fied",
+		Email:     email,
+	}
+	if err := repo.Create(context.Background(), user, &models.UserAuthentication{PasswordHash: "hash"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	return user
+}
+
+func TestEmailVerificationTokenRepository_CreateAndGetByHash(t *testing.T) {
+	db := setupTestDB(t)
+	user := createEmailVerificationTestUser(t, NewPostgresUserRepository(db), "vera.fied@example.com")
+	repo := NewPostgresEmailVerificationTokenRepository(db)
+	ctx := context.Background()
+
+	token := &models.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: "verify-hash-1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := repo.Create(ctx, token); err != nil {
+		t.Fatalf("Failed to create email verification token: %v", err)
+	}
+
+	retrieved, err := repo.GetByHash(ctx, "verify-hash-1")
+	if err != nil {
+		t.Fatalf("Failed to get email verification token: %v", err)
+	}
+	if retrieved.ID != token.ID || retrieved.UserID != user.ID || retrieved.Email != user.Email {
+		t.Errorf("Retrieved token mismatch: got %+v, want %+v", retrieved, token)
+	}
+	if retrieved.UsedAt != nil {
+		t.Error("New token should not be used")
+	}
+
+	if _, err := repo.GetByHash(ctx, "missing"); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected not found for unknown token hash, got %v", err)
+	}
+}
+
+func TestEmailVerificationTokenRepository_MarkUsedAndInvalidate(t *testing.T) {
+	db := setupTestDB(t)
+	userRepo := NewPostgresUserRepository(db)
+	user := createEmailVerificationTestUser(t, userRepo, "vera.fied@example.com")
+	other := createEmailVerificationTestUser(t, userRepo, "other.user@example.com")
+	repo := NewPostgresEmailVerificationTokenRepository(db)
+	ctx := context.Background()
+
+	tokens := map[string]*models.EmailVerificationToken{}
+	for _, tc := range []struct {
+		user *models.User
+		hash string
+	}{{user, "first"}, {user, "second"}, {other, "other"}} {
+		token := &models.EmailVerificationToken{UserID: tc.user.ID, Email: tc.user.Email, TokenHash: tc.hash, ExpiresAt: time.Now().Add(time.Hour)}
+		if err := repo.Create(ctx, token); err != nil {
+			t.Fatalf("Failed to create email verification token: %v", err)
+		}
+		tokens[tc.hash] = token
+	}
+
+	if err := repo.MarkUsed(ctx, tokens["first"].ID); err != nil {
+		t.Fatalf("Failed to mark token used: %v", err)
+	}
+	// A token can only be spent once
+	if err := repo.MarkUsed(ctx, tokens["first"].ID); !errors.Is(err, ErrEmailVerificationTokenUsed) {
+		t.Errorf("Expected ErrEmailVerificationTokenUsed, got %v", err)
+	}
+
+	if err := repo.InvalidateForUser(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to invalidate tokens: %v", err)
+	}
+	for hash, wantUsed := range map[string]bool{"first": true, "second": true, "other": false} {
+		token, err := repo.GetByHash(ctx, hash)
+		if err != nil {
+			t.Fatalf("Failed to get email verification token: %v", err)
+		}
+		if (token.UsedAt != nil) != wantUsed {
+			t.Errorf("Token %s: expected used=%v, got UsedAt=%v", hash, wantUsed, token.UsedAt)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/models"
+)
+
+// MemorySessionRepository keeps sessions in process memory. Sessions are lost
+// on restart and not shared between replicas, so it suits tests and local
+// development rather than production.
+type MemorySessionRepository struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]models.Session
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{sessions: make(map[uuid.UUID]models.Session)}
+}
+
+func (r *MemorySessionRepository) Create(_ context.Context, session *models.Session) error {
+	prepareSession(session)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *MemorySessionRepository) GetByID(_ context.Context, id uuid.UUID) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, errSessionNotFound()
+	}
+	return &session, nil
+}
+
+func (r *MemorySessionRepository) ListActive(_ context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	sessions := []*models.Session{}
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, &session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (r *MemorySessionRepository) Touch(_ context.Context, id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessions[id]; ok {
+		session.LastSeenAt = at
+		r.sessions[id] = session
+	}
+	return nil
+}
+
+func (r *MemorySessionRepository) Revoke(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessions[id]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+		r.sessions[id] = session
+	}
+	return nil
+}
+
+func (r *MemorySessionRepository) RevokeAllForUser(_ context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			r.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+func (r *MemorySessionRepository) RevokeOthersForUser(_ context.Context, userID, keepID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range r.sessions {
+		if session.UserID == userID && id != keepID && session.RevokedAt == nil {
+			session.RevokedAt = &now
+			r.sessions[id] = session
+		}
+	}
+	return nil
+}
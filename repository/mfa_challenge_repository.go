@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+	"gorm.io/gorm"
+)
+
+// ErrMFAChallengeUsed is returned by MarkUsed when the challenge was already completed
+var ErrMFAChallengeUsed = errors.New("MFA challenge has already been used")
+
+type MFAChallengeRepository interface {
+	Create(ctx context.Context, challenge *models.MFAChallenge) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.MFAChallenge, error)
+	RecordFailedAttempt(ctx context.Context, id uuid.UUID) (int, error)
+	MarkUsed(ctx context.Context, id uuid.UUID) error
+}
+
+type PostgresMFAChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresMFAChallengeRepository(db *gorm.DB) *PostgresMFAChallengeRepository {
+	return &PostgresMFAChallengeRepository{db: db}
+}
+
+func (r *PostgresMFAChallengeRepository) Create(ctx context.Context, challenge *models.MFAChallenge) error {
+	challenge.ID = uuid.New()
+	challenge.CreatedAt = time.Now()
+	if challenge.Purpose == "" {
+		challenge.Purpose = models.MFAChallengeVerify
+	}
+
+	if err := r.db.WithContext(ctx).Create(challenge).Error; err != nil {
+		return fmt.Errorf("failed to create MFA challenge: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresMFAChallengeRepository) GetByHash(ctx context.Context, tokenHash string) (*models.MFAChallenge, error) {
+	challenge := &models.MFAChallenge{}
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(challenge).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, apperrors.NotFound("mfa_challenge_not_found", "MFA challenge not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MFA challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// RecordFailedAttempt counts a wrong code against the challenge and returns
+// the number of failed attempts so far
+func (r *PostgresMFAChallengeRepository) RecordFailedAttempt(ctx context.Context, id uuid.UUID) (int, error) {
+	var attempts int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.MFAChallenge{}).
+			Where("id = ?", id).
+			Update("failed_attempts", gorm.Expr("failed_attempts + 1")).Error
+		if err != nil {
+			return fmt.Errorf("failed to record MFA attempt: %w", err)
+		}
+		err = tx.Model(&models.MFAChallenge{}).
+			Where("id = ?", id).
+			Pluck("failed_attempts", &attempts).Error
+		if err != nil {
+			return fmt.Errorf("failed to get MFA attempts: %w", err)
+		}
+		return nil
+	})
+	return attempts, err
+}
+
+// MarkUsed completes the challenge. The update only applies to an unused
+// challenge, so it cannot be exchanged for tokens twice.
+func (r *PostgresMFAChallengeRepository) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&models.MFAChallenge{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark MFA challenge used: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrMFAChallengeUsed
+	}
+	return nil
+}
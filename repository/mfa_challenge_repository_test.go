@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+)
+
+func TestMFAChallengeRepository(t *testing.T) {
+	db := setupTestDB(t)
+	user := createMFATestUser(t, NewPostgresUserRepository(db))
+	repo := NewPostgresMFAChallengeRepository(db)
+	ctx := context.Background()
+
+	challenge := &models.MFAChallenge{
+		UserID:    user.ID,
+		TokenHash: "challenge-hash",
+		ExpiresAt: time.Now().Add(5 * time.Minute),
+	}
+	if err := repo.Create(ctx, challenge); err != nil {
+		t.Fatalf("Failed to create MFA challenge: %v", err)
+	}
+
+	retrieved, err := repo.GetByHash(ctx, "challenge-hash")
+	if err != nil {
+		t.Fatalf("Failed to get MFA challenge: %v", err)
+	}
+	if retrieved.ID != challenge.ID || retrieved.UserID != user.ID || retrieved.Purpose != models.MFAChallengeVerify {
+		t.Errorf("Retrieved challenge mismatch: got %+v, want %+v", retrieved, challenge)
+	}
+	if _, err := repo.GetByHash(ctx, "missing"); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected not found for unknown challenge, got %v", err)
+	}
+
+	for want := 1; want <= 2; want++ {
+		attempts, err := repo.RecordFailedAttempt(ctx, challenge.ID)
+		if err != nil {
+			t.Fatalf("Failed to record failed attempt: %v", err)
+		}
+		if attempts != want {
+			t.Errorf("Expected %d failed attempts, got %d", want, attempts)
+		}
+	}
+
+	if err := repo.MarkUsed(ctx, challenge.ID); err != nil {
+		t.Fatalf("Failed to mark challenge used: %v", err)
+	}
+	if err := repo.MarkUsed(ctx, challenge.ID); !errors.Is(err, ErrMFAChallengeUsed) {
+		t.Errorf("Expected second use to fail, got %v", err)
+	}
+}
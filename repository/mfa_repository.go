@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTOTPStepUsed is returned by UseTOTPStep when a code from the same or a
+	// later time step was already accepted
+	ErrTOTPStepUsed = errors.New("TOTP code has already been used")
+	// ErrRecoveryCodeInvalid is returned by UseRecoveryCode for unknown and spent codes
+	ErrRecoveryCodeInvalid = errors.New("recovery code is invalid or has already been used")
+)
+
+// MFARepository stores the TOTP secrets and recovery codes of users enrolled
+// in multi-factor authentication. The secret and whether MFA is enabled are
+// read through UserRepository.GetUserAuthentication.
+type MFARepository interface {
+	SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	EnableTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, userID uuid.UUID) error
+}
+
+type PostgresMFARepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresMFARepository(db *gorm.DB) *PostgresMFARepository {
+	return &PostgresMFARepository{db: db}
+}
+
+// SetTOTPSecret stores a new secret for the user to confirm. MFA stays
+// disabled until EnableTOTP is called.
+func (r *PostgresMFARepository) SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	result := r.db.WithContext(ctx).Model(&models.UserAuthentication{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"totp_secret":    secret,
+			"mfa_enabled_at": nil,
+			"totp_last_step": 0,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to store TOTP secret: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("authentication_not_found", "authentication not found")
+	}
+	return nil
+}
+
+// EnableTOTP turns on MFA for the user, recording step as used, and replaces
+// their recovery codes with the given hashes
+func (r *PostgresMFARepository) EnableTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&models.UserAuthentication{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"mfa_enabled_at": now,
+				"totp_last_step": step,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to enable TOTP: %w", err)
+		}
+		return replaceRecoveryCodes(tx, userID, recoveryCodeHashes, now)
+	})
+}
+
+// ReplaceRecoveryCodes discards the user's recovery codes, spent or not, in
+// favour of the given hashes
+func (r *PostgresMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, recoveryCodeHashes, time.Now())
+	})
+}
+
+// DisableTOTP turns off MFA for the user, forgetting their secret and
+// recovery codes, so that enrolling again starts from scratch
+func (r *PostgresMFARepository) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserAuthentication{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"totp_secret":    nil,
+				"mfa_enabled_at": nil,
+				"totp_last_step": 0,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to disable TOTP: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("authentication_not_found", "authentication not found")
+		}
+		return replaceRecoveryCodes(tx, userID, nil, time.Now())
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID, recoveryCodeHashes []string, now time.Time) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return fmt.Errorf("failed to remove recovery codes: %w", err)
+	}
+	codes := make([]*models.MFARecoveryCode, 0, len(recoveryCodeHashes))
+	for _, hash := range recoveryCodeHashes {
+		codes = append(codes, &models.MFARecoveryCode{
+			ID:        uuid.New(),
+			UserID:    userID,
+			CodeHash:  hash,
+			CreatedAt: now,
+		})
+	}
+	if len(codes) > 0 {
+		if err := tx.Create(&codes).Error; err != nil {
+			return fmt.Errorf("failed to store recovery codes: %w", err)
+		}
+	}
+	return nil
+}
+
+// UseTOTPStep records that a code from step was accepted. The update only
+// applies to steps after the last accepted one, so a code cannot be replayed
+// even by concurrent requests.
+func (r *PostgresMFARepository) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	result := r.db.WithContext(ctx).Model(&models.UserAuthentication{}).
+		Where("user_id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return fmt.Errorf("failed to record TOTP code use: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTOTPStepUsed
+	}
+	return nil
+}
+
+// UseRecoveryCode spends one of the user's unused recovery codes
+func (r *PostgresMFARepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	result := r.db.WithContext(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecoveryCodeInvalid
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+)
+
+func createMFATestUser(t *testing.T, repo *PostgresUserRepository) *models.User {
+	t.Helper()
+	user := &models.User{
+		FirstName: "Otto",
+		LastName:  "Pee",
+		Email:     "otto.pee@example.com",
+	}
+	if err := repo.Create(context.Background(), user, &models.UserAuthentication{PasswordHash: "hash"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	return user
+}
+
+func TestMFARepository_EnrollAndEnable(t *testing.T) {
+	db := setupTestDB(t)
+	userRepo := NewPostgresUserRepository(db)
+	user := createMFATestUser(t, userRepo)
+	repo := NewPostgresMFARepository(db)
+	ctx := context.Background()
+
+	if err := repo.SetTOTPSecret(ctx, user.ID, "JBSWY3DPEHPK3PXP"); err != nil {
+		t.Fatalf("Failed to store TOTP secret: %v", err)
+	}
+	pending, err := userRepo.GetUserAuthentication(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get user authentication: %v", err)
+	}
+	if pending.TOTPSecret == nil || *pending.TOTPSecret != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Expected stored secret, got %v", pending.TOTPSecret)
+	}
+	if pending.MFAEnabledAt != nil {
+		t.Error("Expected MFA to stay disabled until enabled")
+	}
+
+	if err := repo.EnableTOTP(ctx, user.ID, 100, []string{"hash-a", "hash-b"}); err != nil {
+		t.Fatalf("Failed to enable TOTP: %v", err)
+	}
+	enabled, err := userRepo.GetUserAuthentication(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get user authentication: %v", err)
+	}
+	if enabled.MFAEnabledAt == nil || enabled.TOTPLastStep != 100 {
+		t.Errorf("Expected MFA enabled at step 100, got %v at step %d", enabled.MFAEnabledAt, enabled.TOTPLastStep)
+	}
+
+	// Enabling again replaces the recovery codes
+	if err := repo.EnableTOTP(ctx, user.ID, 200, []string{"hash-c"}); err != nil {
+		t.Fatalf("Failed to enable TOTP: %v", err)
+	}
+	var count int64
+	db.Model(&models.MFARecoveryCode{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected previous recovery codes to be replaced, got %d codes", count)
+	}
+
+	if err := repo.SetTOTPSecret(ctx, uuid.New(), "JBSWY3DPEHPK3PXP"); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected not found for unknown user, got %v", err)
+	}
+}
+
+func TestMFARepository_UseTOTPStep(t *testing.T) {
+	db := setupTestDB(t)
+	user := createMFATestUser(t, NewPostgresUserRepository(db))
+	repo := NewPostgresMFARepository(db)
+	ctx := context.Background()
+
+	if err := repo.EnableTOTP(ctx, user.ID, 100, nil); err != nil {
+		t.Fatalf("Failed to enable TOTP: %v", err)
+	}
+
+	if err := repo.UseTOTPStep(ctx, user.ID, 100); !errors.Is(err, ErrTOTPStepUsed) {
+		t.Errorf("Expected the activation step to be spent, got %v", err)
+	}
+	if err := repo.UseTOTPStep(ctx, user.ID, 101); err != nil {
+		t.Fatalf("Expected a later step to be accepted, got %v", err)
+	}
+	if err := repo.UseTOTPStep(ctx, user.ID, 101); !errors.Is(err, ErrTOTPStepUsed) {
+		t.Errorf("Expected a replayed step to be rejected, got %v", err)
+	}
+}
+
+func TestMFARepository_UseRecoveryCode(t *testing.T) {
+	db := setupTestDB(t)
+	user := createMFATestUser(t, NewPostgresUserRepository(db))
+	repo := NewPostgresMFARepository(db)
+	ctx := context.Background()
+
+	if err := repo.EnableTOTP(ctx, user.ID, 1, []string{"hash-a", "hash-b"}); err != nil {
+		t.Fatalf("Failed to enable TOTP: %v", err)
+	}
+
+	if err := repo.UseRecoveryCode(ctx, user.ID, "hash-a"); err != nil {
+		t.Fatalf("Failed to use recovery code: %v", err)
+	}
+	if err := repo.UseRecoveryCode(ctx, user.ID, "hash-a"); !errors.Is(err, ErrRecoveryCodeInvalid) {
+		t.Errorf("Expected a spent code to be rejected, got %v", err)
+	}
+	if err := repo.UseRecoveryCode(ctx, user.ID, "unknown"); !errors.Is(err, ErrRecoveryCodeInvalid) {
+		t.Errorf("Expected an unknown code to be rejected, got %v", err)
+	}
+	if err := repo.UseRecoveryCode(ctx, uuid.New(), "hash-b"); !errors.Is(err, ErrRecoveryCodeInvalid) {
+		t.Errorf("Expected another user's code to be rejected, got %v", err)
+	}
+}
+
+func TestMFARepository_ReplaceRecoveryCodes(t *testing.T) {
+	db := setupTestDB(t)
+	user := createMFATestUser(t, NewPostgresUserRepository(db))
+	repo := NewPostgresMFARepository(db)
+	ctx := context.Background()
+
+	if err := repo.EnableTOTP(ctx, user.ID, 1, []string{"hash-a", "hash-b"}); err != nil {
+		t.Fatalf("Failed to enable TOTP: %v", err)
+	}
+	if err := repo.UseRecoveryCode(ctx, user.ID, "hash-a"); err != nil {
+		t.Fatalf("Failed to use recovery code: %v", err)
+	}
+
+	if err := repo.ReplaceRecoveryCodes(ctx, user.ID, []string{"hash-c"}); err != nil {
+		t.Fatalf("Failed to replace recovery codes: %v", err)
+	}
+	if err := repo.UseRecoveryCode(ctx, user.ID, "hash-b"); !errors.Is(err, ErrRecoveryCodeInvalid) {
+		t.Errorf("Expected a replaced code to be rejected, got %v", err)
+	}
+	if err := repo.UseRecoveryCode(ctx, user.ID, "hash-c"); err != nil {
+		t.Errorf("Expected the new code to be accepted, got %v", err)
+	}
+}
+
+func TestMFARepository_DisableTOTP(t *testing.T) {
+	db := setupTestDB(t)
+	userRepo := NewPostgresUserRepository(db)
+	user := createMFATestUser(t, userRepo)
+	repo := NewPostgresMFARepository(db)
+	ctx := context.Background()
+
+	if err := repo.SetTOTPSecret(ctx, user.ID, "JBSWY3DPEHPK3PXP"); err != nil {
+		t.Fatalf("Failed to store TOTP secret: %v", err)
+	}
+	if err := repo.EnableTOTP(ctx, user.ID, 100, []string{"hash-a"}); err != nil {
+		t.Fatalf("Failed to enable TOTP: %v", err)
+	}
+
+	if err := repo.DisableTOTP(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to disable TOTP: %v", err)
+	}
+	disabled, err := userRepo.GetUserAuthentication(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get user authentication: %v", err)
+	}
+	if disabled.TOTPSecret != nil || disabled.MFAEnabledAt != nil || disabled.TOTPLastStep != 0 {
+		t.Errorf("Expected MFA to be cleared, got %+v", disabled)
+	}
+	var count int64
+	db.Model(&models.MFARecoveryCode{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected recovery codes to be removed, got %d codes", count)
+	}
+
+	if err := repo.DisableTOTP(ctx, uuid.New()); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected not found for unknown user, got %v", err)
+	}
+}
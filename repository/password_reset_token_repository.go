@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+	"gorm.io/gorm"
+)
+
+// ErrPasswordResetTokenUsed is returned by MarkUsed when the token was already spent
+var ErrPasswordResetTokenUsed = errors.New("password reset token has already been used")
+
+type PasswordResetTokenRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id uuid.UUID) error
+	InvalidateForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type PostgresPasswordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresPasswordResetTokenRepository(db *gorm.DB) *PostgresPasswordResetTokenRepository {
+	return &PostgresPasswordResetTokenRepository{db: db}
+}
+
+func (r *PostgresPasswordResetTokenRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresPasswordResetTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	token := &models.PasswordResetToken{}
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(token).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, apperrors.NotFound("password_reset_token_not_found", "password reset token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+	return token, nil
+}
+
+// MarkUsed spends the token. The update only applies to an unused token, so
+// two concurrent confirmations with the same token cannot both succeed.
+func (r *PostgresPasswordResetTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark password reset token used: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPasswordResetTokenUsed
+	}
+	return nil
+}
+
+// InvalidateForUser spends every outstanding token issued to the user
+func (r *PostgresPasswordResetTokenRepository) InvalidateForUser(ctx context.Context, userID uuid.UUID) error {
+	err := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+	return nil
+}
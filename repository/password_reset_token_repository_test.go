@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+)
+
+func createPasswordResetTestUser(t *testing.T, repo *PostgresUserRepository) *models.User {
+	t.Helper()
+	user := &models.User{
+		FirstName: "Reese",
+		LastName:  "Set",
+		Email:     "reese.set@example.com",
+	}
+	if err := repo.Create(context.Background(), user, &models.UserAuthentication{PasswordHash: "hash"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	return user
+}
+
+func TestPasswordResetTokenRepository_CreateAndGetByHash(t *testing.T) {
+	db := setupTestDB(t)
+	user := createPasswordResetTestUser(t, NewPostgresUserRepository(db))
+	repo := NewPostgresPasswordResetTokenRepository(db)
+	ctx := context.Background()
+
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: "reset-hash-1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := repo.Create(ctx, token); err != nil {
+		t.Fatalf("Failed to create password reset token: %v", err)
+	}
+
+	retrieved, err := repo.GetByHash(ctx, "reset-hash-1")
+	if err != nil {
+		t.Fatalf("Failed to get password reset token: %v", err)
+	}
+	if retrieved.ID != token.ID || retrieved.UserID != user.ID {
+		t.Errorf("Retrieved token mismatch: got %+v, want %+v", retrieved, token)
+	}
+	if retrieved.UsedAt != nil {
+		t.Error("New token should not be used")
+	}
+
+	if _, err := repo.GetByHash(ctx, "missing"); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected not found for unknown token hash, got %v", err)
+	}
+}
+
+func TestPasswordResetTokenRepository_MarkUsed(t *testing.T) {
+	db := setupTestDB(t)
+	user := createPasswordResetTestUser(t, NewPostgresUserRepository(db))
+	repo := NewPostgresPasswordResetTokenRepository(db)
+	ctx := context.Background()
+
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: "reset-hash-1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := repo.Create(ctx, token); err != nil {
+		t.Fatalf("Failed to create password reset token: %v", err)
+	}
+
+	if err := repo.MarkUsed(ctx, token.ID); err != nil {
+		t.Fatalf("Failed to mark token used: %v", err)
+	}
+	retrieved, err := repo.GetByHash(ctx, "reset-hash-1")
+	if err != nil {
+		t.Fatalf("Failed to get password reset token: %v", err)
+	}
+	if retrieved.UsedAt == nil {
+		t.Error("Expected token to be marked used")
+	}
+
+	// A token can only be spent once
+	if err := repo.MarkUsed(ctx, token.ID); !errors.Is(err, ErrPasswordResetTokenUsed) {
+		t.Errorf("Expected ErrPasswordResetTokenUsed, got %v", err)
+	}
+}
+
+func TestPasswordResetTokenRepository_InvalidateForUser(t *testing.T) {
+	db := setupTestDB(t)
+	userRepo := NewPostgresUserRepository(db)
+	user := createPasswordResetTestUser(t, userRepo)
+	other := &models.User{FirstName: "Other", LastName: "User", Email: "other.user@example.com"}
+	if err := userRepo.Create(context.Background(), other, &models.UserAuthentication{PasswordHash: "hash"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	repo := NewPostgresPasswordResetTokenRepository(db)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		user *models.User
+		hash string
+	}{{user, "first"}, {user, "second"}, {other, "other"}} {
+		token := &models.PasswordResetToken{UserID: tc.user.ID, TokenHash: tc.hash, ExpiresAt: time.Now().Add(time.Hour)}
+		if err := repo.Create(ctx, token); err != nil {
+			t.Fatalf("Failed to create password reset token: %v", err)
+		}
+	}
+
+	if err := repo.InvalidateForUser(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to invalidate tokens: %v", err)
+	}
+
+	for hash, wantUsed := range map[string]bool{"first": true, "second": true, "other": false} {
+		token, err := repo.GetByHash(ctx, hash)
+		if err != nil {
+			t.Fatalf("Failed to get password reset token: %v", err)
+		}
+		if (token.UsedAt != nil) != wantUsed {
+			t.Errorf("Token %s: expected used=%v, got UsedAt=%v", hash, wantUsed, token.UsedAt)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PermissionRepository interface {
+	GetByID(ctx context.Context, id int) (*models.Permission, error)
+	GetByNames(ctx context.Context, names []string) ([]*models.Permission, error)
+	List(ctx context.Context) ([]*models.Permission, error)
+	AssignToAccessLevel(ctx context.Context, accessLevelID, permissionID int) error
+	RemoveFromAccessLevel(ctx context.Context, accessLevelID, permissionID int) error
+	GetByAccessLevels(ctx context.Context, accessLevelIDs []int) ([]*models.Permission, error)
+}
+
+type PostgresPermissionRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresPermissionRepository(db *gorm.DB) *PostgresPermissionRepository {
+	return &PostgresPermissionRepository{db: db}
+}
+
+func (r *PostgresPermissionRepository) GetByID(ctx context.Context, id int) (*models.Permission, error) {
+	permission := &models.Permission{}
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(permission).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, apperrors.NotFound("permission_not_found", "permission not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permission: %w", err)
+	}
+	return permission, nil
+}
+
+func (r *PostgresPermissionRepository) GetByNames(ctx context.Context, names []string) ([]*models.Permission, error) {
+	var permissions []*models.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	err := r.db.WithContext(ctx).Where("name IN ?", names).Order("name ASC").Find(&permissions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+func (r *PostgresPermissionRepository) List(ctx context.Context) ([]*models.Permission, error) {
+	var permissions []*models.Permission
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&permissions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+func (r *PostgresPermissionRepository) AssignToAccessLevel(ctx context.Context, accessLevelID, permissionID int) error {
+	now := time.Now()
+	link := &models.AccessLevelPermission{
+		AccessLevelID: accessLevelID,
+		PermissionID:  permissionID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "access_level_id"}, {Name: "permission_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"deleted_at": nil, "updated_at": now}),
+	}).Create(link).Error
+
+	if err != nil {
+		return fmt.Errorf("failed to assign permission to access level: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresPermissionRepository) RemoveFromAccessLevel(ctx context.Context, accessLevelID, permissionID int) error {
+	result := r.db.WithContext(ctx).
+		Where("access_level_id = ? AND permission_id = ?", accessLevelID, permissionID).
+		Delete(&models.AccessLevelPermission{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove permission from access level: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("access_level_permission_not_found", "access level permission not found")
+	}
+	return nil
+}
+
+// GetByAccessLevels returns the distinct permissions granted by any of the given access levels
+func (r *PostgresPermissionRepository) GetByAccessLevels(ctx context.Context, accessLevelIDs []int) ([]*models.Permission, error) {
+	var permissions []*models.Permission
+	if len(accessLevelIDs) == 0 {
+		return permissions, nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Distinct("permissions.*").
+		Joins("INNER JOIN access_level_permissions ON permissions.id = access_level_permissions.permission_id").
+		Where("access_level_permissions.access_level_id IN ? AND access_level_permissions.deleted_at IS NULL", accessLevelIDs).
+		Order("permissions.name ASC").
+		Find(&permissions).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access level permissions: %w", err)
+	}
+	return permissions, nil
+}
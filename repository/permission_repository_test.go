@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wabtcdi/user_service/models"
+	"gorm.io/gorm"
+)
+
+func createTestPermissions(t *testing.T, db *gorm.DB, names ...string) []*models.Permission {
+	t.Helper()
+	permissions := make([]*models.Permission, 0, len(names))
+	for _, name := range names {
+		permission := &models.Permission{Name: name}
+		if err := db.Create(permission).Error; err != nil {
+			t.Fatalf("Failed to create permission %s: %v", name, err)
+		}
+		permissions = append(permissions, permission)
+	}
+	return permissions
+}
+
+func createTestAccessLevel(t *testing.T, repo *PostgresAccessLevelRepository, name string) *models.AccessLevel {
+	t.Helper()
+	accessLevel := &models.AccessLevel{Name: name}
+	if err := repo.Create(context.Background(), accessLevel); err != nil {
+		t.Fatalf("Failed to create access level %s: %v", name, err)
+	}
+	return accessLevel
+}
+
+func TestPermissionRepository_GetByIDAndNames(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresPermissionRepository(db)
+	ctx := context.Background()
+	created := createTestPermissions(t, db, "users:write", "users:read")
+
+	permission, err := repo.GetByID(ctx, created[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get permission: %v", err)
+	}
+	if permission.Name != "users:write" {
+		t.Errorf("Expected users:write, got %s", permission.Name)
+	}
+
+	if _, err := repo.GetByID(ctx, 999); err == nil {
+		t.Error("Expected error for unknown permission, got nil")
+	}
+
+	permissions, err := repo.GetByNames(ctx, []string{"users:read", "users:write", "unknown"})
+	if err != nil {
+		t.Fatalf("Failed to get permissions by name: %v", err)
+	}
+	if len(permissions) != 2 || permissions[0].Name != "users:read" {
+		t.Errorf("Expected [users:read users:write], got %v", permissionNames(permissions))
+	}
+
+	all, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list permissions: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("Expected 2 permissions, got %d", len(all))
+	}
+}
+
+func TestPermissionRepository_AssignAndRemove(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresPermissionRepository(db)
+	accessLevelRepo := NewPostgresAccessLevelRepository(db)
+	ctx := context.Background()
+
+	reader := createTestAccessLevel(t, accessLevelRepo, "reader")
+	writer := createTestAccessLevel(t, accessLevelRepo, "writer")
+	permissions := createTestPermissions(t, db, "users:read", "users:write")
+
+	for _, link := range []struct{ accessLevelID, permissionID int }{
+		{reader.ID, permissions[0].ID},
+		{writer.ID, permissions[0].ID},
+		{writer.ID, permissions[1].ID},
+	} {
+		if err := repo.AssignToAccessLevel(ctx, link.accessLevelID, link.permissionID); err != nil {
+			t.Fatalf("Failed to assign permission: %v", err)
+		}
+	}
+
+	// Assigning again is a no-op
+	if err := repo.AssignToAccessLevel(ctx, reader.ID, permissions[0].ID); err != nil {
+		t.Fatalf("Failed to reassign permission: %v", err)
+	}
+
+	combined, err := repo.GetByAccessLevels(ctx, []int{reader.ID, writer.ID})
+	if err != nil {
+		t.Fatalf("Failed to get access level permissions: %v", err)
+	}
+	if names := permissionNames(combined); len(names) != 2 || names[0] != "users:read" || names[1] != "users:write" {
+		t.Errorf("Expected distinct [users:read users:write], got %v", names)
+	}
+
+	if err := repo.RemoveFromAccessLevel(ctx, writer.ID, permissions[1].ID); err != nil {
+		t.Fatalf("Failed to remove permission: %v", err)
+	}
+	if err := repo.RemoveFromAccessLevel(ctx, writer.ID, permissions[1].ID); err == nil {
+		t.Error("Expected error removing an unassigned permission, got nil")
+	}
+
+	remaining, err := repo.GetByAccessLevels(ctx, []int{writer.ID})
+	if err != nil {
+		t.Fatalf("Failed to get access level permissions: %v", err)
+	}
+	if names := permissionNames(remaining); len(names) != 1 || names[0] != "users:read" {
+		t.Errorf("Expected [users:read] after removal, got %v", names)
+	}
+
+	// A removed permission can be granted again
+	if err := repo.AssignToAccessLevel(ctx, writer.ID, permissions[1].ID); err != nil {
+		t.Fatalf("Failed to restore permission: %v", err)
+	}
+	restored, _ := repo.GetByAccessLevels(ctx, []int{writer.ID})
+	if len(restored) != 2 {
+		t.Errorf("Expected 2 permissions after restore, got %d", len(restored))
+	}
+}
+
+func permissionNames(permissions []*models.Permission) []string {
+	names := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		names = append(names, p.Name)
+	}
+	return names
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+	"gorm.io/gorm"
+)
+
+// ErrRefreshTokenRevoked is returned by Rotate when the token was revoked concurrently
+var ErrRefreshTokenRevoked = errors.New("refresh token has been revoked")
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	Rotate(ctx context.Context, current *models.RefreshToken, next *models.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+	// RevokeOtherFamilies revokes the user's refresh tokens outside keepFamilyID
+	RevokeOtherFamilies(ctx context.Context, userID, keepFamilyID uuid.UUID) error
+}
+
+type PostgresRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresRefreshTokenRepository(db *gorm.DB) *PostgresRefreshTokenRepository {
+	return &PostgresRefreshTokenRepository{db: db}
+}
+
+func (r *PostgresRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return createRefreshToken(r.db.WithContext(ctx), token)
+}
+
+func (r *PostgresRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(token).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, apperrors.NotFound("refresh_token_not_found", "refresh token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// Rotate revokes current and stores next in its place. The revocation only
+// applies to a still-active token, so two concurrent rotations of the same
+// token cannot both succeed.
+func (r *PostgresRefreshTokenRepository) Rotate(ctx context.Context, current *models.RefreshToken, next *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := createRefreshToken(tx, next); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{
+				"revoked_at":     now,
+				"replaced_by_id": next.ID,
+				"updated_at":     now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to revoke refresh token: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenRevoked
+		}
+
+		current.RevokedAt = &now
+		current.ReplacedByID = &next.ID
+		return nil
+	})
+}
+
+func (r *PostgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	now := time.Now()
+	err := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	err := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+func createRefreshToken(db *gorm.DB, token *models.RefreshToken) error {
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+	token.UpdatedAt = token.CreatedAt
+
+	if err := db.Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRefreshTokenRepository) RevokeOtherFamilies(ctx context.Context, userID, keepFamilyID uuid.UUID) error {
+	now := time.Now()
+	err := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/models"
+)
+
+func createRefreshTokenTestUser(t *testing.T, repo *PostgresUserRepository) *models.User {
+	t.Helper()
+	user := &models.User{
+		FirstName: "Rita",
+		LastName:  "Fresh",
+		Email:     "rita.fresh@example.com",
+	}
+	if err := repo.Create(context.Background(), user, &models.UserAuthentication{PasswordHash: "hash"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	return user
+}
+
+func TestRefreshTokenRepository_CreateAndGetByHash(t *testing.T) {
+	db := setupTestDB(t)
+	user := createRefreshTokenTestUser(t, NewPostgresUserRepository(db))
+	repo := NewPostgresRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	token := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  uuid.New(),
+		TokenHash: "hash-1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := repo.Create(ctx, token); err != nil {
+		t.Fatalf("Failed to create refresh token: %v", err)
+	}
+	if token.ID == uuid.Nil {
+		t.Error("Refresh token ID was not set")
+	}
+
+	retrieved, err := repo.GetByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("Failed to get refresh token: %v", err)
+	}
+	if retrieved.ID != token.ID || retrieved.FamilyID != token.FamilyID {
+		t.Errorf("Retrieved token mismatch: got %+v, want %+v", retrieved, token)
+	}
+
+	if _, err := repo.GetByHash(ctx, "missing"); err == nil {
+		t.Error("Expected error for unknown token hash, got nil")
+	}
+}
+
+func TestRefreshTokenRepository_Rotate(t *testing.T) {
+	db := setupTestDB(t)
+	user := createRefreshTokenTestUser(t, NewPostgresUserRepository(db))
+	repo := NewPostgresRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	familyID := uuid.New()
+	current := &models.RefreshToken{UserID: user.ID, FamilyID: familyID, TokenHash: "hash-1", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.Create(ctx, current); err != nil {
+		t.Fatalf("Failed to create refresh token: %v", err)
+	}
+
+	next := &models.RefreshToken{UserID: user.ID, FamilyID: familyID, TokenHash: "hash-2", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.Rotate(ctx, current, next); err != nil {
+		t.Fatalf("Failed to rotate refresh token: %v", err)
+	}
+
+	rotated, _ := repo.GetByHash(ctx, "hash-1")
+	if rotated.RevokedAt == nil {
+		t.Error("Expected rotated token to be revoked")
+	}
+	if rotated.ReplacedByID == nil || *rotated.ReplacedByID != next.ID {
+		t.Errorf("Expected rotated token to point at its replacement")
+	}
+
+	// Rotating the same token again must fail and must not store the new token
+	again := &models.RefreshToken{UserID: user.ID, FamilyID: familyID, TokenHash: "hash-3", ExpiresAt: time.Now().Add(time.Hour)}
+	err := repo.Rotate(ctx, current, again)
+	if !errors.Is(err, ErrRefreshTokenRevoked) {
+		t.Fatalf("Expected ErrRefreshTokenRevoked, got %v", err)
+	}
+	if _, err := repo.GetByHash(ctx, "hash-3"); err == nil {
+		t.Error("Expected failed rotation to be rolled back")
+	}
+}
+
+func TestRefreshTokenRepository_RevokeFamily(t *testing.T) {
+	db := setupTestDB(t)
+	user := createRefreshTokenTestUser(t, NewPostgresUserRepository(db))
+	repo := NewPostgresRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	familyID := uuid.New()
+	for _, hash := range []string{"family-1", "family-2"} {
+		if err := repo.Create(ctx, &models.RefreshToken{UserID: user.ID, FamilyID: familyID, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatalf("Failed to create refresh token: %v", err)
+		}
+	}
+	if err := repo.Create(ctx, &models.RefreshToken{UserID: user.ID, FamilyID: uuid.New(), TokenHash: "other", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Failed to create refresh token: %v", err)
+	}
+
+	if err := repo.RevokeFamily(ctx, familyID); err != nil {
+		t.Fatalf("Failed to revoke family: %v", err)
+	}
+
+	for _, hash := range []string{"family-1", "family-2"} {
+		token, _ := repo.GetByHash(ctx, hash)
+		if token.RevokedAt == nil {
+			t.Errorf("Expected token %s to be revoked", hash)
+		}
+	}
+	other, _ := repo.GetByHash(ctx, "other")
+	if other.RevokedAt != nil {
+		t.Error("Expected token from another family to stay active")
+	}
+
+	keptFamilyID := uuid.New()
+	if err := repo.Create(ctx, &models.RefreshToken{UserID: user.ID, FamilyID: keptFamilyID, TokenHash: "kept", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Failed to create refresh token: %v", err)
+	}
+	if err := repo.RevokeOtherFamilies(ctx, user.ID, keptFamilyID); err != nil {
+		t.Fatalf("Failed to revoke other families: %v", err)
+	}
+	other, _ = repo.GetByHash(ctx, "other")
+	if other.RevokedAt == nil {
+		t.Error("Expected tokens from other families to be revoked")
+	}
+	kept, _ := repo.GetByHash(ctx, "kept")
+	if kept.RevokedAt != nil {
+		t.Error("Expected the kept family to stay active")
+	}
+
+	if err := repo.RevokeAllForUser(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to revoke user tokens: %v", err)
+	}
+	kept, _ = repo.GetByHash(ctx, "kept")
+	if kept.RevokedAt == nil {
+		t.Error("Expected all user tokens to be revoked")
+	}
+}
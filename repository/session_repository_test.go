@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/models"
+)
+
+// testSessionRepository checks the behaviour every SessionRepository
+// implementation shares
+func testSessionRepository(t *testing.T, repo SessionRepository, userID, otherID uuid.UUID) {
+	ctx := context.Background()
+	userAgent := "Mozilla/5.0 (X11; Linux x86_64)"
+	ip := "203.0.113.7"
+
+	newSession := func(userID uuid.UUID, lastSeen time.Time, expiresIn time.Duration) *models.Session {
+		t.Helper()
+		session := &models.Session{
+			UserID:     userID,
+			UserAgent:  &userAgent,
+			IPAddress:  &ip,
+			LastSeenAt: lastSeen,
+			ExpiresAt:  time.Now().Add(expiresIn),
+		}
+		if err := repo.Create(ctx, session); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session
+	}
+
+	// The ID given by the caller is kept
+	familyID := uuid.New()
+	current := &models.Session{ID: familyID, UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.Create(ctx, current); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if current.ID != familyID || current.LastSeenAt.IsZero() {
+		t.Errorf("Expected ID %s and last seen set, got %+v", familyID, current)
+	}
+
+	older := newSession(userID, time.Now().Add(-time.Hour), time.Hour)
+	expired := newSession(userID, time.Now().Add(-2*time.Hour), -time.Minute)
+	other := newSession(otherID, time.Now(), time.Hour)
+
+	retrieved, err := repo.GetByID(ctx, older.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if retrieved.UserID != userID || retrieved.UserAgent == nil || *retrieved.UserAgent != userAgent ||
+		retrieved.IPAddress == nil || *retrieved.IPAddress != ip {
+		t.Errorf("Retrieved session mismatch: got %+v", retrieved)
+	}
+	if _, err := repo.GetByID(ctx, uuid.New()); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected not found for unknown session, got %v", err)
+	}
+
+	active, err := repo.ListActive(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to list sessions: %v", err)
+	}
+	if len(active) != 2 || active[0].ID != current.ID || active[1].ID != older.ID {
+		t.Errorf("Expected current and older session, most recent first, got %+v", active)
+	}
+
+	touchedAt := time.Now().Add(time.Minute)
+	if err := repo.Touch(ctx, older.ID, touchedAt); err != nil {
+		t.Fatalf("Failed to touch session: %v", err)
+	}
+	retrieved, _ = repo.GetByID(ctx, older.ID)
+	if !retrieved.LastSeenAt.Equal(touchedAt) {
+		t.Errorf("Expected last seen %v, got %v", touchedAt, retrieved.LastSeenAt)
+	}
+
+	if err := repo.Revoke(ctx, older.ID); err != nil {
+		t.Fatalf("Failed to revoke session: %v", err)
+	}
+	retrieved, _ = repo.GetByID(ctx, older.ID)
+	if retrieved.RevokedAt == nil {
+		t.Error("Expected session to be revoked")
+	}
+
+	if err := repo.RevokeOthersForUser(ctx, userID, current.ID); err != nil {
+		t.Fatalf("Failed to revoke other sessions: %v", err)
+	}
+	if active, _ := repo.ListActive(ctx, userID); len(active) != 1 || active[0].ID != current.ID {
+		t.Errorf("Expected only the kept session to stay active, got %+v", active)
+	}
+	retrieved, _ = repo.GetByID(ctx, expired.ID)
+	if retrieved.RevokedAt == nil {
+		t.Error("Expected the expired session to be revoked with the others")
+	}
+
+	if err := repo.RevokeAllForUser(ctx, userID); err != nil {
+		t.Fatalf("Failed to revoke sessions: %v", err)
+	}
+	for id, wantRevoked := range map[uuid.UUID]bool{current.ID: true, expired.ID: true, other.ID: false} {
+		session, err := repo.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to get session: %v", err)
+		}
+		if (session.RevokedAt != nil) != wantRevoked {
+			t.Errorf("Session %s: expected revoked=%v, got RevokedAt=%v", id, wantRevoked, session.RevokedAt)
+		}
+	}
+	if active, _ := repo.ListActive(ctx, userID); len(active) != 0 {
+		t.Errorf("Expected no active sessions after revoking all, got %d", len(active))
+	}
+}
+
+func TestPostgresSessionRepository(t *testing.T) {
+	db := setupTestDB(t)
+	userRepo := NewPostgresUserRepository(db)
+	user := createEmailVerificationTestUser(t, userRepo, "session.user@example.com")
+	other := createEmailVerificationTestUser(t, userRepo, "other.user@example.com")
+
+	testSessionRepository(t, NewPostgresSessionRepository(db), user.ID, other.ID)
+}
+
+func TestMemorySessionRepository(t *testing.T) {
+	testSessionRepository(t, NewMemorySessionRepository(), uuid.New(), uuid.New())
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repositories are the repositories available inside a unit of work. Every
+// call made through them belongs to the same transaction.
+type Repositories struct {
+	Users               UserRepository
+	AccessLevels        AccessLevelRepository
+	Permissions         PermissionRepository
+	RefreshTokens       RefreshTokenRepository
+	PasswordResetTokens PasswordResetTokenRepository
+	AuditEvents         AuditEventRepository
+	MFA                 MFARepository
+	MFAChallenges       MFAChallengeRepository
+	EmailVerifications  EmailVerificationTokenRepository
+}
+
+// UnitOfWork runs multi-step operations atomically: the changes made through
+// the repositories passed to fn are committed only if fn returns nil, and
+// rolled back otherwise
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+type PostgresUnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewPostgresUnitOfWork(db *gorm.DB) *PostgresUnitOfWork {
+	return &PostgresUnitOfWork{db: db}
+}
+
+func (u *PostgresUnitOfWork) Do(ctx context.Context, fn func(repos Repositories) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(Repositories{
+			Users:               NewPostgresUserRepository(tx),
+			AccessLevels:        NewPostgresAccessLevelRepository(tx),
+			Permissions:         NewPostgresPermissionRepository(tx),
+			RefreshTokens:       NewPostgresRefreshTokenRepository(tx),
+			PasswordResetTokens: NewPostgresPasswordResetTokenRepository(tx),
+			AuditEvents:         NewPostgresAuditEventRepository(tx),
+			MFA:                 NewPostgresMFARepository(tx),
+			MFAChallenges:       NewPostgresMFAChallengeRepository(tx),
+			EmailVerifications:  NewPostgresEmailVerificationTokenRepository(tx),
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/wabtcdi/user_service/models"
+)
+
+func TestPostgresUnitOfWork_Do(t *testing.T) {
+	db := setupTestDB(t)
+	uow := NewPostgresUnitOfWork(db)
+	userRepo := NewPostgresUserRepository(db)
+	accessLevelRepo := NewPostgresAccessLevelRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{
+		FirstName: "Unit",
+		LastName:  "Work",
+		Email:     "unit.work@example.com",
+	}
+	if err := userRepo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	alpha := createTestAccessLevel(t, accessLevelRepo, "Alpha")
+	beta := createTestAccessLevel(t, accessLevelRepo, "Beta")
+
+	t.Run("Rollback", func(t *testing.T) {
+		errAbort := errors.New("abort")
+		err := uow.Do(ctx, func(repos Repositories) error {
+			if err := repos.AccessLevels.AssignToUser(ctx, user.ID, alpha.ID); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("Expected abort error, got %v", err)
+		}
+
+		levels, err := accessLevelRepo.GetUserAccessLevels(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Failed to get user access levels: %v", err)
+		}
+		if len(levels) != 0 {
+			t.Errorf("Expected assignment to be rolled back, got %d access levels", len(levels))
+		}
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		err := uow.Do(ctx, func(repos Repositories) error {
+			if _, err := repos.Users.GetByID(ctx, user.ID); err != nil {
+				return err
+			}
+			if err := repos.AccessLevels.AssignToUser(ctx, user.ID, alpha.ID); err != nil {
+				return err
+			}
+			return repos.AccessLevels.AssignToUser(ctx, user.ID, beta.ID)
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		levels, err := accessLevelRepo.GetUserAccessLevels(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Failed to get user access levels: %v", err)
+		}
+		if len(levels) != 2 {
+			t.Errorf("Expected 2 committed access levels, got %d", len(levels))
+		}
+	})
+}
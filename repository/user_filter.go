@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserSortFields are the fields users can be sorted by
+var UserSortFields = []string{"first_name", "last_name", "email", "created_at", "updated_at"}
+
+// UserFilter narrows down and orders a user listing. Zero fields do not
+// filter; without Sort users are listed newest first.
+type UserFilter struct {
+	// EmailPrefix and NamePrefix match case-insensitively; NamePrefix matches
+	// the first or the last name
+	EmailPrefix string
+	NamePrefix  string
+	PhoneNumber string
+	// AccessLevel is the name of an access level assigned to the user
+	AccessLevel    string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	IncludeDeleted bool
+	// OnlyDeleted lists soft-deleted users only
+	OnlyDeleted bool
+	Sort        []UserSort
+}
+
+// UserSort orders users by one of UserSortFields
+type UserSort struct {
+	Field string
+	Desc  bool
+}
+
+// UserCursor is a position in the users ordered by creation time, with the
+// user ID breaking ties between users created at the same time
+type UserCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// apply restricts a query on users to those after the cursor
+func (c UserCursor) apply(db *gorm.DB, ascending bool) *gorm.DB {
+	if ascending {
+		return db.Where("(users.created_at, users.id) > (?, ?)", c.CreatedAt, c.ID)
+	}
+	return db.Where("(users.created_at, users.id) < (?, ?)", c.CreatedAt, c.ID)
+}
+
+// keysetOrder returns the ORDER BY columns matching UserCursor positions
+func keysetOrder(ascending bool) []clause.OrderByColumn {
+	return []clause.OrderByColumn{
+		{Column: clause.Column{Table: "users", Name: "created_at"}, Desc: !ascending},
+		{Column: clause.Column{Table: "users", Name: "id"}, Desc: !ascending},
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// apply adds the filter's conditions to a query on users
+func (f UserFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.IncludeDeleted || f.OnlyDeleted {
+		db = db.Unscoped()
+	}
+	if f.OnlyDeleted {
+		db = db.Where("users.deleted_at IS NOT NULL")
+	}
+	if f.EmailPrefix != "" {
+		db = db.Where(`LOWER(users.email) LIKE LOWER(?) ESCAPE '\'`, likePrefix(f.EmailPrefix))
+	}
+	if f.NamePrefix != "" {
+		prefix := likePrefix(f.NamePrefix)
+		db = db.Where(`(LOWER(users.first_name) LIKE LOWER(?) ESCAPE '\' OR LOWER(users.last_name) LIKE LOWER(?) ESCAPE '\')`, prefix, prefix)
+	}
+	if f.PhoneNumber != "" {
+		db = db.Where("users.phone_number = ?", f.PhoneNumber)
+	}
+	if f.AccessLevel != "" {
+		db = db.Where(`EXISTS (
+			SELECT 1 FROM user_access_levels
+			INNER JOIN access_levels ON access_levels.id = user_access_levels.access_level_id
+			WHERE user_access_levels.user_id = users.id
+				AND user_access_levels.deleted_at IS NULL
+				AND access_levels.deleted_at IS NULL
+				AND access_levels.name = ?)`, f.AccessLevel)
+	}
+	if f.CreatedAfter != nil {
+		db = db.Where("users.created_at >= ?", *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		db = db.Where("users.created_at < ?", *f.CreatedBefore)
+	}
+	return db
+}
+
+// orderBy returns the ORDER BY columns for the filter's sort, ending with the
+// user ID so pages are stable when sort values repeat
+func (f UserFilter) orderBy() ([]clause.OrderByColumn, error) {
+	sort := f.Sort
+	if len(sort) == 0 {
+		sort = []UserSort{{Field: "created_at", Desc: true}}
+	}
+
+	columns := make([]clause.OrderByColumn, 0, len(sort)+1)
+	for _, s := range sort {
+		if !isUserSortField(s.Field) {
+			return nil, apperrors.Validation("invalid_sort", "cannot sort users by %q, sortable fields are %s",
+				s.Field, strings.Join(UserSortFields, ", "))
+		}
+		columns = append(columns, clause.OrderByColumn{
+			Column: clause.Column{Table: "users", Name: s.Field},
+			Desc:   s.Desc,
+		})
+	}
+	return append(columns, clause.OrderByColumn{Column: clause.Column{Table: "users", Name: "id"}}), nil
+}
+
+func isUserSortField(field string) bool {
+	for _, f := range UserSortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
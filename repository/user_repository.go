@@ -2,32 +2,60 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
 	"github.com/wabtcdi/user_service/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrEmailChanged is returned by MarkEmailVerified when the user's email is no
+// longer the address being verified
+var ErrEmailChanged = errors.New("user email has changed")
+
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User, auth *models.UserAuthentication) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, limit, offset int) ([]*models.User, int, error)
+	Restore(ctx context.Context, id uuid.UUID) (*models.User, error)
+	Purge(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, filter UserFilter, limit, offset int) ([]*models.User, error)
+	ListAfter(ctx context.Context, filter UserFilter, after *UserCursor, ascending bool, limit int) ([]*models.User, error)
+	Count(ctx context.Context, filter UserFilter) (int, error)
+	Search(ctx context.Context, query string, limit int) ([]*models.User, error)
 	GetUserAuthentication(ctx context.Context, userID uuid.UUID) (*models.UserAuthentication, error)
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error
+	GetPasswordHistory(ctx context.Context, userID uuid.UUID, limit int) ([]*models.PasswordHistory, error)
+	RecordFailedLogin(ctx context.Context, userID uuid.UUID, at time.Time) (int, error)
+	LockUntil(ctx context.Context, userID uuid.UUID, until time.Time) error
+	ResetFailedLogins(ctx context.Context, userID uuid.UUID) error
+	MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) error
 }
 
 type AccessLevelRepository interface {
 	Create(ctx context.Context, accessLevel *models.AccessLevel) error
 	GetByID(ctx context.Context, id int) (*models.AccessLevel, error)
+	GetByIDs(ctx context.Context, ids []int) ([]*models.AccessLevel, error)
 	GetByName(ctx context.Context, name string) (*models.AccessLevel, error)
 	List(ctx context.Context) ([]*models.AccessLevel, error)
+	Update(ctx context.Context, accessLevel *models.AccessLevel) error
+	Delete(ctx context.Context, id int, removeAssignments bool) error
+	CountUsers(ctx context.Context, id int) (int64, error)
 	AssignToUser(ctx context.Context, userID uuid.UUID, accessLevelID int) error
 	RemoveFromUser(ctx context.Context, userID uuid.UUID, accessLevelID int) error
+	ReplaceUserAccessLevels(ctx context.Context, userID uuid.UUID, accessLevelIDs []int) error
 	GetUserAccessLevels(ctx context.Context, userID uuid.UUID) ([]*models.AccessLevel, error)
+	GetUsersAccessLevels(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]*models.AccessLevel, error)
+	GetEffectiveUserAccessLevels(ctx context.Context, userID uuid.UUID) ([]*models.AccessLevel, error)
+	GetParentLinks(ctx context.Context) ([]*models.AccessLevelParent, error)
+	LockParentLinks(ctx context.Context) error
+	SetParents(ctx context.Context, accessLevelID int, parentIDs []int) error
 }
 
 type PostgresUserRepository struct {
@@ -69,7 +97,7 @@ func (r *PostgresUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*mo
 	user := &models.User{}
 	err := r.db.WithContext(ctx).Where("id = ?", id).First(user).Error
 	if err == gorm.ErrRecordNotFound {
-		return nil, fmt.Errorf("user not found")
+		return nil, apperrors.NotFound("user_not_found", "user not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -82,7 +110,7 @@ func (r *PostgresUserRepository) GetByIDWithAccessLevels(ctx context.Context, id
 	user := &models.User{}
 	err := r.db.WithContext(ctx).Where("id = ?", id).First(user).Error
 	if err == gorm.ErrRecordNotFound {
-		return nil, nil, fmt.Errorf("user not found")
+		return nil, nil, apperrors.NotFound("user_not_found", "user not found")
 	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get user: %w", err)
@@ -106,7 +134,7 @@ func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (
 	user := &models.User{}
 	err := r.db.WithContext(ctx).Where("email = ?", email).First(user).Error
 	if err == gorm.ErrRecordNotFound {
-		return nil, fmt.Errorf("user not found")
+		return nil, apperrors.NotFound("user_not_found", "user not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -114,20 +142,23 @@ func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (
 	return user, nil
 }
 
+// Update saves the user's profile fields. Changing the email clears its
+// verification, since the new address has not been confirmed.
 func (r *PostgresUserRepository) Update(ctx context.Context, user *models.User) error {
 	user.UpdatedAt = time.Now()
 	result := r.db.WithContext(ctx).Model(user).Updates(map[string]interface{}{
-		"first_name":   user.FirstName,
-		"last_name":    user.LastName,
-		"email":        user.Email,
-		"phone_number": user.PhoneNumber,
-		"updated_at":   user.UpdatedAt,
+		"first_name":        user.FirstName,
+		"last_name":         user.LastName,
+		"email":             user.Email,
+		"phone_number":      user.PhoneNumber,
+		"email_verified_at": gorm.Expr("CASE WHEN email = ? THEN email_verified_at END", user.Email),
+		"updated_at":        user.UpdatedAt,
 	})
 	if result.Error != nil {
 		return fmt.Errorf("failed to update user: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return apperrors.NotFound("user_not_found", "user not found")
 	}
 	return nil
 }
@@ -138,40 +169,245 @@ func (r *PostgresUserRepository) Delete(ctx context.Context, id uuid.UUID) error
 		return fmt.Errorf("failed to delete user: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return apperrors.NotFound("user_not_found", "user not found")
 	}
 	return nil
 }
 
-func (r *PostgresUserRepository) List(ctx context.Context, limit, offset int) ([]*models.User, int, error) {
-	// Get total count
-	var total int64
-	if err := r.db.WithContext(ctx).Model(&models.User{}).Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+// Restore undoes the soft delete of a user. It fails with email_taken when
+// another user has registered the user's email since the delete.
+func (r *PostgresUserRepository) Restore(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user := &models.User{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(user).Error
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.NotFound("deleted_user_not_found", "deleted user not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		var taken int64
+		if err := tx.Model(&models.User{}).Where("email = ?", user.Email).Count(&taken).Error; err != nil {
+			return fmt.Errorf("failed to check email: %w", err)
+		}
+		if taken > 0 {
+			return apperrors.Conflict("email_taken", "email %s is already taken", user.Email)
+		}
+
+		if err := tx.Unscoped().Model(user).Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore user: %w", err)
+		}
+		user.DeletedAt = gorm.DeletedAt{}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Purge permanently removes a user, whether soft-deleted or not, together
+// with their authentication and access level rows. The remaining rows that
+// reference the user are removed by the database's cascading foreign keys,
+// except audit events, which are kept.
+func (r *PostgresUserRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&models.UserAccessLevel{}).Error; err != nil {
+			return fmt.Errorf("failed to delete user access levels: %w", err)
+		}
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&models.UserAuthentication{}).Error; err != nil {
+			return fmt.Errorf("failed to delete authentication: %w", err)
+		}
+		result := tx.Unscoped().Delete(&models.User{}, "id = ?", id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to purge user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("user_not_found", "user not found")
+		}
+		return nil
+	})
+}
+
+// List returns a page of the users matching filter
+func (r *PostgresUserRepository) List(ctx context.Context, filter UserFilter, limit, offset int) ([]*models.User, error) {
+	orderBy, err := filter.orderBy()
+	if err != nil {
+		return nil, err
 	}
 
-	// Get users
 	var users []*models.User
-	err := r.db.WithContext(ctx).
-		Order("created_at DESC").
+	err = filter.apply(r.db.WithContext(ctx)).
+		Order(clause.OrderBy{Columns: orderBy}).
 		Limit(limit).
 		Offset(offset).
 		Find(&users).Error
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
+	return users, nil
+}
 
-	return users, int(total), nil
+// ListAfter returns up to limit users matching filter that come after the
+// cursor in creation order, newest first unless ascending is set. A nil cursor
+// starts at the first user. The filter's sort is ignored.
+func (r *PostgresUserRepository) ListAfter(ctx context.Context, filter UserFilter, after *UserCursor, ascending bool, limit int) ([]*models.User, error) {
+	db := filter.apply(r.db.WithContext(ctx))
+	if after != nil {
+		db = after.apply(db, ascending)
+	}
+
+	var users []*models.User
+	err := db.Order(clause.OrderBy{Columns: keysetOrder(ascending)}).
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return users, nil
+}
+
+// Count returns the number of users matching filter
+func (r *PostgresUserRepository) Count(ctx context.Context, filter UserFilter) (int, error) {
+	var total int64
+	if err := filter.apply(r.db.WithContext(ctx).Model(&models.User{})).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return int(total), nil
 }
 
 func (r *PostgresUserRepository) GetUserAuthentication(ctx context.Context, userID uuid.UUID) (*models.UserAuthentication, error) {
 	auth := &models.UserAuthentication{}
 	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(auth).Error
 	if err == gorm.ErrRecordNotFound {
-		return nil, fmt.Errorf("authentication not found")
+		return nil, apperrors.NotFound("authentication_not_found", "authentication not found")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get authentication: %w", err)
 	}
 	return auth, nil
 }
+
+// UpdatePassword replaces the password hash stored for the user, records when
+// it was changed and keeps the replaced hash in the password history
+func (r *PostgresUserRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current := &models.UserAuthentication{}
+		err := tx.Where("user_id = ?", userID).First(current).Error
+		if err == gorm.ErrRecordNotFound {
+			return apperrors.NotFound("authentication_not_found", "authentication not found")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get authentication: %w", err)
+		}
+
+		now := time.Now()
+		previous := &models.PasswordHistory{
+			ID:           uuid.New(),
+			UserID:       userID,
+			PasswordHash: current.PasswordHash,
+			CreatedAt:    now,
+		}
+		if err := tx.Create(previous).Error; err != nil {
+			return fmt.Errorf("failed to record password history: %w", err)
+		}
+
+		err = tx.Model(current).Updates(map[string]interface{}{
+			"password_hash":       passwordHash,
+			"password_changed_at": now,
+			"updated_at":          now,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetPasswordHistory returns up to limit of the user's replaced password
+// hashes, most recent first
+func (r *PostgresUserRepository) GetPasswordHistory(ctx context.Context, userID uuid.UUID, limit int) ([]*models.PasswordHistory, error) {
+	var history []*models.PasswordHistory
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&history).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password history: %w", err)
+	}
+	return history, nil
+}
+
+// RecordFailedLogin counts a failed login against the user and returns the
+// number of failures since the last successful login. The count is
+// incremented in the database, so concurrent failures are all counted.
+func (r *PostgresUserRepository) RecordFailedLogin(ctx context.Context, userID uuid.UUID, at time.Time) (int, error) {
+	var failures int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.UserAuthentication{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{
+				"failed_login_count":   gorm.Expr("failed_login_count + 1"),
+				"last_failed_login_at": at,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to record failed login: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("authentication_not_found", "authentication not found")
+		}
+
+		err := tx.Model(&models.UserAuthentication{}).
+			Where("user_id = ?", userID).
+			Pluck("failed_login_count", &failures).Error
+		if err != nil {
+			return fmt.Errorf("failed to get failed login count: %w", err)
+		}
+		return nil
+	})
+	return failures, err
+}
+
+// LockUntil refuses logins to the user until the given time
+func (r *PostgresUserRepository) LockUntil(ctx context.Context, userID uuid.UUID, until time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.UserAuthentication{}).
+		Where("user_id = ?", userID).
+		Update("locked_until", until).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+	return nil
+}
+
+// ResetFailedLogins clears the user's failed login count and any lock
+func (r *PostgresUserRepository) ResetFailedLogins(ctx context.Context, userID uuid.UUID) error {
+	err := r.db.WithContext(ctx).Model(&models.UserAuthentication{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"failed_login_count":   0,
+			"last_failed_login_at": nil,
+			"locked_until":         nil,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to reset failed logins: %w", err)
+	}
+	return nil
+}
+
+// MarkEmailVerified records that the user confirmed email. Nothing changes
+// if the user's address is no longer email, which is reported as
+// ErrEmailChanged.
+func (r *PostgresUserRepository) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND email = ?", userID, email).
+		Update("email_verified_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark email verified: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrEmailChanged
+	}
+	return nil
+}
@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
 	"github.com/wabtcdi/user_service/models"
 	"gorm.io/gorm"
 )
@@ -27,6 +29,17 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&models.UserAuthentication{},
 		&models.AccessLevel{},
 		&models.UserAccessLevel{},
+		&models.AccessLevelParent{},
+		&models.RefreshToken{},
+		&models.Permission{},
+		&models.AccessLevelPermission{},
+		&models.PasswordResetToken{},
+		&models.PasswordHistory{},
+		&models.AuditEvent{},
+		&models.MFARecoveryCode{},
+		&models.MFAChallenge{},
+		&models.EmailVerificationToken{},
+		&models.Session{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
@@ -127,6 +140,9 @@ func TestUserRepository_GetByID_NotFound(t *testing.T) {
 	if err != nil && err.Error() != "user not found" {
 		t.Errorf("Expected 'user not found' error, got: %v", err)
 	}
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected error to match apperrors.ErrNotFound, got: %v", err)
+	}
 }
 
 func TestUserRepository_GetByEmail(t *testing.T) {
@@ -241,6 +257,132 @@ func TestUserRepository_Delete(t *testing.T) {
 	}
 }
 
+func TestUserRepository_Restore(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{FirstName: "Charlie", LastName: "Davis", Email: "charlie.davis@example.com"}
+	if err := repo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	duplicate := &models.User{FirstName: "Other", LastName: "Davis", Email: user.Email}
+	if err := repo.Create(ctx, duplicate, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err == nil {
+		t.Fatal("Expected the email of an active user to stay unique, got nil")
+	}
+
+	t.Run("Not Deleted", func(t *testing.T) {
+		_, err := repo.Restore(ctx, user.ID)
+		if !errors.Is(err, apperrors.ErrNotFound) || apperrors.Code(err) != "deleted_user_not_found" {
+			t.Errorf("Expected deleted_user_not_found, got %v", err)
+		}
+	})
+
+	if err := repo.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	t.Run("Email Taken", func(t *testing.T) {
+		// The email can be registered again once its user is deleted
+		other := &models.User{FirstName: "Other", LastName: "Davis", Email: user.Email}
+		if err := repo.Create(ctx, other, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+			t.Fatalf("Expected a deleted user's email to be reusable, got %v", err)
+		}
+
+		_, err := repo.Restore(ctx, user.ID)
+		if !errors.Is(err, apperrors.ErrConflict) || apperrors.Code(err) != "email_taken" {
+			t.Errorf("Expected email_taken, got %v", err)
+		}
+
+		if err := repo.Delete(ctx, other.ID); err != nil {
+			t.Fatalf("Failed to delete user: %v", err)
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		restored, err := repo.Restore(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Failed to restore user: %v", err)
+		}
+		if restored.DeletedAt.Valid || restored.Email != user.Email {
+			t.Errorf("Expected restored user %s, got %+v", user.Email, restored)
+		}
+		if _, err := repo.GetByID(ctx, user.ID); err != nil {
+			t.Errorf("Expected restored user to be found, got %v", err)
+		}
+	})
+
+	t.Run("Unknown User", func(t *testing.T) {
+		if _, err := repo.Restore(ctx, uuid.New()); apperrors.Code(err) != "deleted_user_not_found" {
+			t.Errorf("Expected deleted_user_not_found, got %v", err)
+		}
+	})
+}
+
+func TestUserRepository_Purge(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresUserRepository(db)
+	accessLevelRepo := NewPostgresAccessLevelRepository(db)
+	ctx := context.Background()
+
+	admin := &models.AccessLevel{Name: "admin"}
+	if err := accessLevelRepo.Create(ctx, admin); err != nil {
+		t.Fatalf("Failed to create access level: %v", err)
+	}
+
+	for _, softDeleted := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Soft Deleted %t", softDeleted), func(t *testing.T) {
+			user := &models.User{FirstName: "Charlie", LastName: "Davis", Email: "charlie.davis@example.com"}
+			if err := repo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+				t.Fatalf("Failed to create test user: %v", err)
+			}
+			if err := accessLevelRepo.AssignToUser(ctx, user.ID, admin.ID); err != nil {
+				t.Fatalf("Failed to assign access level: %v", err)
+			}
+			if softDeleted {
+				if err := repo.Delete(ctx, user.ID); err != nil {
+					t.Fatalf("Failed to delete user: %v", err)
+				}
+			}
+			auditRepo := NewPostgresAuditEventRepository(db)
+			if err := auditRepo.Create(ctx, &models.AuditEvent{UserID: user.ID, Event: models.AuditEventAccountLocked}); err != nil {
+				t.Fatalf("Failed to create audit event: %v", err)
+			}
+
+			if err := repo.Purge(ctx, user.ID); err != nil {
+				t.Fatalf("Failed to purge user: %v", err)
+			}
+
+			var events int64
+			db.Model(&models.AuditEvent{}).Where("user_id = ?", user.ID).Count(&events)
+			if events != 1 {
+				t.Errorf("Expected the audit event to outlive the purge, found %d", events)
+			}
+
+			for _, model := range []any{&models.User{}, &models.UserAuthentication{}, &models.UserAccessLevel{}} {
+				column := "user_id"
+				if _, ok := model.(*models.User); ok {
+					column = "id"
+				}
+				var count int64
+				if err := db.Unscoped().Model(model).Where(column+" = ?", user.ID).Count(&count).Error; err != nil {
+					t.Fatalf("Failed to count rows: %v", err)
+				}
+				if count != 0 {
+					t.Errorf("Expected no %T rows left, found %d", model, count)
+				}
+			}
+		})
+	}
+
+	t.Run("Unknown User", func(t *testing.T) {
+		if err := repo.Purge(ctx, uuid.New()); apperrors.Code(err) != "user_not_found" {
+			t.Errorf("Expected user_not_found, got %v", err)
+		}
+	})
+}
+
 func TestUserRepository_List(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewPostgresUserRepository(db)
@@ -265,32 +407,256 @@ func TestUserRepository_List(t *testing.T) {
 	}
 
 	// Test List with pagination
-	users, total, err := repo.List(ctx, 3, 0)
+	users, err := repo.List(ctx, UserFilter{}, 3, 0)
 	if err != nil {
 		t.Fatalf("Failed to list users: %v", err)
 	}
 
-	if total != 5 {
-		t.Errorf("Total count mismatch: got %d, want 5", total)
-	}
 	if len(users) != 3 {
 		t.Errorf("Users count mismatch: got %d, want 3", len(users))
 	}
 
 	// Test pagination offset
-	users, total, err = repo.List(ctx, 3, 3)
+	users, err = repo.List(ctx, UserFilter{}, 3, 3)
 	if err != nil {
 		t.Fatalf("Failed to list users with offset: %v", err)
 	}
 
-	if total != 5 {
-		t.Errorf("Total count mismatch with offset: got %d, want 5", total)
-	}
 	if len(users) != 2 {
 		t.Errorf("Users count mismatch with offset: got %d, want 2", len(users))
 	}
 }
 
+func TestUserRepository_List_Filter(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresUserRepository(db)
+	accessLevelRepo := NewPostgresAccessLevelRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	phone := "+15550100"
+	seed := []struct {
+		first, last, email string
+		phone              *string
+		createdAt          time.Time
+	}{
+		{"Alice", "Zimmer", "alice@example.com", &phone, base},
+		{"Bob", "Young", "bob@example.org", nil, base.Add(24 * time.Hour)},
+		{"Carol", "Alderman", "carol_a@example.com", nil, base.Add(48 * time.Hour)},
+		{"Dave", "Young", "CAROLINE@example.com", nil, base.Add(72 * time.Hour)},
+	}
+	users := make(map[string]*models.User)
+	for _, u := range seed {
+		user := &models.User{FirstName: u.first, LastName: u.last, Email: u.email, PhoneNumber: u.phone}
+		if err := repo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		// Create stamps the current time
+		if err := db.Model(user).UpdateColumn("created_at", u.createdAt).Error; err != nil {
+			t.Fatalf("Failed to set created_at: %v", err)
+		}
+		users[u.first] = user
+	}
+
+	admin := &models.AccessLevel{Name: "admin"}
+	if err := accessLevelRepo.Create(ctx, admin); err != nil {
+		t.Fatalf("Failed to create access level: %v", err)
+	}
+	for _, name := range []string{"Alice", "Dave"} {
+		if err := accessLevelRepo.AssignToUser(ctx, users[name].ID, admin.ID); err != nil {
+			t.Fatalf("Failed to assign access level: %v", err)
+		}
+	}
+	if err := repo.Delete(ctx, users["Dave"].ID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	at := func(d time.Duration) *time.Time {
+		t := base.Add(d)
+		return &t
+	}
+	tests := []struct {
+		name   string
+		filter UserFilter
+		want   []string
+	}{
+		{"Default Order", UserFilter{}, []string{"Carol", "Bob", "Alice"}},
+		{"Email Prefix", UserFilter{EmailPrefix: "CAROL"}, []string{"Carol"}},
+		{"Email Prefix Escapes Wildcards", UserFilter{EmailPrefix: "carol_"}, []string{"Carol"}},
+		{"Name Prefix Matches Last Name", UserFilter{NamePrefix: "you"}, []string{"Bob"}},
+		{"Name Prefix Matches First Name", UserFilter{NamePrefix: "al", Sort: []UserSort{{Field: "first_name"}}}, []string{"Alice", "Carol"}},
+		{"Phone", UserFilter{PhoneNumber: phone}, []string{"Alice"}},
+		{"Access Level", UserFilter{AccessLevel: "admin"}, []string{"Alice"}},
+		{"Created Range", UserFilter{CreatedAfter: at(time.Hour), CreatedBefore: at(48 * time.Hour)}, []string{"Bob"}},
+		{"Include Deleted", UserFilter{IncludeDeleted: true, AccessLevel: "admin"}, []string{"Dave", "Alice"}},
+		{"Only Deleted", UserFilter{OnlyDeleted: true}, []string{"Dave"}},
+		{"Sort", UserFilter{IncludeDeleted: true, Sort: []UserSort{{Field: "last_name"}, {Field: "created_at", Desc: true}}},
+			[]string{"Carol", "Dave", "Bob", "Alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed, err := repo.List(ctx, tt.filter, 10, 0)
+			if err != nil {
+				t.Fatalf("Failed to list users: %v", err)
+			}
+			total, err := repo.Count(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Failed to count users: %v", err)
+			}
+			var got []string
+			for _, user := range listed {
+				got = append(got, user.FirstName)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) || total != len(tt.want) {
+				t.Errorf("Expected %v (total %d), got %v (total %d)", tt.want, len(tt.want), got, total)
+			}
+		})
+	}
+
+	t.Run("Unknown Sort Field", func(t *testing.T) {
+		_, err := repo.List(ctx, UserFilter{Sort: []UserSort{{Field: "password_hash"}}}, 10, 0)
+		if !errors.Is(err, apperrors.ErrValidation) || apperrors.Code(err) != "invalid_sort" {
+			t.Errorf("Expected invalid_sort, got %v", err)
+		}
+	})
+}
+
+func TestUserRepository_ListAfter(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	// Three users share a creation time so the ID has to break the tie
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := []time.Time{base, base.Add(time.Hour), base.Add(time.Hour), base.Add(time.Hour), base.Add(2 * time.Hour)}
+	for i, at := range createdAt {
+		user := &models.User{FirstName: "User", LastName: "Test", Email: fmt.Sprintf("user%d@example.com", i)}
+		if err := repo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		if err := db.Model(user).UpdateColumn("created_at", at).Error; err != nil {
+			t.Fatalf("Failed to set created_at: %v", err)
+		}
+	}
+
+	for _, ascending := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Ascending %t", ascending), func(t *testing.T) {
+			all, err := repo.ListAfter(ctx, UserFilter{}, nil, ascending, 10)
+			if err != nil {
+				t.Fatalf("Failed to list users: %v", err)
+			}
+			if len(all) != len(createdAt) {
+				t.Fatalf("Expected %d users, got %d", len(createdAt), len(all))
+			}
+
+			// Walking two at a time visits every user exactly once, in order
+			var walked []*models.User
+			var after *UserCursor
+			for {
+				page, err := repo.ListAfter(ctx, UserFilter{}, after, ascending, 2)
+				if err != nil {
+					t.Fatalf("Failed to list users: %v", err)
+				}
+				if len(page) == 0 {
+					break
+				}
+				walked = append(walked, page...)
+				last := page[len(page)-1]
+				after = &UserCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+			}
+			if len(walked) != len(all) {
+				t.Fatalf("Expected to walk %d users, got %d", len(all), len(walked))
+			}
+			for i := range all {
+				if walked[i].ID != all[i].ID {
+					t.Errorf("User %d: expected %s, got %s", i, all[i].ID, walked[i].ID)
+				}
+				if i == 0 {
+					continue
+				}
+				earlier, later := all[i-1], all[i]
+				if !ascending {
+					earlier, later = later, earlier
+				}
+				if later.CreatedAt.Before(earlier.CreatedAt) {
+					t.Errorf("User %d is out of order", i)
+				}
+			}
+		})
+	}
+
+	t.Run("Filter", func(t *testing.T) {
+		after := base.Add(time.Hour)
+		users, err := repo.ListAfter(ctx, UserFilter{CreatedAfter: &after}, nil, false, 10)
+		if err != nil {
+			t.Fatalf("Failed to list users: %v", err)
+		}
+		if len(users) != 4 {
+			t.Errorf("Expected 4 users, got %d", len(users))
+		}
+	})
+}
+
+func TestUserRepository_Search(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	phone := "+15550100"
+	seed := []struct {
+		first, last, email string
+		phone              *string
+	}{
+		{"John", "Smith", "john.smith@example.com", &phone},
+		{"Johanna", "Blacksmith", "jo@example.org", nil},
+		{"Alice", "Jones", "alice_j@example.com", nil},
+		{"Deleted", "Smith", "deleted@example.com", nil},
+	}
+	for _, u := range seed {
+		user := &models.User{FirstName: u.first, LastName: u.last, Email: u.email, PhoneNumber: u.phone}
+		if err := repo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+			t.Fatalf("Failed to create test user: %v", err)
+		}
+		if u.first == "Deleted" {
+			if err := repo.Delete(ctx, user.ID); err != nil {
+				t.Fatalf("Failed to delete user: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{"Prefix Matches First", "smith", 10, []string{"John", "Johanna"}},
+		{"Partial Name", "OHA", 10, []string{"Johanna"}},
+		{"Every Word Must Match", "john smi", 10, []string{"John"}},
+		{"Exact Email First", "jo@example.org", 10, []string{"Johanna"}},
+		{"Email Domain", "example.com", 10, []string{"Alice", "John"}},
+		{"Partial Phone", "0100", 10, []string{"John"}},
+		{"Wildcards Are Literal", "e_j", 10, []string{"Alice"}},
+		{"Limit", "jo", 1, []string{"Johanna"}},
+		{"No Match", "zzz", 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := repo.Search(ctx, tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("Failed to search users: %v", err)
+			}
+			var got []string
+			for _, user := range users {
+				got = append(got, user.FirstName)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestUserRepository_GetUserAuthentication(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewPostgresUserRepository(db)
@@ -324,6 +690,172 @@ func TestUserRepository_GetUserAuthentication(t *testing.T) {
 	}
 }
 
+func TestUserRepository_UpdatePassword(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{
+		FirstName: "Paula",
+		LastName:  "Word",
+		Email:     "paula.word@example.com",
+	}
+	if err := repo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "oldhash"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	if err := repo.UpdatePassword(ctx, user.ID, "newhash"); err != nil {
+		t.Fatalf("Failed to update password: %v", err)
+	}
+	retrievedAuth, err := repo.GetUserAuthentication(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get user authentication: %v", err)
+	}
+	if retrievedAuth.PasswordHash != "newhash" {
+		t.Errorf("PasswordHash mismatch: got %v, want newhash", retrievedAuth.PasswordHash)
+	}
+	if retrievedAuth.PasswordChangedAt == nil {
+		t.Error("Expected PasswordChangedAt to be recorded")
+	}
+
+	if err := repo.UpdatePassword(ctx, user.ID, "newesthash"); err != nil {
+		t.Fatalf("Failed to update password: %v", err)
+	}
+	history, err := repo.GetPasswordHistory(ctx, user.ID, 5)
+	if err != nil {
+		t.Fatalf("Failed to get password history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(history))
+	}
+	// Most recent first; ties on created_at are possible on fast machines
+	hashes := map[string]bool{history[0].PasswordHash: true, history[1].PasswordHash: true}
+	if !hashes["oldhash"] || !hashes["newhash"] {
+		t.Errorf("Expected replaced hashes in history, got %v", hashes)
+	}
+
+	limited, err := repo.GetPasswordHistory(ctx, user.ID, 1)
+	if err != nil {
+		t.Fatalf("Failed to get password history: %v", err)
+	}
+	if len(limited) != 1 {
+		t.Errorf("Expected history to be limited to 1 entry, got %d", len(limited))
+	}
+
+	if err := repo.UpdatePassword(ctx, uuid.New(), "newhash"); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected not found for unknown user, got %v", err)
+	}
+}
+
+func TestUserRepository_FailedLogins(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{
+		FirstName: "Lock",
+		LastName:  "Out",
+		Email:     "lock.out@example.com",
+	}
+	if err := repo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hash"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	for want := 1; want <= 3; want++ {
+		failures, err := repo.RecordFailedLogin(ctx, user.ID, time.Now())
+		if err != nil {
+			t.Fatalf("Failed to record failed login: %v", err)
+		}
+		if failures != want {
+			t.Errorf("Expected %d failures, got %d", want, failures)
+		}
+	}
+
+	until := time.Now().Add(15 * time.Minute)
+	if err := repo.LockUntil(ctx, user.ID, until); err != nil {
+		t.Fatalf("Failed to lock user: %v", err)
+	}
+	locked, err := repo.GetUserAuthentication(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get user authentication: %v", err)
+	}
+	if locked.FailedLoginCount != 3 || locked.LastFailedLoginAt == nil {
+		t.Errorf("Expected 3 recorded failures, got %d at %v", locked.FailedLoginCount, locked.LastFailedLoginAt)
+	}
+	if locked.LockedUntil == nil || !locked.LockedUntil.Equal(until) {
+		t.Errorf("Expected lock until %v, got %v", until, locked.LockedUntil)
+	}
+
+	if err := repo.ResetFailedLogins(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to reset failed logins: %v", err)
+	}
+	reset, err := repo.GetUserAuthentication(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get user authentication: %v", err)
+	}
+	if reset.FailedLoginCount != 0 || reset.LastFailedLoginAt != nil || reset.LockedUntil != nil {
+		t.Errorf("Expected failures and lock to be cleared, got %+v", reset)
+	}
+
+	if _, err := repo.RecordFailedLogin(ctx, uuid.New(), time.Now()); !errors.Is(err, apperrors.ErrNotFound) {
+		t.Errorf("Expected not found for unknown user, got %v", err)
+	}
+}
+
+func TestUserRepository_EmailVerification(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewPostgresUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{
+		FirstName: "Vera",
+		LastName:  "Fied",
+		Email:     "vera.fied@example.com",
+	}
+	if err := repo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hash"}); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	verified := func() bool {
+		t.Helper()
+		retrieved, err := repo.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Failed to get user: %v", err)
+		}
+		return retrieved.EmailVerifiedAt != nil
+	}
+
+	if verified() {
+		t.Fatal("New user should not be verified")
+	}
+	if err := repo.MarkEmailVerified(ctx, user.ID, "someone.else@example.com"); !errors.Is(err, ErrEmailChanged) {
+		t.Errorf("Expected ErrEmailChanged for another address, got %v", err)
+	}
+	if err := repo.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+		t.Fatalf("Failed to mark email verified: %v", err)
+	}
+	if !verified() {
+		t.Fatal("Expected email to be verified")
+	}
+
+	// Profile changes keep the verification...
+	user.FirstName = "Veronica"
+	if err := repo.Update(ctx, user); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	if !verified() {
+		t.Error("Expected verification to survive a name change")
+	}
+
+	// ...but a new address has to be verified again
+	user.Email = "veronica.fied@example.com"
+	if err := repo.Update(ctx, user); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	if verified() {
+		t.Error("Expected verification to be cleared by an email change")
+	}
+}
+
 func TestUserRepository_GetByIDWithAccessLevels(t *testing.T) {
 	db := setupTestDB(t)
 	userRepo := NewPostgresUserRepository(db)
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/wabtcdi/user_service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchSimilarityThreshold is the pg_trgm word similarity a name, email or
+// phone number needs to match a search. The extension's default of 0.6 misses
+// most typos in short names.
+const searchSimilarityThreshold = 0.3
+
+// The expressions below match the indexes created by the user search
+// migration; changing them stops those indexes from being used
+const (
+	searchNameExpr     = `lower(users.first_name || ' ' || users.last_name)`
+	searchEmailExpr    = `lower(users.email)`
+	searchDocumentExpr = `to_tsvector('simple', users.first_name || ' ' || users.last_name || ' ' || users.email)`
+)
+
+// Search returns up to limit users whose name, email or phone number
+// resembles query, most relevant first. On Postgres matches are fuzzy, using
+// trigram similarity and full-text search; other databases fall back to
+// substring matching of every word in query.
+func (r *PostgresUserRepository) Search(ctx context.Context, query string, limit int) ([]*models.User, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	var users []*models.User
+	var err error
+	if r.db.Dialector.Name() == "postgres" {
+		err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// SET LOCAL keeps the threshold from leaking to other queries on the connection
+			if err := tx.Exec(fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %g", searchSimilarityThreshold)).Error; err != nil {
+				return err
+			}
+			return searchTrigram(tx, query).Limit(limit).Find(&users).Error
+		})
+	} else {
+		err = searchLike(r.db.WithContext(ctx), query).Limit(limit).Find(&users).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	return users, nil
+}
+
+// searchTrigram matches users whose name, email or phone number contains a
+// word similar to query, or whose name and email contain its words, ranked by
+// the closest similarity plus the full-text rank
+func searchTrigram(db *gorm.DB, query string) *gorm.DB {
+	return db.
+		Where(`? <% `+searchNameExpr+` OR ? <% `+searchEmailExpr+` OR ? <% users.phone_number OR `+
+			searchDocumentExpr+` @@ plainto_tsquery('simple', ?)`, query, query, query, query).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL: `GREATEST(word_similarity(?, ` + searchNameExpr + `), word_similarity(?, ` + searchEmailExpr + `), word_similarity(?, users.phone_number)) + ` +
+				`ts_rank(` + searchDocumentExpr + `, plainto_tsquery('simple', ?)) DESC, users.id`,
+			Vars:               []any{query, query, query, query},
+			WithoutParentheses: true,
+		}})
+}
+
+// searchLike matches users whose name, email or phone number contains every
+// word in query, ranking an exact email first and then prefix matches
+func searchLike(db *gorm.DB, query string) *gorm.DB {
+	for _, word := range strings.Fields(query) {
+		pattern := "%" + likeEscaper.Replace(word) + "%"
+		db = db.Where(`(`+searchNameExpr+` LIKE ? ESCAPE '\' OR `+searchEmailExpr+` LIKE ? ESCAPE '\' OR users.phone_number LIKE ? ESCAPE '\')`,
+			pattern, pattern, pattern)
+	}
+	prefix := likePrefix(query)
+	return db.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL: `CASE WHEN ` + searchEmailExpr + ` = ? THEN 0
+			WHEN ` + searchNameExpr + ` LIKE ? ESCAPE '\' OR lower(users.last_name) LIKE ? ESCAPE '\' OR ` + searchEmailExpr + ` LIKE ? ESCAPE '\' THEN 1
+			ELSE 2 END, users.last_name, users.first_name, users.id`,
+		Vars:               []any{query, prefix, prefix, prefix},
+		WithoutParentheses: true,
+	}})
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wabtcdi/user_service/models"
+)
+
+// accessLevelGraph maps each access level ID to the IDs of its parents
+type accessLevelGraph map[int][]int
+
+func newAccessLevelGraph(links []*models.AccessLevelParent) accessLevelGraph {
+	graph := make(accessLevelGraph)
+	for _, link := range links {
+		graph[link.AccessLevelID] = append(graph[link.AccessLevelID], link.ParentID)
+	}
+	return graph
+}
+
+// findCycle reports the cycle that giving accessLevelID the parents parentIDs
+// would introduce, as the chain of IDs from accessLevelID back to itself, or
+// nil when the hierarchy stays acyclic
+func (g accessLevelGraph) findCycle(accessLevelID int, parentIDs []int) []int {
+	visited := make(map[int]bool)
+	var walk func(id int) []int
+	walk = func(id int) []int {
+		if id == accessLevelID {
+			return []int{id}
+		}
+		if visited[id] {
+			return nil
+		}
+		visited[id] = true
+		for _, parentID := range g[id] {
+			if path := walk(parentID); path != nil {
+				return append([]int{id}, path...)
+			}
+		}
+		return nil
+	}
+
+	for _, parentID := range parentIDs {
+		if path := walk(parentID); path != nil {
+			return append([]int{accessLevelID}, path...)
+		}
+	}
+	return nil
+}
+
+// uniqueIDs returns ids sorted with duplicates removed
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	sort.Ints(unique)
+	return unique
+}
+
+func formatIDPath(ids []int) string {
+	return joinIDs(ids, " -> ")
+}
+
+func formatIDList(ids []int) string {
+	return joinIDs(ids, ", ")
+}
+
+func joinIDs(ids []int, sep string) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, sep)
+}
@@ -2,26 +2,68 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/wabtcdi/user_service/apperrors"
 	"github.com/wabtcdi/user_service/dto"
 	"github.com/wabtcdi/user_service/models"
 	"github.com/wabtcdi/user_service/repository"
 )
 
 type AccessLevelService struct {
-	repo repository.AccessLevelRepository
+	repo           repository.AccessLevelRepository
+	permissionRepo repository.PermissionRepository
+	unitOfWork     repository.UnitOfWork
+	protected      map[string]bool
 }
 
-func NewAccessLevelService(repo repository.AccessLevelRepository) *AccessLevelService {
-	return &AccessLevelService{repo: repo}
+// AccessLevelServiceOption configures optional AccessLevelService dependencies
+type AccessLevelServiceOption func(*AccessLevelService)
+
+// WithAccessLevelUnitOfWork runs multi-step operations in a transaction.
+// Without it they run directly against the service's repositories.
+func WithAccessLevelUnitOfWork(uow repository.UnitOfWork) AccessLevelServiceOption {
+	return func(s *AccessLevelService) {
+		s.unitOfWork = uow
+	}
+}
+
+// WithProtectedAccessLevels names the access levels that route authorization
+// rules refer to. They cannot be renamed or deleted, and no other level may
+// take their names, so managing access levels cannot confer their rights.
+func WithProtectedAccessLevels(names ...string) AccessLevelServiceOption {
+	return func(s *AccessLevelService) {
+		s.protected = make(map[string]bool, len(names))
+		for _, name := range names {
+			s.protected[name] = true
+		}
+	}
+}
+
+func NewAccessLevelService(repo repository.AccessLevelRepository, permissionRepo repository.PermissionRepository, opts ...AccessLevelServiceOption) *AccessLevelService {
+	s := &AccessLevelService{repo: repo, permissionRepo: permissionRepo}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.unitOfWork == nil {
+		s.unitOfWork = directUnitOfWork{repository.Repositories{AccessLevels: repo, Permissions: permissionRepo}}
+	}
+	return s
 }
 
 func (s *AccessLevelService) CreateAccessLevel(ctx context.Context, req *dto.CreateAccessLevelRequest) (*dto.AccessLevelResponse, error) {
 	// Check if access level already exists
 	existing, _ := s.repo.GetByName(ctx, req.Name)
 	if existing != nil {
-		return nil, fmt.Errorf("access level with name %s already exists", req.Name)
+		return nil, apperrors.Conflict("access_level_name_taken", "access level with name %s already exists", req.Name)
+	}
+
+	// A new access level has no children yet, so its parents cannot form a cycle
+	parentIDs := uniqueIDs(req.ParentIDs)
+	if err := checkParentsExist(ctx, s.repo, parentIDs); err != nil {
+		return nil, err
 	}
 
 	accessLevel := &models.AccessLevel{
@@ -31,21 +73,23 @@ func (s *AccessLevelService) CreateAccessLevel(ctx context.Context, req *dto.Cre
 		accessLevel.Description = &req.Description
 	}
 
-	err := s.repo.Create(ctx, accessLevel)
+	// Create the level and its parents together, so a failure does not leave
+	// a level behind whose name blocks a retry
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.AccessLevels.Create(ctx, accessLevel); err != nil {
+			return fmt.Errorf("failed to create access level: %w", err)
+		}
+		if len(parentIDs) == 0 {
+			return nil
+		}
+		return repos.AccessLevels.SetParents(ctx, accessLevel.ID, parentIDs)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create access level: %w", err)
-	}
-
-	desc := ""
-	if accessLevel.Description != nil {
-		desc = *accessLevel.Description
+		return nil, err
 	}
 
-	return &dto.AccessLevelResponse{
-		ID:          accessLevel.ID,
-		Name:        accessLevel.Name,
-		Description: desc,
-	}, nil
+	response := toAccessLevelResponse(accessLevel, parentIDs)
+	return &response, nil
 }
 
 func (s *AccessLevelService) GetAccessLevel(ctx context.Context, id int) (*dto.AccessLevelResponse, error) {
@@ -54,16 +98,13 @@ func (s *AccessLevelService) GetAccessLevel(ctx context.Context, id int) (*dto.A
 		return nil, err
 	}
 
-	desc := ""
-	if accessLevel.Description != nil {
-		desc = *accessLevel.Description
+	links, err := s.repo.GetParentLinks(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return &dto.AccessLevelResponse{
-		ID:          accessLevel.ID,
-		Name:        accessLevel.Name,
-		Description: desc,
-	}, nil
+	response := toAccessLevelResponse(accessLevel, newAccessLevelGraph(links)[id])
+	return &response, nil
 }
 
 func (s *AccessLevelService) ListAccessLevels(ctx context.Context) ([]dto.AccessLevelResponse, error) {
@@ -72,18 +113,234 @@ func (s *AccessLevelService) ListAccessLevels(ctx context.Context) ([]dto.Access
 		return nil, err
 	}
 
+	links, err := s.repo.GetParentLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	graph := newAccessLevelGraph(links)
+
 	responses := make([]dto.AccessLevelResponse, 0, len(accessLevels))
 	for _, al := range accessLevels {
+		responses = append(responses, toAccessLevelResponse(al, graph[al.ID]))
+	}
+
+	return responses, nil
+}
+
+func (s *AccessLevelService) UpdateAccessLevel(ctx context.Context, id int, req *dto.UpdateAccessLevelRequest) (*dto.AccessLevelResponse, error) {
+	accessLevel, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil && *req.Name != accessLevel.Name {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, apperrors.Validation("name_required", "name cannot be empty")
+		}
+		if s.protected[accessLevel.Name] {
+			return nil, apperrors.Conflict("access_level_protected", "access level %s is built in and cannot be renamed", accessLevel.Name)
+		}
+		if s.protected[*req.Name] {
+			return nil, apperrors.Conflict("access_level_name_reserved", "access level name %s is reserved", *req.Name)
+		}
+		// Check if the new name is already taken by another access level
+		existing, _ := s.repo.GetByName(ctx, *req.Name)
+		if existing != nil && existing.ID != id {
+			return nil, apperrors.Conflict("access_level_name_taken", "access level with name %s already exists", *req.Name)
+		}
+		accessLevel.Name = *req.Name
+	}
+	if req.Description != nil {
+		if *req.Description == "" {
+			accessLevel.Description = nil
+		} else {
+			accessLevel.Description = req.Description
+		}
+	}
+
+	if err := s.repo.Update(ctx, accessLevel); err != nil {
+		return nil, fmt.Errorf("failed to update access level: %w", err)
+	}
+
+	return s.GetAccessLevel(ctx, id)
+}
+
+// DeleteAccessLevel removes an access level. Levels still assigned to users are
+// only deleted when force is set, in which case the assignments go with them.
+// Protected levels are never deleted.
+func (s *AccessLevelService) DeleteAccessLevel(ctx context.Context, id int, force bool) error {
+	accessLevel, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if s.protected[accessLevel.Name] {
+		return apperrors.Conflict("access_level_protected", "access level %s is built in and cannot be deleted", accessLevel.Name)
+	}
+
+	if !force {
+		users, err := s.repo.CountUsers(ctx, id)
+		if err != nil {
+			return err
+		}
+		if users > 0 {
+			return apperrors.Conflict("access_level_in_use",
+				"access level is still assigned to users: %d assignment(s) remain, use force=true to delete it anyway", users)
+		}
+	}
+
+	return s.repo.Delete(ctx, id, force)
+}
+
+// SetAccessLevelParents replaces the parents of an access level, rejecting
+// changes that would make the level inherit from itself
+func (s *AccessLevelService) SetAccessLevelParents(ctx context.Context, id int, req *dto.SetAccessLevelParentsRequest) (*dto.AccessLevelResponse, error) {
+	accessLevel, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	parentIDs := uniqueIDs(req.ParentIDs)
+	// The hierarchy is locked before it is checked, so two concurrent changes
+	// cannot each pass the cycle check and form a cycle together
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.AccessLevels.LockParentLinks(ctx); err != nil {
+			return err
+		}
+		if err := checkParentsExist(ctx, repos.AccessLevels, parentIDs); err != nil {
+			return err
+		}
+
+		links, err := repos.AccessLevels.GetParentLinks(ctx)
+		if err != nil {
+			return err
+		}
+		graph := newAccessLevelGraph(links)
+		delete(graph, id)
+		if cycle := graph.findCycle(id, parentIDs); cycle != nil {
+			return apperrors.Validation("access_level_cycle", "access level hierarchy would contain a cycle: %s", formatIDPath(cycle))
+		}
+
+		return repos.AccessLevels.SetParents(ctx, id, parentIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := toAccessLevelResponse(accessLevel, parentIDs)
+	return &response, nil
+}
+
+func checkParentsExist(ctx context.Context, repo repository.AccessLevelRepository, parentIDs []int) error {
+	for _, parentID := range parentIDs {
+		_, err := repo.GetByID(ctx, parentID)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.Validation("parent_access_level_not_found", "parent access level %d not found", parentID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *AccessLevelService) ListPermissions(ctx context.Context) ([]dto.PermissionResponse, error) {
+	permissions, err := s.permissionRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toPermissionResponses(permissions), nil
+}
+
+func (s *AccessLevelService) GetAccessLevelPermissions(ctx context.Context, accessLevelID int) ([]dto.PermissionResponse, error) {
+	if _, err := s.repo.GetByID(ctx, accessLevelID); err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.permissionRepo.GetByAccessLevels(ctx, []int{accessLevelID})
+	if err != nil {
+		return nil, err
+	}
+	return toPermissionResponses(permissions), nil
+}
+
+func (s *AccessLevelService) AssignPermissions(ctx context.Context, accessLevelID int, req *dto.AssignPermissionsRequest) ([]dto.PermissionResponse, error) {
+	if _, err := s.repo.GetByID(ctx, accessLevelID); err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.permissionRepo.GetByNames(ctx, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject the whole request if any permission name is unknown
+	known := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		known[p.Name] = true
+	}
+	var unknown []string
+	for _, name := range req.Permissions {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, apperrors.Validation("unknown_permissions", "unknown permissions: %s", strings.Join(unknown, ", "))
+	}
+
+	// Grant all of the permissions or none of them
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		for _, p := range permissions {
+			if err := repos.Permissions.AssignToAccessLevel(ctx, accessLevelID, p.ID); err != nil {
+				return fmt.Errorf("failed to assign permission %s: %w", p.Name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAccessLevelPermissions(ctx, accessLevelID)
+}
+
+func (s *AccessLevelService) RemovePermission(ctx context.Context, accessLevelID, permissionID int) error {
+	return s.permissionRepo.RemoveFromAccessLevel(ctx, accessLevelID, permissionID)
+}
+
+func toPermissionResponses(permissions []*models.Permission) []dto.PermissionResponse {
+	responses := make([]dto.PermissionResponse, 0, len(permissions))
+	for _, p := range permissions {
 		desc := ""
-		if al.Description != nil {
-			desc = *al.Description
+		if p.Description != nil {
+			desc = *p.Description
 		}
-		responses = append(responses, dto.AccessLevelResponse{
-			ID:          al.ID,
-			Name:        al.Name,
+		responses = append(responses, dto.PermissionResponse{
+			ID:          p.ID,
+			Name:        p.Name,
 			Description: desc,
 		})
 	}
+	return responses
+}
 
-	return responses, nil
+func toAccessLevelResponse(accessLevel *models.AccessLevel, parentIDs []int) dto.AccessLevelResponse {
+	desc := ""
+	if accessLevel.Description != nil {
+		desc = *accessLevel.Description
+	}
+	return dto.AccessLevelResponse{
+		ID:          accessLevel.ID,
+		Name:        accessLevel.Name,
+		Description: desc,
+		ParentIDs:   parentIDs,
+	}
+}
+
+func toAccessLevelResponses(accessLevels []*models.AccessLevel) []dto.AccessLevelResponse {
+	responses := make([]dto.AccessLevelResponse, 0, len(accessLevels))
+	for _, al := range accessLevels {
+		responses = append(responses, toAccessLevelResponse(al, nil))
+	}
+	return responses
 }
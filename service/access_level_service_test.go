@@ -6,9 +6,11 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/wabtcdi/user_service/apperrors"
 	"github.com/wabtcdi/user_service/dto"
 	"github.com/wabtcdi/user_service/mocks"
 	"github.com/wabtcdi/user_service/models"
+	"github.com/wabtcdi/user_service/repository"
 )
 
 func TestAccessLevelService_CreateAccessLevel(t *testing.T) {
@@ -16,7 +18,7 @@ func TestAccessLevelService_CreateAccessLevel(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
-	service := NewAccessLevelService(mockRepo)
+	service := NewAccessLevelService(mockRepo, mocks.NewMockPermissionRepository(ctrl))
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
@@ -123,12 +125,101 @@ func TestAccessLevelService_CreateAccessLevel(t *testing.T) {
 	})
 }
 
+func TestAccessLevelService_CreateAccessLevel_UnitOfWork(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The level and its parents are written through the unit of work, so a
+	// failure setting the parents rolls back the level as well
+	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockUoW := mocks.NewMockUnitOfWork(ctrl)
+	txRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewAccessLevelService(mockRepo, mocks.NewMockPermissionRepository(ctrl), WithAccessLevelUnitOfWork(mockUoW))
+	ctx := context.Background()
+
+	mockRepo.EXPECT().GetByName(ctx, "Editor").Return(nil, apperrors.NotFound("access_level_not_found", "access level not found"))
+	mockRepo.EXPECT().GetByID(ctx, 1).Return(&models.AccessLevel{ID: 1, Name: "Viewer"}, nil)
+	mockUoW.EXPECT().Do(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(repository.Repositories) error) error {
+			return fn(repository.Repositories{AccessLevels: txRepo})
+		})
+	txRepo.EXPECT().Create(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, al *models.AccessLevel) error {
+			al.ID = 2
+			return nil
+		})
+	txRepo.EXPECT().SetParents(ctx, 2, []int{1}).Return(errors.New("database error"))
+
+	_, err := service.CreateAccessLevel(ctx, &dto.CreateAccessLevelRequest{Name: "Editor", ParentIDs: []int{1}})
+	if err == nil {
+		t.Fatal("Expected error to be returned from the unit of work, got nil")
+	}
+}
+
+func TestAccessLevelService_SetAccessLevelParents_UnitOfWork(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The hierarchy is locked, checked and rewritten in one transaction
+	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockUoW := mocks.NewMockUnitOfWork(ctrl)
+	txRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewAccessLevelService(mockRepo, mocks.NewMockPermissionRepository(ctrl), WithAccessLevelUnitOfWork(mockUoW))
+	ctx := context.Background()
+
+	mockRepo.EXPECT().GetByID(ctx, 2).Return(&models.AccessLevel{ID: 2, Name: "Editor"}, nil)
+	mockUoW.EXPECT().Do(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(repository.Repositories) error) error {
+			return fn(repository.Repositories{AccessLevels: txRepo})
+		})
+	gomock.InOrder(
+		txRepo.EXPECT().LockParentLinks(ctx).Return(nil),
+		txRepo.EXPECT().GetByID(ctx, 1).Return(&models.AccessLevel{ID: 1, Name: "Viewer"}, nil),
+		txRepo.EXPECT().GetParentLinks(ctx).Return(nil, nil),
+		txRepo.EXPECT().SetParents(ctx, 2, []int{1}).Return(nil),
+	)
+
+	if _, err := service.SetAccessLevelParents(ctx, 2, &dto.SetAccessLevelParentsRequest{ParentIDs: []int{1}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestAccessLevelService_AssignPermissions_UnitOfWork(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// A failure granting one permission rolls back the ones granted before it
+	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockPermissionRepo := mocks.NewMockPermissionRepository(ctrl)
+	mockUoW := mocks.NewMockUnitOfWork(ctrl)
+	txPermissionRepo := mocks.NewMockPermissionRepository(ctrl)
+	service := NewAccessLevelService(mockRepo, mockPermissionRepo, WithAccessLevelUnitOfWork(mockUoW))
+	ctx := context.Background()
+	req := &dto.AssignPermissionsRequest{Permissions: []string{"users:read", "users:write"}}
+
+	mockRepo.EXPECT().GetByID(ctx, 1).Return(&models.AccessLevel{ID: 1, Name: "Viewer"}, nil)
+	mockPermissionRepo.EXPECT().GetByNames(ctx, req.Permissions).Return([]*models.Permission{
+		{ID: 1, Name: "users:read"},
+		{ID: 2, Name: "users:write"},
+	}, nil)
+	mockUoW.EXPECT().Do(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(repository.Repositories) error) error {
+			return fn(repository.Repositories{Permissions: txPermissionRepo})
+		})
+	txPermissionRepo.EXPECT().AssignToAccessLevel(ctx, 1, 1).Return(nil)
+	txPermissionRepo.EXPECT().AssignToAccessLevel(ctx, 1, 2).Return(errors.New("database error"))
+
+	if _, err := service.AssignPermissions(ctx, 1, req); err == nil {
+		t.Fatal("Expected error to be returned from the unit of work, got nil")
+	}
+}
+
 func TestAccessLevelService_GetAccessLevel(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
-	service := NewAccessLevelService(mockRepo)
+	service := NewAccessLevelService(mockRepo, mocks.NewMockPermissionRepository(ctrl))
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
@@ -142,11 +233,17 @@ func TestAccessLevelService_GetAccessLevel(t *testing.T) {
 		mockRepo.EXPECT().
 			GetByID(ctx, 1).
 			Return(accessLevel, nil)
+		mockRepo.EXPECT().
+			GetParentLinks(ctx).
+			Return([]*models.AccessLevelParent{{AccessLevelID: 1, ParentID: 2}, {AccessLevelID: 2, ParentID: 3}}, nil)
 
 		resp, err := service.GetAccessLevel(ctx, 1)
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
+		if len(resp.ParentIDs) != 1 || resp.ParentIDs[0] != 2 {
+			t.Errorf("Expected parent IDs [2], got %v", resp.ParentIDs)
+		}
 
 		if resp.ID != 1 {
 			t.Errorf("Expected ID 1, got %d", resp.ID)
@@ -169,6 +266,9 @@ func TestAccessLevelService_GetAccessLevel(t *testing.T) {
 		mockRepo.EXPECT().
 			GetByID(ctx, 2).
 			Return(accessLevel, nil)
+		mockRepo.EXPECT().
+			GetParentLinks(ctx).
+			Return(nil, nil)
 
 		resp, err := service.GetAccessLevel(ctx, 2)
 		if err != nil {
@@ -200,7 +300,7 @@ func TestAccessLevelService_ListAccessLevels(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
-	service := NewAccessLevelService(mockRepo)
+	service := NewAccessLevelService(mockRepo, mocks.NewMockPermissionRepository(ctrl))
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
@@ -222,6 +322,9 @@ func TestAccessLevelService_ListAccessLevels(t *testing.T) {
 		mockRepo.EXPECT().
 			List(ctx).
 			Return(accessLevels, nil)
+		mockRepo.EXPECT().
+			GetParentLinks(ctx).
+			Return(nil, nil)
 
 		resp, err := service.ListAccessLevels(ctx)
 		if err != nil {
@@ -244,6 +347,9 @@ func TestAccessLevelService_ListAccessLevels(t *testing.T) {
 		mockRepo.EXPECT().
 			List(ctx).
 			Return([]*models.AccessLevel{}, nil)
+		mockRepo.EXPECT().
+			GetParentLinks(ctx).
+			Return(nil, nil)
 
 		resp, err := service.ListAccessLevels(ctx)
 		if err != nil {
@@ -273,6 +379,9 @@ func TestAccessLevelService_ListAccessLevels(t *testing.T) {
 		mockRepo.EXPECT().
 			List(ctx).
 			Return(accessLevels, nil)
+		mockRepo.EXPECT().
+			GetParentLinks(ctx).
+			Return(nil, nil)
 
 		resp, err := service.ListAccessLevels(ctx)
 		if err != nil {
@@ -298,3 +407,364 @@ func TestAccessLevelService_ListAccessLevels(t *testing.T) {
 		}
 	})
 }
+
+func TestAccessLevelService_Permissions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockPermissionRepo := mocks.NewMockPermissionRepository(ctrl)
+	service := NewAccessLevelService(mockRepo, mockPermissionRepo)
+	ctx := context.Background()
+
+	accessLevel := &models.AccessLevel{ID: 1, Name: "reader"}
+	permissions := []*models.Permission{{ID: 1, Name: "users:read"}, {ID: 2, Name: "users:write"}}
+
+	t.Run("ListPermissions", func(t *testing.T) {
+		mockPermissionRepo.EXPECT().List(ctx).Return(permissions, nil)
+
+		resp, err := service.ListPermissions(ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp) != 2 || resp[0].Name != "users:read" {
+			t.Errorf("Unexpected permissions: %+v", resp)
+		}
+	})
+
+	t.Run("GetAccessLevelPermissions", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(accessLevel, nil)
+		mockPermissionRepo.EXPECT().GetByAccessLevels(ctx, []int{1}).Return(permissions[:1], nil)
+
+		resp, err := service.GetAccessLevelPermissions(ctx, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp) != 1 || resp[0].Name != "users:read" {
+			t.Errorf("Unexpected permissions: %+v", resp)
+		}
+	})
+
+	t.Run("GetAccessLevelPermissionsNotFound", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 99).Return(nil, errors.New("access level not found"))
+
+		if _, err := service.GetAccessLevelPermissions(ctx, 99); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("AssignPermissions", func(t *testing.T) {
+		req := &dto.AssignPermissionsRequest{Permissions: []string{"users:read", "users:write"}}
+
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(accessLevel, nil).Times(2)
+		mockPermissionRepo.EXPECT().GetByNames(ctx, req.Permissions).Return(permissions, nil)
+		mockPermissionRepo.EXPECT().AssignToAccessLevel(ctx, 1, 1).Return(nil)
+		mockPermissionRepo.EXPECT().AssignToAccessLevel(ctx, 1, 2).Return(nil)
+		mockPermissionRepo.EXPECT().GetByAccessLevels(ctx, []int{1}).Return(permissions, nil)
+
+		resp, err := service.AssignPermissions(ctx, 1, req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp) != 2 {
+			t.Errorf("Expected 2 permissions, got %d", len(resp))
+		}
+	})
+
+	t.Run("AssignUnknownPermissions", func(t *testing.T) {
+		req := &dto.AssignPermissionsRequest{Permissions: []string{"users:read", "users:fly", "users:swim"}}
+
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(accessLevel, nil)
+		mockPermissionRepo.EXPECT().GetByNames(ctx, req.Permissions).Return(permissions[:1], nil)
+
+		_, err := service.AssignPermissions(ctx, 1, req)
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+		if err.Error() != "unknown permissions: users:fly, users:swim" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("RemovePermission", func(t *testing.T) {
+		mockPermissionRepo.EXPECT().RemoveFromAccessLevel(ctx, 1, 2).Return(nil)
+
+		if err := service.RemovePermission(ctx, 1, 2); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+}
+
+func TestAccessLevelService_Hierarchy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewAccessLevelService(mockRepo, mocks.NewMockPermissionRepository(ctrl))
+	ctx := context.Background()
+
+	viewer := &models.AccessLevel{ID: 1, Name: "viewer"}
+	editor := &models.AccessLevel{ID: 2, Name: "editor"}
+	admin := &models.AccessLevel{ID: 3, Name: "admin"}
+	// admin inherits editor, which inherits viewer
+	links := []*models.AccessLevelParent{
+		{AccessLevelID: 2, ParentID: 1},
+		{AccessLevelID: 3, ParentID: 2},
+	}
+
+	t.Run("CreateWithParents", func(t *testing.T) {
+		req := &dto.CreateAccessLevelRequest{Name: "owner", ParentIDs: []int{3, 3}}
+
+		mockRepo.EXPECT().GetByName(ctx, "owner").Return(nil, errors.New("not found"))
+		mockRepo.EXPECT().GetByID(ctx, 3).Return(admin, nil)
+		mockRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, al *models.AccessLevel) error {
+				al.ID = 4
+				return nil
+			})
+		mockRepo.EXPECT().SetParents(ctx, 4, []int{3}).Return(nil)
+
+		resp, err := service.CreateAccessLevel(ctx, req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp.ParentIDs) != 1 || resp.ParentIDs[0] != 3 {
+			t.Errorf("Expected parent IDs [3], got %v", resp.ParentIDs)
+		}
+	})
+
+	t.Run("CreateWithUnknownParent", func(t *testing.T) {
+		req := &dto.CreateAccessLevelRequest{Name: "owner", ParentIDs: []int{99}}
+
+		mockRepo.EXPECT().GetByName(ctx, "owner").Return(nil, errors.New("not found"))
+		mockRepo.EXPECT().GetByID(ctx, 99).Return(nil, errors.New("access level not found"))
+
+		if _, err := service.CreateAccessLevel(ctx, req); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("SetParents", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 3).Return(admin, nil)
+		mockRepo.EXPECT().LockParentLinks(ctx).Return(nil)
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(viewer, nil)
+		mockRepo.EXPECT().GetByID(ctx, 2).Return(editor, nil)
+		mockRepo.EXPECT().GetParentLinks(ctx).Return(links, nil)
+		mockRepo.EXPECT().SetParents(ctx, 3, []int{1, 2}).Return(nil)
+
+		resp, err := service.SetAccessLevelParents(ctx, 3, &dto.SetAccessLevelParentsRequest{ParentIDs: []int{2, 1}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp.ParentIDs) != 2 {
+			t.Errorf("Expected 2 parent IDs, got %v", resp.ParentIDs)
+		}
+	})
+
+	t.Run("SetParentsRejectsCycle", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(viewer, nil)
+		mockRepo.EXPECT().LockParentLinks(ctx).Return(nil)
+		mockRepo.EXPECT().GetByID(ctx, 3).Return(admin, nil)
+		mockRepo.EXPECT().GetParentLinks(ctx).Return(links, nil)
+
+		_, err := service.SetAccessLevelParents(ctx, 1, &dto.SetAccessLevelParentsRequest{ParentIDs: []int{3}})
+		if err == nil {
+			t.Fatal("Expected cycle error, got nil")
+		}
+		if err.Error() != "access level hierarchy would contain a cycle: 1 -> 3 -> 2 -> 1" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("SetParentsRejectsSelf", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 2).Return(editor, nil).Times(2)
+		mockRepo.EXPECT().LockParentLinks(ctx).Return(nil)
+		mockRepo.EXPECT().GetParentLinks(ctx).Return(links, nil)
+
+		if _, err := service.SetAccessLevelParents(ctx, 2, &dto.SetAccessLevelParentsRequest{ParentIDs: []int{2}}); err == nil {
+			t.Fatal("Expected cycle error, got nil")
+		}
+	})
+
+	t.Run("ClearParents", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 3).Return(admin, nil)
+		mockRepo.EXPECT().LockParentLinks(ctx).Return(nil)
+		mockRepo.EXPECT().GetParentLinks(ctx).Return(links, nil)
+		mockRepo.EXPECT().SetParents(ctx, 3, []int{}).Return(nil)
+
+		if _, err := service.SetAccessLevelParents(ctx, 3, &dto.SetAccessLevelParentsRequest{}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+}
+
+func TestAccessLevelService_UpdateAccessLevel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewAccessLevelService(mockRepo, mocks.NewMockPermissionRepository(ctrl))
+	ctx := context.Background()
+
+	t.Run("Rename", func(t *testing.T) {
+		desc := "Can edit"
+		accessLevel := &models.AccessLevel{ID: 2, Name: "writer", Description: &desc}
+		name := "editor"
+
+		mockRepo.EXPECT().GetByID(ctx, 2).Return(accessLevel, nil).Times(2)
+		mockRepo.EXPECT().GetByName(ctx, name).Return(nil, errors.New("not found"))
+		mockRepo.EXPECT().Update(ctx, accessLevel).Return(nil)
+		mockRepo.EXPECT().GetParentLinks(ctx).Return(nil, nil)
+
+		resp, err := service.UpdateAccessLevel(ctx, 2, &dto.UpdateAccessLevelRequest{Name: &name})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.Name != name {
+			t.Errorf("Expected name %s, got %s", name, resp.Name)
+		}
+		if resp.Description != desc {
+			t.Errorf("Expected description to stay %s, got %s", desc, resp.Description)
+		}
+	})
+
+	t.Run("RenameConflict", func(t *testing.T) {
+		name := "admin"
+
+		mockRepo.EXPECT().GetByID(ctx, 2).Return(&models.AccessLevel{ID: 2, Name: "editor"}, nil)
+		mockRepo.EXPECT().GetByName(ctx, name).Return(&models.AccessLevel{ID: 3, Name: name}, nil)
+
+		_, err := service.UpdateAccessLevel(ctx, 2, &dto.UpdateAccessLevelRequest{Name: &name})
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+		if err.Error() != "access level with name admin already exists" {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("ClearDescription", func(t *testing.T) {
+		desc := "Can edit"
+		accessLevel := &models.AccessLevel{ID: 2, Name: "editor", Description: &desc}
+		empty := ""
+
+		mockRepo.EXPECT().GetByID(ctx, 2).Return(accessLevel, nil).Times(2)
+		mockRepo.EXPECT().Update(ctx, accessLevel).
+			DoAndReturn(func(ctx context.Context, al *models.AccessLevel) error {
+				if al.Description != nil {
+					t.Errorf("Expected description to be cleared, got %s", *al.Description)
+				}
+				return nil
+			})
+		mockRepo.EXPECT().GetParentLinks(ctx).Return(nil, nil)
+
+		if _, err := service.UpdateAccessLevel(ctx, 2, &dto.UpdateAccessLevelRequest{Description: &empty}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 99).Return(nil, errors.New("access level not found"))
+
+		if _, err := service.UpdateAccessLevel(ctx, 99, &dto.UpdateAccessLevelRequest{}); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+}
+
+func TestAccessLevelService_ProtectedAccessLevels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewAccessLevelService(mockRepo, mocks.NewMockPermissionRepository(ctrl), WithProtectedAccessLevels("admin"))
+	ctx := context.Background()
+	admin := &models.AccessLevel{ID: 1, Name: "admin"}
+
+	t.Run("Rename", func(t *testing.T) {
+		name := "former-admin"
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(admin, nil)
+
+		_, err := service.UpdateAccessLevel(ctx, 1, &dto.UpdateAccessLevelRequest{Name: &name})
+		if apperrors.Code(err) != "access_level_protected" {
+			t.Errorf("Expected access_level_protected, got %v", err)
+		}
+	})
+
+	t.Run("TakeName", func(t *testing.T) {
+		name := "admin"
+		mockRepo.EXPECT().GetByID(ctx, 2).Return(&models.AccessLevel{ID: 2, Name: "editor"}, nil)
+
+		_, err := service.UpdateAccessLevel(ctx, 2, &dto.UpdateAccessLevelRequest{Name: &name})
+		if apperrors.Code(err) != "access_level_name_reserved" {
+			t.Errorf("Expected access_level_name_reserved, got %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(admin, nil)
+
+		err := service.DeleteAccessLevel(ctx, 1, true)
+		if apperrors.Code(err) != "access_level_protected" {
+			t.Errorf("Expected access_level_protected, got %v", err)
+		}
+	})
+
+	t.Run("Describe", func(t *testing.T) {
+		desc := "Full access"
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(admin, nil).Times(2)
+		mockRepo.EXPECT().Update(ctx, admin).Return(nil)
+		mockRepo.EXPECT().GetParentLinks(ctx).Return(nil, nil)
+
+		if _, err := service.UpdateAccessLevel(ctx, 1, &dto.UpdateAccessLevelRequest{Description: &desc}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+}
+
+func TestAccessLevelService_DeleteAccessLevel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewAccessLevelService(mockRepo, mocks.NewMockPermissionRepository(ctrl))
+	ctx := context.Background()
+	accessLevel := &models.AccessLevel{ID: 1, Name: "viewer"}
+
+	t.Run("Unassigned", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(accessLevel, nil)
+		mockRepo.EXPECT().CountUsers(ctx, 1).Return(int64(0), nil)
+		mockRepo.EXPECT().Delete(ctx, 1, false).Return(nil)
+
+		if err := service.DeleteAccessLevel(ctx, 1, false); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("StillAssigned", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(accessLevel, nil)
+		mockRepo.EXPECT().CountUsers(ctx, 1).Return(int64(2), nil)
+
+		err := service.DeleteAccessLevel(ctx, 1, false)
+		if !errors.Is(err, apperrors.ErrConflict) || apperrors.Code(err) != "access_level_in_use" {
+			t.Fatalf("Expected access_level_in_use conflict, got %v", err)
+		}
+	})
+
+	t.Run("Force", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 1).Return(accessLevel, nil)
+		mockRepo.EXPECT().Delete(ctx, 1, true).Return(nil)
+
+		if err := service.DeleteAccessLevel(ctx, 1, true); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockRepo.EXPECT().GetByID(ctx, 99).Return(nil, errors.New("access level not found"))
+
+		if err := service.DeleteAccessLevel(ctx, 99, true); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+}
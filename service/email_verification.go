@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/auth"
+	"github.com/wabtcdi/user_service/dto"
+	"github.com/wabtcdi/user_service/models"
+	"github.com/wabtcdi/user_service/notify"
+	"github.com/wabtcdi/user_service/repository"
+)
+
+const defaultEmailVerificationTTL = 24 * time.Hour
+
+// errInvalidVerificationToken is returned for unknown, expired, spent and
+// outdated verification tokens alike
+var errInvalidVerificationToken = apperrors.Validation("invalid_verification_token", "email verification token is invalid or has expired")
+
+// errEmailNotVerified refuses logins to unverified accounts when verification
+// is required
+var errEmailNotVerified = apperrors.Unauthorized("email_not_verified", "email address has not been verified")
+
+type emailVerificationConfig struct {
+	repo     repository.EmailVerificationTokenRepository
+	notifier notify.Notifier
+	url      string
+	ttl      time.Duration
+	required bool
+}
+
+// startEmailVerification sends a verification link for the user's current
+// address. The account change that prompted it has already been saved, so a
+// failure is only logged; the user can ask for another link.
+func (s *UserService) startEmailVerification(ctx context.Context, user *models.User) {
+	if s.emailVerify.repo == nil {
+		return
+	}
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		logrus.Errorf("Failed to send verification email to user %s: %v", user.ID, err)
+	}
+}
+
+// sendVerificationEmail replaces any outstanding verification tokens of the
+// user with a new one for their current address and sends its link
+func (s *UserService) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, tokenHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate email verification token: %w", err)
+	}
+	link, err := tokenLink(s.emailVerify.url, token)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(s.emailVerify.ttl)
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.EmailVerifications.InvalidateForUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return repos.EmailVerifications.Create(ctx, &models.EmailVerificationToken{
+			UserID:    user.ID,
+			Email:     user.Email,
+			TokenHash: tokenHash,
+			ExpiresAt: expiresAt,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store email verification token: %w", err)
+	}
+
+	err = s.emailVerify.notifier.Notify(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Use this link to confirm your email address. It can be used once and expires at %s.\n\n%s",
+			expiresAt.UTC().Format(time.RFC1123), link),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send verification link: %w", err)
+	}
+	return nil
+}
+
+// ResendVerificationEmail sends a new verification link to the account with
+// the given email if it is still unverified. Unknown and already verified
+// addresses are ignored so the endpoint cannot be used to discover accounts.
+func (s *UserService) ResendVerificationEmail(ctx context.Context, req *dto.ResendVerificationEmailRequest) error {
+	if s.emailVerify.repo == nil {
+		return fmt.Errorf("email verification is not enabled")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	return s.sendVerificationEmail(ctx, user)
+}
+
+// VerifyEmail spends a verification token and marks the address it was sent
+// to as verified. Tokens sent to an address the user has since changed are
+// rejected.
+func (s *UserService) VerifyEmail(ctx context.Context, req *dto.VerifyEmailRequest) error {
+	if s.emailVerify.repo == nil {
+		return fmt.Errorf("email verification is not enabled")
+	}
+
+	token, err := s.emailVerify.repo.GetByHash(ctx, auth.HashOpaqueToken(req.Token))
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errInvalidVerificationToken
+	}
+	if err != nil {
+		return err
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return errInvalidVerificationToken
+	}
+
+	return s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		err := repos.EmailVerifications.MarkUsed(ctx, token.ID)
+		if errors.Is(err, repository.ErrEmailVerificationTokenUsed) {
+			return errInvalidVerificationToken
+		}
+		if err != nil {
+			return err
+		}
+
+		err = repos.Users.MarkEmailVerified(ctx, token.UserID, token.Email)
+		if errors.Is(err, repository.ErrEmailChanged) {
+			return errInvalidVerificationToken
+		}
+		return err
+	})
+}
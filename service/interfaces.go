@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/wabtcdi/user_service/dto"
@@ -13,10 +14,34 @@ type UserServiceInterface interface {
 	GetUser(ctx context.Context, id uuid.UUID) (*dto.UserResponse, error)
 	UpdateUser(ctx context.Context, id uuid.UUID, req *dto.UpdateUserRequest) (*dto.UserResponse, error)
 	DeleteUser(ctx context.Context, id uuid.UUID) error
-	ListUsers(ctx context.Context, page, pageSize int) (*dto.ListUsersResponse, error)
+	RestoreUser(ctx context.Context, id uuid.UUID) (*dto.UserResponse, error)
+	PurgeUser(ctx context.Context, id uuid.UUID) error
+	ListUsers(ctx context.Context, query *dto.ListUsersQuery) (*dto.ListUsersResponse, error)
+	SearchUsers(ctx context.Context, query string, limit int) (*dto.SearchUsersResponse, error)
 	AuthenticateUser(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error)
+	RefreshTokens(ctx context.Context, req *dto.RefreshTokenRequest) (*dto.TokenResponse, error)
+	ChangePassword(ctx context.Context, userID uuid.UUID, req *dto.ChangePasswordRequest) error
+	RequestPasswordReset(ctx context.Context, req *dto.PasswordResetRequest) error
+	ConfirmPasswordReset(ctx context.Context, req *dto.PasswordResetConfirmRequest) error
+	UnlockUser(ctx context.Context, userID uuid.UUID) error
+	VerifyEmail(ctx context.Context, req *dto.VerifyEmailRequest) error
+	ResendVerificationEmail(ctx context.Context, req *dto.ResendVerificationEmailRequest) error
+	EnrollTOTP(ctx context.Context, userID uuid.UUID) (*dto.TOTPEnrollmentResponse, error)
+	ActivateTOTP(ctx context.Context, userID uuid.UUID, req *dto.ActivateTOTPRequest) (*dto.RecoveryCodesResponse, error)
+	VerifyMFA(ctx context.Context, req *dto.MFAVerifyRequest) (*dto.LoginResponse, error)
+	DisableTOTP(ctx context.Context, userID uuid.UUID, req *dto.MFACodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req *dto.MFACodeRequest) (*dto.RecoveryCodesResponse, error)
+	ResetMFA(ctx context.Context, userID uuid.UUID) error
+	ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
 	AssignAccessLevels(ctx context.Context, userID uuid.UUID, req *dto.AssignAccessLevelRequest) error
+	ReplaceAccessLevels(ctx context.Context, userID uuid.UUID, req *dto.ReplaceAccessLevelsRequest) ([]dto.AccessLevelResponse, error)
+	RemoveAccessLevel(ctx context.Context, userID uuid.UUID, accessLevelID int) error
 	GetUserAccessLevels(ctx context.Context, userID uuid.UUID) ([]dto.AccessLevelResponse, error)
+	GetUserEffectiveAccessLevels(ctx context.Context, userID uuid.UUID) (*dto.UserAccessLevelsResponse, error)
+	GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error)
 }
 
 // AccessLevelServiceInterface defines the interface for access level service operations
@@ -24,6 +49,18 @@ type AccessLevelServiceInterface interface {
 	CreateAccessLevel(ctx context.Context, req *dto.CreateAccessLevelRequest) (*dto.AccessLevelResponse, error)
 	GetAccessLevel(ctx context.Context, id int) (*dto.AccessLevelResponse, error)
 	ListAccessLevels(ctx context.Context) ([]dto.AccessLevelResponse, error)
+	UpdateAccessLevel(ctx context.Context, id int, req *dto.UpdateAccessLevelRequest) (*dto.AccessLevelResponse, error)
+	DeleteAccessLevel(ctx context.Context, id int, force bool) error
+	SetAccessLevelParents(ctx context.Context, id int, req *dto.SetAccessLevelParentsRequest) (*dto.AccessLevelResponse, error)
+	ListPermissions(ctx context.Context) ([]dto.PermissionResponse, error)
+	GetAccessLevelPermissions(ctx context.Context, accessLevelID int) ([]dto.PermissionResponse, error)
+	AssignPermissions(ctx context.Context, accessLevelID int, req *dto.AssignPermissionsRequest) ([]dto.PermissionResponse, error)
+	RemovePermission(ctx context.Context, accessLevelID, permissionID int) error
+}
+
+// TokenIssuer issues signed access tokens for authenticated users
+type TokenIssuer interface {
+	IssueAccessToken(userID uuid.UUID, accessLevels []string, sessionID string) (string, time.Duration, error)
 }
 
 // Ensure UserService implements UserServiceInterface
@@ -0,0 +1,490 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/auth"
+	"github.com/wabtcdi/user_service/dto"
+	"github.com/wabtcdi/user_service/models"
+	"github.com/wabtcdi/user_service/repository"
+	"github.com/wabtcdi/user_service/totp"
+	"github.com/wabtcdi/user_service/validation"
+)
+
+const (
+	defaultMFAIssuer       = "user_service"
+	defaultMFAChallengeTTL = 5 * time.Minute
+	// mfaChallengeMaxAttempts is how many wrong codes a challenge accepts
+	// before the login has to start over
+	mfaChallengeMaxAttempts = 5
+	recoveryCodeCount       = 10
+	recoveryCodeLength      = 10
+)
+
+// errInvalidMFAToken is returned for unknown, expired, spent and exhausted MFA challenges alike
+var errInvalidMFAToken = apperrors.Unauthorized("invalid_mfa_token", "MFA token is invalid or has expired")
+
+// errInvalidMFACode is returned for wrong, replayed and spent codes alike
+var errInvalidMFACode = apperrors.Unauthorized("invalid_mfa_code", "MFA code is incorrect")
+
+// errIncorrectMFACode is returned to signed in users whose code does not
+// confirm a change to their MFA settings
+var errIncorrectMFACode = apperrors.Validation("invalid_mfa_code", "MFA code is incorrect")
+
+var errMFANotEnabled = apperrors.Validation("mfa_not_enabled", "MFA is not enabled")
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type mfaConfig struct {
+	repo           repository.MFARepository
+	challenges     repository.MFAChallengeRepository
+	secrets        *auth.SecretBox
+	issuer         string
+	challengeTTL   time.Duration
+	requiredLevels []string
+}
+
+// EnrollTOTP starts TOTP enrollment by generating a new secret for the user.
+// MFA stays off until the secret is confirmed with ActivateTOTP; enrolling
+// again before then replaces the secret.
+func (s *UserService) EnrollTOTP(ctx context.Context, userID uuid.UUID) (*dto.TOTPEnrollmentResponse, error) {
+	if s.mfa.repo == nil {
+		return nil, fmt.Errorf("MFA is not enabled")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	userAuth, err := s.userRepo.GetUserAuthentication(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if userAuth.MFAEnabledAt != nil {
+		return nil, apperrors.Conflict("mfa_already_enabled", "MFA is already enabled")
+	}
+	return s.newTOTPSecret(ctx, user)
+}
+
+// newTOTPSecret generates and stores a TOTP secret for the user to confirm
+func (s *UserService) newTOTPSecret(ctx context.Context, user *models.User) (*dto.TOTPEnrollmentResponse, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.mfa.secrets.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfa.repo.SetTOTPSecret(ctx, user.ID, sealed); err != nil {
+		return nil, err
+	}
+
+	return &dto.TOTPEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.mfa.issuer, user.Email, secret),
+	}, nil
+}
+
+// openTOTPSecret returns the user's TOTP secret, which must be set
+func (s *UserService) openTOTPSecret(userAuth *models.UserAuthentication) (string, error) {
+	secret, err := s.mfa.secrets.Open(*userAuth.TOTPSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to open TOTP secret: %w", err)
+	}
+	return secret, nil
+}
+
+// ActivateTOTP turns on MFA once the user proves their authenticator app
+// holds the enrolled secret, and returns a fresh set of recovery codes
+func (s *UserService) ActivateTOTP(ctx context.Context, userID uuid.UUID, req *dto.ActivateTOTPRequest) (*dto.RecoveryCodesResponse, error) {
+	if s.mfa.repo == nil {
+		return nil, fmt.Errorf("MFA is not enabled")
+	}
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	userAuth, err := s.userRepo.GetUserAuthentication(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if userAuth.MFAEnabledAt != nil {
+		return nil, apperrors.Conflict("mfa_already_enabled", "MFA is already enabled")
+	}
+	if userAuth.TOTPSecret == nil {
+		return nil, apperrors.Validation("mfa_not_enrolled", "TOTP enrollment has not been started")
+	}
+	secret, err := s.openTOTPSecret(userAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(secret, req.Code, time.Now())
+	if !ok {
+		return nil, errIncorrectMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfa.repo.EnableTOTP(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// mfaRequired reports whether the user holds an access level that requires MFA
+func (s *UserService) mfaRequired(ctx context.Context, userID uuid.UUID) (bool, error) {
+	if len(s.mfa.requiredLevels) == 0 {
+		return false, nil
+	}
+	names, err := s.effectiveAccessLevelNames(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range names {
+		if slices.Contains(s.mfa.requiredLevels, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// startMFAChallenge answers a correct password for a user with MFA enabled
+// with a challenge token instead of a session
+func (s *UserService) startMFAChallenge(ctx context.Context, userID uuid.UUID) (*dto.LoginResponse, error) {
+	token, err := s.createMFAChallenge(ctx, userID, models.MFAChallengeVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		Message:     "MFA verification required",
+		MFARequired: true,
+		MFAToken:    token,
+	}, nil
+}
+
+// startMFAEnrollment answers a correct password for a user who must use MFA
+// but has not enabled it with a new TOTP secret and a challenge token that is
+// completed by confirming the secret
+func (s *UserService) startMFAEnrollment(ctx context.Context, user *models.User) (*dto.LoginResponse, error) {
+	enrollment, err := s.newTOTPSecret(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	token, err := s.createMFAChallenge(ctx, user.ID, models.MFAChallengeEnroll)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		Message:               "MFA enrollment required",
+		MFAEnrollmentRequired: true,
+		MFAToken:              token,
+		TOTPEnrollment:        enrollment,
+	}, nil
+}
+
+func (s *UserService) createMFAChallenge(ctx context.Context, userID uuid.UUID, purpose string) (string, error) {
+	token, tokenHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	challenge := &models.MFAChallenge{
+		UserID:    userID,
+		TokenHash: tokenHash,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(s.mfa.challengeTTL),
+	}
+	if err := s.mfa.challenges.Create(ctx, challenge); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// VerifyMFA completes a login started by AuthenticateUser with a code from
+// the user's authenticator app or one of their recovery codes. An enrollment
+// challenge only accepts a code for the new secret, enables MFA and returns
+// the user's recovery codes with the session. Wrong codes count towards the
+// account lockout, and a challenge is abandoned after mfaChallengeMaxAttempts
+// of them.
+func (s *UserService) VerifyMFA(ctx context.Context, req *dto.MFAVerifyRequest) (*dto.LoginResponse, error) {
+	if s.mfa.challenges == nil {
+		return nil, fmt.Errorf("MFA is not enabled")
+	}
+
+	challenge, err := s.mfa.challenges.GetByHash(ctx, auth.HashOpaqueToken(req.MFAToken))
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, errInvalidMFAToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) || challenge.FailedAttempts >= mfaChallengeMaxAttempts {
+		return nil, errInvalidMFAToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, challenge.UserID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, errInvalidMFAToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	userAuth, err := s.userRepo.GetUserAuthentication(ctx, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// Enrollment challenges lapse once MFA is on, and others once it is off
+	enroll := challenge.Purpose == models.MFAChallengeEnroll
+	if userAuth.TOTPSecret == nil || enroll != (userAuth.MFAEnabledAt == nil) {
+		return nil, errInvalidMFAToken
+	}
+	if s.lockout.enabled {
+		if err := s.checkLockout(userAuth); err != nil {
+			return nil, err
+		}
+	}
+	secret, err := s.openTOTPSecret(userAuth)
+	if err != nil {
+		return nil, err
+	}
+	var codes, hashes []string
+	if enroll {
+		if codes, hashes, err = generateRecoveryCodes(); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if enroll {
+			step, ok := totp.Validate(secret, req.Code, time.Now())
+			if !ok {
+				return errInvalidMFACode
+			}
+			if err := repos.MFA.EnableTOTP(ctx, user.ID, step, hashes); err != nil {
+				return err
+			}
+		} else if err := useSecondFactor(ctx, repos.MFA, user.ID, secret, req.Code); err != nil {
+			return err
+		}
+		err := repos.MFAChallenges.MarkUsed(ctx, challenge.ID)
+		if errors.Is(err, repository.ErrMFAChallengeUsed) {
+			return errInvalidMFAToken
+		}
+		return err
+	})
+	if errors.Is(err, errInvalidMFACode) {
+		if _, err := s.mfa.challenges.RecordFailedAttempt(ctx, challenge.ID); err != nil {
+			return nil, err
+		}
+		if s.lockout.enabled {
+			if err := s.recordFailedLogin(ctx, user.ID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, errInvalidMFACode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.lockout.enabled && userAuth.FailedLoginCount > 0 {
+		if err := s.userRepo.ResetFailedLogins(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	response, err := s.completeLogin(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	response.RecoveryCodes = codes
+	return response, nil
+}
+
+// DisableTOTP turns off MFA for a user who confirms it with a current code.
+// Users whose access levels require MFA cannot turn it off.
+func (s *UserService) DisableTOTP(ctx context.Context, userID uuid.UUID, req *dto.MFACodeRequest) error {
+	if s.mfa.repo == nil {
+		return fmt.Errorf("MFA is not enabled")
+	}
+	if err := validation.Struct(req); err != nil {
+		return err
+	}
+
+	userAuth, err := s.userRepo.GetUserAuthentication(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if userAuth.MFAEnabledAt == nil {
+		return errMFANotEnabled
+	}
+	required, err := s.mfaRequired(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return apperrors.Conflict("mfa_required", "MFA is required for the user's access levels")
+	}
+
+	return s.confirmSecondFactor(ctx, userAuth, req.Code, func(repos repository.Repositories) error {
+		if err := repos.MFA.DisableTOTP(ctx, userID); err != nil {
+			return err
+		}
+		return recordAuditEvent(ctx, repos, userID, models.AuditEventMFADisabled, "")
+	})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user who confirms
+// it with a current code, so that codes which were used up or may have been
+// seen by someone else stop working
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, req *dto.MFACodeRequest) (*dto.RecoveryCodesResponse, error) {
+	if s.mfa.repo == nil {
+		return nil, fmt.Errorf("MFA is not enabled")
+	}
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	userAuth, err := s.userRepo.GetUserAuthentication(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if userAuth.MFAEnabledAt == nil {
+		return nil, errMFANotEnabled
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.confirmSecondFactor(ctx, userAuth, req.Code, func(repos repository.Repositories) error {
+		if err := repos.MFA.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+			return err
+		}
+		return recordAuditEvent(ctx, repos, userID, models.AuditEventRecoveryCodesRegenerated, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// ResetMFA turns off MFA for a user who lost their authenticator and their
+// recovery codes. They log in with their password alone afterwards, or enroll
+// again straight away when their access levels require MFA.
+func (s *UserService) ResetMFA(ctx context.Context, userID uuid.UUID) error {
+	if s.mfa.repo == nil {
+		return fmt.Errorf("MFA is not enabled")
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+	userAuth, err := s.userRepo.GetUserAuthentication(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if userAuth.MFAEnabledAt == nil {
+		return errMFANotEnabled
+	}
+
+	return s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.MFA.DisableTOTP(ctx, userID); err != nil {
+			return err
+		}
+		return recordAuditEvent(ctx, repos, userID, models.AuditEventMFAReset, "")
+	})
+}
+
+// confirmSecondFactor runs then in the same transaction that spends code, a
+// TOTP or recovery code of the user. Like a wrong password, a wrong code
+// counts towards the account lockout.
+func (s *UserService) confirmSecondFactor(ctx context.Context, userAuth *models.UserAuthentication, code string, then func(repos repository.Repositories) error) error {
+	if s.lockout.enabled {
+		if err := s.checkLockout(userAuth); err != nil {
+			return err
+		}
+	}
+	secret, err := s.openTOTPSecret(userAuth)
+	if err != nil {
+		return err
+	}
+
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if err := useSecondFactor(ctx, repos.MFA, userAuth.UserID, secret, code); err != nil {
+			return err
+		}
+		if s.lockout.enabled && userAuth.FailedLoginCount > 0 {
+			if err := repos.Users.ResetFailedLogins(ctx, userAuth.UserID); err != nil {
+				return err
+			}
+		}
+		return then(repos)
+	})
+	if errors.Is(err, errInvalidMFACode) {
+		if s.lockout.enabled {
+			if err := s.recordFailedLogin(ctx, userAuth.UserID); err != nil {
+				return err
+			}
+		}
+		return errIncorrectMFACode
+	}
+	return err
+}
+
+// useSecondFactor accepts a TOTP code for secret that has not been used
+// before or spends a recovery code
+func useSecondFactor(ctx context.Context, repo repository.MFARepository, userID uuid.UUID, secret, code string) error {
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(secret, code, time.Now())
+		if !ok {
+			return errInvalidMFACode
+		}
+		err := repo.UseTOTPStep(ctx, userID, step)
+		if errors.Is(err, repository.ErrTOTPStepUsed) {
+			return errInvalidMFACode
+		}
+		return err
+	}
+
+	err := repo.UseRecoveryCode(ctx, userID, auth.HashOpaqueToken(normalizeRecoveryCode(code)))
+	if errors.Is(err, repository.ErrRecoveryCodeInvalid) {
+		return errInvalidMFACode
+	}
+	return err
+}
+
+// generateRecoveryCodes returns new recovery codes formatted for display as
+// "xxxxx-xxxxx", and the hashes to store for them
+func generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	buf := make([]byte, recoveryCodeLength*5/8)
+	for range recoveryCodeCount {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+		half := recoveryCodeLength / 2
+		codes = append(codes, code[:half]+"-"+code[half:])
+		hashes = append(hashes, auth.HashOpaqueToken(code))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode undoes the display formatting so codes can be typed
+// with or without the dash and in either case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
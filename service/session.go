@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/auth"
+	"github.com/wabtcdi/user_service/dto"
+	"github.com/wabtcdi/user_service/models"
+	"github.com/wabtcdi/user_service/repository"
+)
+
+const (
+	defaultSessionIdleTimeout     = 7 * 24 * time.Hour
+	defaultSessionAbsoluteTimeout = 30 * 24 * time.Hour
+
+	// sessionTouchInterval limits how often a session's last seen time is
+	// written, so busy clients do not cause a write per request
+	sessionTouchInterval = time.Minute
+
+	// maxUserAgentLength matches the sessions.user_agent column
+	maxUserAgentLength = 512
+)
+
+var (
+	errSessionRevoked = apperrors.Unauthorized("session_revoked", "session has been revoked")
+	errSessionExpired = apperrors.Unauthorized("session_expired", "session has expired")
+)
+
+type sessionConfig struct {
+	repo            repository.SessionRepository
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+}
+
+// expired reports whether session has been idle for too long or has passed
+// its absolute expiry at now
+func (c sessionConfig) expired(session *models.Session, now time.Time) bool {
+	return now.After(session.ExpiresAt) || now.Sub(session.LastSeenAt) > c.idleTimeout
+}
+
+// createSession records a session for a login. Its ID is shared with the
+// refresh token family of the login, so revoking one ends the other.
+func (s *UserService) createSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(s.sessions.absoluteTimeout),
+	}
+	if userAgent, ok := auth.UserAgentFromContext(ctx); ok {
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+		session.UserAgent = &userAgent
+	}
+	if ip, ok := auth.ClientIPFromContext(ctx); ok {
+		session.IPAddress = &ip
+	}
+	if err := s.sessions.repo.Create(ctx, session); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// ValidateSession checks that the session belongs to the user and is neither
+// revoked nor expired, and records that it has been seen. Sessions of users
+// who have since been deleted count as revoked.
+func (s *UserService) ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if s.sessions.repo == nil {
+		return nil
+	}
+	if _, err := s.activeSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	_, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errSessionRevoked
+	}
+	return err
+}
+
+// activeSession returns the user's session after checking it is still usable,
+// updating its last seen time when that is out of date
+func (s *UserService) activeSession(ctx context.Context, userID, sessionID uuid.UUID) (*models.Session, error) {
+	session, err := s.sessions.repo.GetByID(ctx, sessionID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, errSessionRevoked
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID || session.RevokedAt != nil {
+		return nil, errSessionRevoked
+	}
+
+	now := time.Now()
+	if s.sessions.expired(session, now) {
+		return nil, errSessionExpired
+	}
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.sessions.repo.Touch(ctx, session.ID, now); err != nil {
+			return nil, fmt.Errorf("failed to update session: %w", err)
+		}
+		session.LastSeenAt = now
+	}
+	return session, nil
+}
+
+// ListSessions returns the user's active sessions, most recently used first.
+// The session of the calling request is marked as current.
+func (s *UserService) ListSessions(ctx context.Context, userID uuid.UUID) ([]dto.SessionResponse, error) {
+	if s.sessions.repo == nil {
+		return nil, fmt.Errorf("sessions are not enabled")
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessions.repo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var currentID string
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		currentID = principal.SessionID
+	}
+	now := time.Now()
+	responses := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		if s.sessions.expired(session, now) {
+			continue
+		}
+		response := dto.SessionResponse{
+			ID:         session.ID,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID.String() == currentID,
+		}
+		if session.UserAgent != nil {
+			response.UserAgent = *session.UserAgent
+		}
+		if session.IPAddress != nil {
+			response.IPAddress = *session.IPAddress
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// RevokeSession ends one of the user's sessions together with the refresh
+// tokens issued for it
+func (s *UserService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if s.sessions.repo == nil {
+		return fmt.Errorf("sessions are not enabled")
+	}
+
+	session, err := s.sessions.repo.GetByID(ctx, sessionID)
+	if err == nil && session.UserID != userID {
+		err = apperrors.NotFound("session_not_found", "session not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.sessions.repo.Revoke(ctx, sessionID); err != nil {
+		return err
+	}
+	if s.refreshTokenRepo != nil {
+		if err := s.refreshTokenRepo.RevokeFamily(ctx, sessionID); err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+	}
+	return nil
+}
+
+// RevokeAllSessions logs the user out everywhere, ending every session and
+// refresh token they hold
+func (s *UserService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	if s.sessions.repo == nil {
+		return fmt.Errorf("sessions are not enabled")
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.sessions.repo.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	if s.refreshTokenRepo != nil {
+		if err := s.refreshTokenRepo.RevokeAllForUser(ctx, userID); err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+	}
+	return nil
+}
+
+// callerSessionID returns the session of the calling request when the caller
+// is the given user, or uuid.Nil when there is none
+func (s *UserService) callerSessionID(ctx context.Context, userID uuid.UUID) uuid.UUID {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || principal.UserID != userID {
+		return uuid.Nil
+	}
+	sessionID, err := uuid.Parse(principal.SessionID)
+	if err != nil {
+		return uuid.Nil
+	}
+	return sessionID
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/dto"
+	"github.com/wabtcdi/user_service/repository"
+)
+
+var (
+	errInvalidCursor = apperrors.Validation("invalid_cursor", "cursor is invalid or was issued for a different sort order")
+	errCursorSort    = apperrors.Validation("invalid_sort", "cursor pagination can only sort users by created_at")
+)
+
+// userCursor is the position encoded in a user listing cursor: the user it
+// was issued for, the listing's sort order and whether it pages backwards
+type userCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+	Ascending bool      `json:"a,omitempty"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+// listUsersByCursor returns the page of users after the position in token,
+// or the first page when token is empty, with cursors for the pages either
+// side of it
+func (s *UserService) listUsersByCursor(ctx context.Context, filter repository.UserFilter, token string, pageSize int) (*dto.ListUsersResponse, error) {
+	ascending, err := cursorSortOrder(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor userCursor
+	var after *repository.UserCursor
+	if token != "" {
+		if err := s.cursors.Decode(token, &cursor); err != nil || cursor.Ascending != ascending {
+			return nil, errInvalidCursor
+		}
+		after = &repository.UserCursor{CreatedAt: cursor.CreatedAt, ID: cursor.ID}
+	}
+
+	// Fetch one extra user to tell whether there is another page
+	users, err := s.userRepo.ListAfter(ctx, filter, after, ascending != cursor.Backward, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	more := len(users) > pageSize
+	if more {
+		users = users[:pageSize]
+	}
+	hasNext, hasPrev := more, after != nil
+	if cursor.Backward {
+		slices.Reverse(users)
+		hasNext, hasPrev = true, more
+	}
+
+	userResponses, err := s.toUserResponses(ctx, users)
+	if err != nil {
+		return nil, err
+	}
+	response := &dto.ListUsersResponse{Users: userResponses, PageSize: pageSize}
+	if len(users) == 0 {
+		return response, nil
+	}
+	if hasNext {
+		last := users[len(users)-1]
+		if response.NextCursor, err = s.cursors.Encode(userCursor{CreatedAt: last.CreatedAt, ID: last.ID, Ascending: ascending}); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		first := users[0]
+		if response.PrevCursor, err = s.cursors.Encode(userCursor{CreatedAt: first.CreatedAt, ID: first.ID, Ascending: ascending, Backward: true}); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// cursorSortOrder reports whether a cursor listing sorted by sort runs oldest
+// first. Cursors are positions in creation order, so no other field is allowed.
+func cursorSortOrder(sort []repository.UserSort) (bool, error) {
+	switch {
+	case len(sort) == 0:
+		return false, nil
+	case len(sort) == 1 && sort[0].Field == "created_at":
+		return !sort[0].Desc, nil
+	default:
+		return false, errCursorSort
+	}
+}
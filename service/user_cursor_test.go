@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/dto"
+	"github.com/wabtcdi/user_service/models"
+	"github.com/wabtcdi/user_service/pagination"
+	"github.com/wabtcdi/user_service/repository"
+)
+
+// setupCursorDB returns a service backed by an in-memory SQLite database
+// holding seven users, two of which share a creation time, and their emails
+// newest first
+func setupCursorDB(t *testing.T, opts ...UserServiceOption) (*UserService, []string) {
+	t.Helper()
+
+	db, err := repository.OpenTestDB()
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.UserAuthentication{}, &models.AccessLevel{}, &models.UserAccessLevel{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	ctx := context.Background()
+	userRepo := repository.NewPostgresUserRepository(db)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var created []*models.User
+	for i, offset := range []int{0, 1, 2, 3, 3, 4, 5} {
+		user := &models.User{FirstName: "Cursor", LastName: "User", Email: fmt.Sprintf("user%d@example.com", i)}
+		if err := userRepo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		// Create stamps the current time
+		if err := db.Model(user).UpdateColumn("created_at", base.Add(time.Duration(offset)*time.Hour)).Error; err != nil {
+			t.Fatalf("Failed to set created_at: %v", err)
+		}
+		created = append(created, user)
+	}
+
+	users, err := userRepo.ListAfter(ctx, repository.UserFilter{}, nil, false, len(created))
+	if err != nil {
+		t.Fatalf("Failed to list users: %v", err)
+	}
+	var emails []string
+	for _, user := range users {
+		emails = append(emails, user.Email)
+	}
+	return NewUserService(userRepo, repository.NewPostgresAccessLevelRepository(db), opts...), emails
+}
+
+func emailsOf(resp *dto.ListUsersResponse) []string {
+	var emails []string
+	for _, user := range resp.Users {
+		emails = append(emails, user.Email)
+	}
+	return emails
+}
+
+func TestUserService_ListUsers_Cursor(t *testing.T) {
+	service, newestFirst := setupCursorDB(t)
+	ctx := context.Background()
+
+	// Walk forwards through every page, then back again from the last one
+	var pages []*dto.ListUsersResponse
+	query := &dto.ListUsersQuery{UseCursor: true, PageSize: 3}
+	for {
+		resp, err := service.ListUsers(ctx, query)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		pages = append(pages, resp)
+		if resp.NextCursor == "" {
+			break
+		}
+		query = &dto.ListUsersQuery{Cursor: resp.NextCursor, PageSize: 3}
+	}
+
+	want := [][]string{newestFirst[0:3], newestFirst[3:6], newestFirst[6:7]}
+	if len(pages) != len(want) {
+		t.Fatalf("Expected %d pages, got %d", len(want), len(pages))
+	}
+	for i, page := range pages {
+		if fmt.Sprint(emailsOf(page)) != fmt.Sprint(want[i]) {
+			t.Errorf("Page %d: expected %v, got %v", i, want[i], emailsOf(page))
+		}
+		if page.Total == nil || *page.Total != len(newestFirst) {
+			t.Errorf("Page %d: expected total %d, got %v", i, len(newestFirst), page.Total)
+		}
+		if page.Page != 0 {
+			t.Errorf("Page %d: expected no page number, got %d", i, page.Page)
+		}
+		if (page.PrevCursor == "") != (i == 0) {
+			t.Errorf("Page %d: unexpected prev_cursor %q", i, page.PrevCursor)
+		}
+	}
+
+	prevCursor := pages[len(pages)-1].PrevCursor
+	for i := len(pages) - 2; i >= 0; i-- {
+		resp, err := service.ListUsers(ctx, &dto.ListUsersQuery{Cursor: prevCursor, PageSize: 3})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if fmt.Sprint(emailsOf(resp)) != fmt.Sprint(want[i]) {
+			t.Errorf("Back to page %d: expected %v, got %v", i, want[i], emailsOf(resp))
+		}
+		if resp.NextCursor == "" {
+			t.Errorf("Back to page %d: expected a next_cursor", i)
+		}
+		if (resp.PrevCursor == "") != (i == 0) {
+			t.Errorf("Back to page %d: unexpected prev_cursor %q", i, resp.PrevCursor)
+		}
+		prevCursor = resp.PrevCursor
+	}
+}
+
+func TestUserService_ListUsers_CursorAscending(t *testing.T) {
+	service, newestFirst := setupCursorDB(t)
+	ctx := context.Background()
+
+	first, err := service.ListUsers(ctx, &dto.ListUsersQuery{UseCursor: true, PageSize: 4, Sort: "created_at", SkipTotal: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.Total != nil {
+		t.Errorf("Expected no total, got %d", *first.Total)
+	}
+	second, err := service.ListUsers(ctx, &dto.ListUsersQuery{Cursor: first.NextCursor, PageSize: 4, Sort: "created_at"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got := append(emailsOf(first), emailsOf(second)...)
+	for i, email := range got {
+		if want := newestFirst[len(newestFirst)-1-i]; email != want {
+			t.Errorf("User %d: expected %s, got %s", i, want, email)
+		}
+	}
+	if second.NextCursor != "" {
+		t.Errorf("Expected no next_cursor on the last page, got %q", second.NextCursor)
+	}
+
+	// A cursor only continues the sort order it was issued for
+	_, err = service.ListUsers(ctx, &dto.ListUsersQuery{Cursor: first.NextCursor, PageSize: 4})
+	if apperrors.Code(err) != "invalid_cursor" {
+		t.Errorf("Expected invalid_cursor, got %v", err)
+	}
+}
+
+func TestUserService_ListUsers_CursorSigning(t *testing.T) {
+	ctx := context.Background()
+	service, _ := setupCursorDB(t, WithCursorCodec(pagination.NewCodec([]byte("secret"))))
+	resp, err := service.ListUsers(ctx, &dto.ListUsersQuery{UseCursor: true, PageSize: 3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Another instance sharing the key accepts the cursor
+	shared, _ := setupCursorDB(t, WithCursorCodec(pagination.NewCodec([]byte("secret"))))
+	if _, err := shared.ListUsers(ctx, &dto.ListUsersQuery{Cursor: resp.NextCursor, PageSize: 3}); err != nil {
+		t.Errorf("Expected cursor to be accepted with the same key, got %v", err)
+	}
+
+	other, _ := setupCursorDB(t, WithCursorCodec(pagination.NewCodec([]byte("other"))))
+	_, err = other.ListUsers(ctx, &dto.ListUsersQuery{Cursor: resp.NextCursor, PageSize: 3})
+	if apperrors.Code(err) != "invalid_cursor" {
+		t.Errorf("Expected invalid_cursor with a different key, got %v", err)
+	}
+}
@@ -2,38 +2,265 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/auth"
 	"github.com/wabtcdi/user_service/dto"
 	"github.com/wabtcdi/user_service/models"
+	"github.com/wabtcdi/user_service/notify"
+	"github.com/wabtcdi/user_service/pagination"
+	"github.com/wabtcdi/user_service/password"
 	"github.com/wabtcdi/user_service/repository"
+	"github.com/wabtcdi/user_service/validation"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	defaultRefreshTokenTTL  = 30 * 24 * time.Hour
+	defaultPasswordResetTTL = time.Hour
+
+	// A single character would match nearly every user
+	minSearchQueryLength = 2
+	maxSearchQueryLength = 100
+	defaultSearchLimit   = 20
+)
+
+// errInvalidCredentials is returned for every failed login so callers cannot
+// tell an unknown email from a wrong password
+var errInvalidCredentials = apperrors.Unauthorized("invalid_credentials", "invalid email or password")
+
+// errInvalidResetToken is returned for unknown, expired and spent reset tokens alike
+var errInvalidResetToken = apperrors.Validation("invalid_reset_token", "password reset token is invalid or has expired")
+
 type UserService struct {
-	userRepo        repository.UserRepository
-	accessLevelRepo repository.AccessLevelRepository
+	userRepo         repository.UserRepository
+	accessLevelRepo  repository.AccessLevelRepository
+	tokenIssuer      TokenIssuer
+	refreshTokenRepo repository.RefreshTokenRepository
+	refreshTokenTTL  time.Duration
+	permissionRepo   repository.PermissionRepository
+	unitOfWork       repository.UnitOfWork
+	passwordReset    passwordResetConfig
+	passwordPolicy   password.Policy
+	lockout          lockoutConfig
+	mfa              mfaConfig
+	emailVerify      emailVerificationConfig
+	sessions         sessionConfig
+	cursors          *pagination.Codec
+}
+
+type passwordResetConfig struct {
+	repo     repository.PasswordResetTokenRepository
+	notifier notify.Notifier
+	url      string
+	ttl      time.Duration
+}
+
+type lockoutConfig struct {
+	enabled bool
+	policy  LockoutPolicy
+	audit   repository.AuditEventRepository
+}
+
+// UserServiceOption configures optional UserService dependencies
+type UserServiceOption func(*UserService)
+
+// WithTokenIssuer makes AuthenticateUser issue access tokens on successful login
+func WithTokenIssuer(issuer TokenIssuer) UserServiceOption {
+	return func(s *UserService) {
+		s.tokenIssuer = issuer
+	}
+}
+
+// WithRefreshTokens makes AuthenticateUser issue rotating refresh tokens
+func WithRefreshTokens(repo repository.RefreshTokenRepository, ttl time.Duration) UserServiceOption {
+	return func(s *UserService) {
+		if ttl <= 0 {
+			ttl = defaultRefreshTokenTTL
+		}
+		s.refreshTokenRepo = repo
+		s.refreshTokenTTL = ttl
+	}
+}
+
+// WithPermissions enables resolving a user's effective permissions
+func WithPermissions(repo repository.PermissionRepository) UserServiceOption {
+	return func(s *UserService) {
+		s.permissionRepo = repo
+	}
+}
+
+// WithPasswordReset enables the password reset flow. Reset links are sent
+// through notifier and point at resetURL with the token in its "token" query
+// parameter; an empty resetURL sends the bare token instead.
+func WithPasswordReset(repo repository.PasswordResetTokenRepository, notifier notify.Notifier, resetURL string, ttl time.Duration) UserServiceOption {
+	return func(s *UserService) {
+		if ttl <= 0 {
+			ttl = defaultPasswordResetTTL
+		}
+		s.passwordReset = passwordResetConfig{repo: repo, notifier: notifier, url: resetURL, ttl: ttl}
+	}
+}
+
+// WithPasswordPolicy replaces the default policy that new passwords are
+// checked against on creation, change and reset
+func WithPasswordPolicy(policy password.Policy) UserServiceOption {
+	return func(s *UserService) {
+		s.passwordPolicy = policy
+	}
+}
+
+// WithLockout tracks failed logins per account, delaying and then locking
+// accounts that keep failing as described by policy. Unset policy values fall
+// back to 5 attempts, a 15 minute lockout and a 1 second base delay. Lockouts
+// and unlocks are recorded in audit.
+func WithLockout(policy LockoutPolicy, audit repository.AuditEventRepository) UserServiceOption {
+	return func(s *UserService) {
+		if policy.MaxAttempts <= 0 {
+			policy.MaxAttempts = defaultLockoutMaxAttempts
+		}
+		if policy.Duration <= 0 {
+			policy.Duration = defaultLockoutDuration
+		}
+		if policy.BaseDelay <= 0 {
+			policy.BaseDelay = defaultLockoutBaseDelay
+		}
+		s.lockout = lockoutConfig{enabled: true, policy: policy, audit: audit}
+	}
+}
+
+// WithMFA enables TOTP multi-factor authentication. Users who turn it on log
+// in with their password and then complete an MFA challenge, which expires
+// after challengeTTL (5 minutes when unset). TOTP secrets are stored sealed
+// by secrets, and issuer names the service in authenticator apps.
+func WithMFA(repo repository.MFARepository, challenges repository.MFAChallengeRepository, secrets *auth.SecretBox, issuer string, challengeTTL time.Duration) UserServiceOption {
+	return func(s *UserService) {
+		if issuer == "" {
+			issuer = defaultMFAIssuer
+		}
+		if challengeTTL <= 0 {
+			challengeTTL = defaultMFAChallengeTTL
+		}
+		s.mfa.repo = repo
+		s.mfa.challenges = challenges
+		s.mfa.secrets = secrets
+		s.mfa.issuer = issuer
+		s.mfa.challengeTTL = challengeTTL
+	}
+}
+
+// WithRequiredMFA requires MFA of users holding any of the named access
+// levels, inherited ones included. Such users cannot turn MFA off, and a
+// password login without it makes them enroll before they get a session.
+func WithRequiredMFA(accessLevels ...string) UserServiceOption {
+	return func(s *UserService) {
+		s.mfa.requiredLevels = accessLevels
+	}
+}
+
+// WithEmailVerification sends new and changed email addresses a single-use
+// link through notifier, pointing at verifyURL like WithPasswordReset does
+// for reset links. Links expire after ttl (24 hours when unset). When
+// required is set, users cannot log in until their address is verified.
+func WithEmailVerification(repo repository.EmailVerificationTokenRepository, notifier notify.Notifier, verifyURL string, ttl time.Duration, required bool) UserServiceOption {
+	return func(s *UserService) {
+		if ttl <= 0 {
+			ttl = defaultEmailVerificationTTL
+		}
+		s.emailVerify = emailVerificationConfig{repo: repo, notifier: notifier, url: verifyURL, ttl: ttl, required: required}
+	}
+}
+
+// WithSessions records a session for every login that is checked on each
+// request and can be listed and revoked. Sessions end after idleTimeout
+// without use and absoluteTimeout after login; zero values use the defaults.
+func WithSessions(repo repository.SessionRepository, idleTimeout, absoluteTimeout time.Duration) UserServiceOption {
+	return func(s *UserService) {
+		if idleTimeout <= 0 {
+			idleTimeout = defaultSessionIdleTimeout
+		}
+		if absoluteTimeout <= 0 {
+			absoluteTimeout = defaultSessionAbsoluteTimeout
+		}
+		s.sessions = sessionConfig{repo: repo, idleTimeout: idleTimeout, absoluteTimeout: absoluteTimeout}
+	}
+}
+
+// WithCursorCodec signs user listing cursors with codec. Without it cursors
+// are signed with a random key and only valid in the current process.
+func WithCursorCodec(codec *pagination.Codec) UserServiceOption {
+	return func(s *UserService) {
+		s.cursors = codec
+	}
+}
+
+// WithUnitOfWork runs multi-step operations in a transaction. Without it they
+// run directly against the service's repositories.
+func WithUnitOfWork(uow repository.UnitOfWork) UserServiceOption {
+	return func(s *UserService) {
+		s.unitOfWork = uow
+	}
 }
 
-func NewUserService(userRepo repository.UserRepository, accessLevelRepo repository.AccessLevelRepository) *UserService {
-	return &UserService{
+func NewUserService(userRepo repository.UserRepository, accessLevelRepo repository.AccessLevelRepository, opts ...UserServiceOption) *UserService {
+	s := &UserService{
 		userRepo:        userRepo,
 		accessLevelRepo: accessLevelRepo,
+		passwordPolicy:  password.DefaultPolicy(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.cursors == nil {
+		s.cursors = pagination.NewRandomCodec()
+	}
+	if s.unitOfWork == nil {
+		s.unitOfWork = directUnitOfWork{repository.Repositories{
+			Users:               userRepo,
+			AccessLevels:        accessLevelRepo,
+			Permissions:         s.permissionRepo,
+			RefreshTokens:       s.refreshTokenRepo,
+			PasswordResetTokens: s.passwordReset.repo,
+			AuditEvents:         s.lockout.audit,
+			MFA:                 s.mfa.repo,
+			MFAChallenges:       s.mfa.challenges,
+			EmailVerifications:  s.emailVerify.repo,
+		}}
+	}
+	return s
+}
+
+// directUnitOfWork hands out the service's own repositories without starting
+// a transaction
+type directUnitOfWork struct {
+	repos repository.Repositories
+}
+
+func (u directUnitOfWork) Do(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	return fn(u.repos)
 }
 
 func (s *UserService) CreateUser(ctx context.Context, req *dto.CreateUserRequest) (*dto.UserResponse, error) {
 	// Validate input
-	if err := s.validateCreateUserRequest(req); err != nil {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+	if err := s.passwordPolicy.Check("password", req.Password, req.Email, req.FirstName, req.LastName); err != nil {
 		return nil, err
 	}
 
 	// Check if user already exists
 	existingUser, _ := s.userRepo.GetByEmail(ctx, req.Email)
 	if existingUser != nil {
-		return nil, fmt.Errorf("user with email %s already exists", req.Email)
+		return nil, apperrors.Conflict("email_taken", "user with email %s already exists", req.Email)
 	}
 
 	// Hash password
@@ -62,6 +289,7 @@ func (s *UserService) CreateUser(ctx context.Context, req *dto.CreateUserRequest
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	s.startEmailVerification(ctx, user)
 
 	return s.toUserResponse(ctx, user), nil
 }
@@ -89,11 +317,12 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, req *dto.Upd
 	if req.LastName != "" {
 		user.LastName = req.LastName
 	}
+	emailChanged := req.Email != "" && req.Email != user.Email
 	if req.Email != "" {
 		// Check if new email is already taken by another user
 		existingUser, _ := s.userRepo.GetByEmail(ctx, req.Email)
 		if existingUser != nil && existingUser.ID != id {
-			return nil, fmt.Errorf("email %s is already taken", req.Email)
+			return nil, apperrors.Conflict("email_taken", "email %s is already taken", req.Email)
 		}
 		user.Email = req.Email
 	}
@@ -101,20 +330,88 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, req *dto.Upd
 		user.PhoneNumber = &req.PhoneNumber
 	}
 
-	// Save updates
+	// Save updates; a new email address has to be verified again
+	if emailChanged {
+		user.EmailVerifiedAt = nil
+	}
 	err = s.userRepo.Update(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
+	if emailChanged {
+		s.startEmailVerification(ctx, user)
+	}
 
 	return s.toUserResponse(ctx, user), nil
 }
 
+// DeleteUser soft deletes a user and, in the same unit of work, ends
+// everything that would still let them in: their refresh tokens, sessions and
+// outstanding password reset and email verification links
 func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	return s.userRepo.Delete(ctx, id)
+	return s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Users.Delete(ctx, id); err != nil {
+			return err
+		}
+		if repos.RefreshTokens != nil {
+			if err := repos.RefreshTokens.RevokeAllForUser(ctx, id); err != nil {
+				return err
+			}
+		}
+		if repos.PasswordResetTokens != nil {
+			if err := repos.PasswordResetTokens.InvalidateForUser(ctx, id); err != nil {
+				return err
+			}
+		}
+		if repos.EmailVerifications != nil {
+			if err := repos.EmailVerifications.InvalidateForUser(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		// Sessions may live outside the database, so they are revoked last,
+		// once everything the transaction can undo has succeeded
+		if s.sessions.repo != nil {
+			if err := s.sessions.repo.RevokeAllForUser(ctx, id); err != nil {
+				return fmt.Errorf("failed to revoke sessions: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RestoreUser undoes the soft delete of a user, unless another user has taken
+// their email in the meantime
+func (s *UserService) RestoreUser(ctx context.Context, id uuid.UUID) (*dto.UserResponse, error) {
+	var user *models.User
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		var err error
+		if user, err = repos.Users.Restore(ctx, id); err != nil {
+			return err
+		}
+		return recordAuditEvent(ctx, repos, id, models.AuditEventUserRestored, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.toUserResponse(ctx, user), nil
+}
+
+// PurgeUser permanently removes a user and everything stored about them
+// except their audit events, and records the purge. It cannot be undone.
+func (s *UserService) PurgeUser(ctx context.Context, id uuid.UUID) error {
+	return s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Users.Purge(ctx, id); err != nil {
+			return err
+		}
+		return recordAuditEvent(ctx, repos, id, models.AuditEventUserPurged, "")
+	})
 }
 
-func (s *UserService) ListUsers(ctx context.Context, page, pageSize int) (*dto.ListUsersResponse, error) {
+// ListUsers returns a page of the users matching the query's filters, in the
+// order given by its sort fields or newest first
+func (s *UserService) ListUsers(ctx context.Context, query *dto.ListUsersQuery) (*dto.ListUsersResponse, error) {
+	page, pageSize := query.Page, query.PageSize
 	if page < 1 {
 		page = 1
 	}
@@ -122,74 +419,543 @@ func (s *UserService) ListUsers(ctx context.Context, page, pageSize int) (*dto.L
 		pageSize = 10
 	}
 
-	offset := (page - 1) * pageSize
-	users, total, err := s.userRepo.List(ctx, pageSize, offset)
+	if query.CreatedAfter != nil && query.CreatedBefore != nil && !query.CreatedAfter.Before(*query.CreatedBefore) {
+		return nil, apperrors.Validation("invalid_created_range", "created_after must be before created_before")
+	}
+	filter := repository.UserFilter{
+		EmailPrefix:    query.Email,
+		NamePrefix:     query.Name,
+		PhoneNumber:    query.Phone,
+		AccessLevel:    query.AccessLevel,
+		CreatedAfter:   query.CreatedAfter,
+		CreatedBefore:  query.CreatedBefore,
+		IncludeDeleted: query.IncludeDeleted,
+		OnlyDeleted:    query.OnlyDeleted,
+		Sort:           parseUserSort(query.Sort),
+	}
+
+	var response *dto.ListUsersResponse
+	if query.UseCursor || query.Cursor != "" {
+		var err error
+		if response, err = s.listUsersByCursor(ctx, filter, query.Cursor, pageSize); err != nil {
+			return nil, err
+		}
+	} else {
+		offset := (page - 1) * pageSize
+		users, err := s.userRepo.List(ctx, filter, pageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		userResponses, err := s.toUserResponses(ctx, users)
+		if err != nil {
+			return nil, err
+		}
+		response = &dto.ListUsersResponse{Users: userResponses, Page: page, PageSize: pageSize}
+	}
+
+	if !query.SkipTotal {
+		total, err := s.userRepo.Count(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		response.Total = &total
+	}
+	return response, nil
+}
+
+// SearchUsers returns up to limit users whose name, email or phone number
+// resembles query, most relevant first
+func (s *UserService) SearchUsers(ctx context.Context, query string, limit int) (*dto.SearchUsersResponse, error) {
+	query = strings.TrimSpace(query)
+	if n := utf8.RuneCountInString(query); n < minSearchQueryLength || n > maxSearchQueryLength {
+		return nil, apperrors.Validation("invalid_search_query", "search query must be between %d and %d characters",
+			minSearchQueryLength, maxSearchQueryLength)
+	}
+	if limit < 1 || limit > 100 {
+		limit = defaultSearchLimit
+	}
+
+	users, err := s.userRepo.Search(ctx, query, limit)
 	if err != nil {
 		return nil, err
 	}
-
-	userResponses := make([]dto.UserResponse, 0, len(users))
-	for _, user := range users {
-		userResponses = append(userResponses, *s.toUserResponse(ctx, user))
+	userResponses, err := s.toUserResponses(ctx, users)
+	if err != nil {
+		return nil, err
 	}
+	return &dto.SearchUsersResponse{Users: userResponses}, nil
+}
 
-	return &dto.ListUsersResponse{
-		Users:    userResponses,
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
-	}, nil
+// parseUserSort splits a sort parameter such as "last_name,-created_at" into
+// its fields; the repository rejects fields users cannot be sorted by
+func parseUserSort(sort string) []repository.UserSort {
+	if sort == "" {
+		return nil
+	}
+	var fields []repository.UserSort
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		fields = append(fields, repository.UserSort{Field: strings.TrimPrefix(field, "-"), Desc: desc})
+	}
+	return fields
 }
 
 func (s *UserService) AuthenticateUser(ctx context.Context, req *dto.LoginRequest) (*dto.LoginResponse, error) {
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, fmt.Errorf("invalid email or password")
+		return nil, errInvalidCredentials
 	}
 
 	// Get authentication
-	auth, err := s.userRepo.GetUserAuthentication(ctx, user.ID)
+	userAuth, err := s.userRepo.GetUserAuthentication(ctx, user.ID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid email or password")
+		return nil, errInvalidCredentials
+	}
+
+	if s.lockout.enabled {
+		if err := s.checkLockout(userAuth); err != nil {
+			return nil, err
+		}
 	}
 
 	// Compare passwords
-	err = bcrypt.CompareHashAndPassword([]byte(auth.PasswordHash), []byte(req.Password))
+	err = bcrypt.CompareHashAndPassword([]byte(userAuth.PasswordHash), []byte(req.Password))
 	if err != nil {
-		return nil, fmt.Errorf("invalid email or password")
+		if s.lockout.enabled {
+			if err := s.recordFailedLogin(ctx, user.ID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, errInvalidCredentials
+	}
+
+	// Checked only after the password so the response does not reveal
+	// whether an address is registered
+	if s.emailVerify.required && user.EmailVerifiedAt == nil {
+		return nil, errEmailNotVerified
 	}
 
-	return &dto.LoginResponse{
-		User:    *s.toUserResponse(ctx, user),
+	// Failed logins are only cleared once every factor has been verified, so
+	// the password cannot be used to reset the count while guessing MFA codes
+	if s.mfa.challenges != nil {
+		if userAuth.MFAEnabledAt != nil {
+			return s.startMFAChallenge(ctx, user.ID)
+		}
+		required, err := s.mfaRequired(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if required {
+			return s.startMFAEnrollment(ctx, user)
+		}
+	}
+
+	if s.lockout.enabled && userAuth.FailedLoginCount > 0 {
+		if err := s.userRepo.ResetFailedLogins(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.completeLogin(ctx, user)
+}
+
+// completeLogin returns the user and, when token issuing is enabled, a new
+// session for a user who has passed every authentication step
+func (s *UserService) completeLogin(ctx context.Context, user *models.User) (*dto.LoginResponse, error) {
+	response := &dto.LoginResponse{
+		User:    s.toUserResponse(ctx, user),
 		Message: "Login successful",
-	}, nil
+	}
+
+	if s.tokenIssuer != nil {
+		accessLevels, err := s.effectiveAccessLevelNames(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// The session shares its ID with the refresh token family of the login
+		sessionID := uuid.New()
+		if s.sessions.repo != nil {
+			if err := s.createSession(ctx, user.ID, sessionID); err != nil {
+				return nil, err
+			}
+			response.SessionID = sessionID.String()
+		}
+
+		tokens, err := s.issueTokens(ctx, user.ID, accessLevels, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		response.TokenResponse = *tokens
+	}
+
+	return response, nil
 }
 
-func (s *UserService) AssignAccessLevels(ctx context.Context, userID uuid.UUID, req *dto.AssignAccessLevelRequest) error {
-	// Verify user exists
-	_, err := s.userRepo.GetByID(ctx, userID)
+// RefreshTokens exchanges a refresh token for a new access token and a rotated
+// refresh token. Presenting a token that was already rotated or revoked revokes
+// every token issued from the same login.
+func (s *UserService) RefreshTokens(ctx context.Context, req *dto.RefreshTokenRequest) (*dto.TokenResponse, error) {
+	if s.tokenIssuer == nil || s.refreshTokenRepo == nil {
+		return nil, fmt.Errorf("refresh tokens are not enabled")
+	}
+
+	current, err := s.refreshTokenRepo.GetByHash(ctx, auth.HashOpaqueToken(req.RefreshToken))
+	if err != nil {
+		return nil, apperrors.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	}
+
+	if current.RevokedAt != nil {
+		return nil, s.revokeRefreshTokenFamily(ctx, current.FamilyID)
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return nil, apperrors.Unauthorized("refresh_token_expired", "refresh token expired")
+	}
+
+	if _, err := s.userRepo.GetByID(ctx, current.UserID); err != nil {
+		return nil, apperrors.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	}
+
+	// Tokens of a revoked or expired session cannot be refreshed
+	var sessionID string
+	if s.sessions.repo != nil {
+		session, err := s.activeSession(ctx, current.UserID, current.FamilyID)
+		if err != nil {
+			return nil, err
+		}
+		sessionID = session.ID.String()
+	}
+
+	names, err := s.effectiveAccessLevelNames(ctx, current.UserID)
 	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.issueAccessToken(current.UserID, names, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, next, err := s.newRefreshToken(current.UserID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.refreshTokenRepo.Rotate(ctx, current, next)
+	if errors.Is(err, repository.ErrRefreshTokenRevoked) {
+		return nil, s.revokeRefreshTokenFamily(ctx, current.FamilyID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	tokens.RefreshToken = refreshToken
+
+	return tokens, nil
+}
+
+// ChangePassword replaces the user's password once the current one has been
+// confirmed. The new password must differ from the current one and satisfy
+// the password policy.
+func (s *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, req *dto.ChangePasswordRequest) error {
+	if err := validation.Struct(req); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	userAuth, err := s.userRepo.GetUserAuthentication(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// The current password is guessed under the same limits as a login
+	if s.lockout.enabled {
+		if err := s.checkLockout(userAuth); err != nil {
+			return err
+		}
+	}
+	if bcrypt.CompareHashAndPassword([]byte(userAuth.PasswordHash), []byte(req.CurrentPassword)) != nil {
+		if s.lockout.enabled {
+			if err := s.recordFailedLogin(ctx, userID); err != nil {
+				return err
+			}
+		}
+		return apperrors.Validation("invalid_current_password", "current password is incorrect")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(userAuth.PasswordHash), []byte(req.NewPassword)) == nil {
+		return apperrors.Validation("password_reused", "new password must differ from the current password")
+	}
+	if err := s.checkNewPassword(ctx, "new_password", req.NewPassword, user, userAuth); err != nil {
 		return err
 	}
 
-	// Assign each access level
-	for _, accessLevelID := range req.AccessLevelIDs {
-		// Verify access level exists
-		_, err := s.accessLevelRepo.GetByID(ctx, accessLevelID)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// As after a reset, every other session and refresh token of the user
+	// ends. A user changing their own password keeps the session they used.
+	keep := s.callerSessionID(ctx, userID)
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Users.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+			return fmt.Errorf("failed to change password: %w", err)
+		}
+		if s.lockout.enabled && userAuth.FailedLoginCount > 0 {
+			if err := repos.Users.ResetFailedLogins(ctx, userID); err != nil {
+				return err
+			}
+		}
+		if repos.RefreshTokens == nil {
+			return nil
+		}
+		if keep == uuid.Nil {
+			return repos.RefreshTokens.RevokeAllForUser(ctx, userID)
+		}
+		return repos.RefreshTokens.RevokeOtherFamilies(ctx, userID, keep)
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.sessions.repo != nil {
+		if keep == uuid.Nil {
+			err = s.sessions.repo.RevokeAllForUser(ctx, userID)
+		} else {
+			err = s.sessions.repo.RevokeOthersForUser(ctx, userID, keep)
+		}
 		if err != nil {
-			return fmt.Errorf("access level %d not found", accessLevelID)
+			return fmt.Errorf("failed to revoke sessions: %w", err)
 		}
+	}
+	return nil
+}
+
+// checkNewPassword applies the password policy to a password replacing the
+// one in userAuth, including the ban on repeating recent passwords
+func (s *UserService) checkNewPassword(ctx context.Context, field, newPassword string, user *models.User, userAuth *models.UserAuthentication) error {
+	if err := s.passwordPolicy.Check(field, newPassword, user.Email, user.FirstName, user.LastName); err != nil {
+		return err
+	}
+
+	historySize := s.passwordPolicy.HistorySize
+	if historySize <= 0 {
+		return nil
+	}
+	recent := []string{userAuth.PasswordHash}
+	if historySize > 1 {
+		history, err := s.userRepo.GetPasswordHistory(ctx, user.ID, historySize-1)
+		if err != nil {
+			return err
+		}
+		for _, h := range history {
+			recent = append(recent, h.PasswordHash)
+		}
+	}
+	for _, hash := range recent {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(newPassword)) == nil {
+			return apperrors.Validation("password_reused", "new password must not match any of the last %d passwords", historySize)
+		}
+	}
+	return nil
+}
+
+// RequestPasswordReset sends a single-use reset link to the account with the
+// given email. Unknown emails are ignored so callers cannot probe for accounts.
+func (s *UserService) RequestPasswordReset(ctx context.Context, req *dto.PasswordResetRequest) error {
+	if s.passwordReset.repo == nil {
+		return fmt.Errorf("password reset is not enabled")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Whoever changed the email could otherwise take the account over with
+	// the link, so it only goes to addresses their owner has confirmed
+	if s.emailVerify.repo != nil && user.EmailVerifiedAt == nil {
+		logrus.Infof("Not sending a password reset link to the unverified email of user %s", user.ID)
+		return nil
+	}
+
+	token, tokenHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+	link, err := tokenLink(s.passwordReset.url, token)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(s.passwordReset.ttl)
+	err = s.passwordReset.repo.Create(ctx, &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+
+	err = s.passwordReset.notifier.Notify(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use this link to choose a new password. It can be used once and expires at %s.\n\n%s",
+			expiresAt.UTC().Format(time.RFC1123), link),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send password reset link: %w", err)
+	}
+	return nil
+}
+
+// ConfirmPasswordReset spends a reset token and sets the user's new password.
+// Every other outstanding reset token, refresh token and session of the user
+// is revoked with it, so sessions opened with the old password end.
+func (s *UserService) ConfirmPasswordReset(ctx context.Context, req *dto.PasswordResetConfirmRequest) error {
+	if s.passwordReset.repo == nil {
+		return fmt.Errorf("password reset is not enabled")
+	}
+
+	token, err := s.passwordReset.repo.GetByHash(ctx, auth.HashOpaqueToken(req.Token))
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return errInvalidResetToken
+	}
 
-		err = s.accessLevelRepo.AssignToUser(ctx, userID, accessLevelID)
+	user, err := s.userRepo.GetByID(ctx, token.UserID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return errInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	userAuth, err := s.userRepo.GetUserAuthentication(ctx, token.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkNewPassword(ctx, "new_password", req.NewPassword, user, userAuth); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		err := repos.PasswordResetTokens.MarkUsed(ctx, token.ID)
+		if errors.Is(err, repository.ErrPasswordResetTokenUsed) {
+			return errInvalidResetToken
+		}
 		if err != nil {
-			return fmt.Errorf("failed to assign access level %d: %w", accessLevelID, err)
+			return err
 		}
+
+		if err := repos.Users.UpdatePassword(ctx, token.UserID, string(hashedPassword)); err != nil {
+			return err
+		}
+		if err := repos.PasswordResetTokens.InvalidateForUser(ctx, token.UserID); err != nil {
+			return err
+		}
+		if repos.RefreshTokens != nil {
+			if err := repos.RefreshTokens.RevokeAllForUser(ctx, token.UserID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	// Sessions may live outside the database, so they are revoked once the
+	// new password is saved
+	if s.sessions.repo != nil {
+		if err := s.sessions.repo.RevokeAllForUser(ctx, token.UserID); err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+	}
 	return nil
 }
 
+// tokenLink returns baseURL with token in its "token" query parameter, or the
+// bare token when no URL is configured
+func tokenLink(baseURL, token string) (string, error) {
+	if baseURL == "" {
+		return token, nil
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid link URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// AssignAccessLevels adds the requested access levels to the user. Either all
+// of them are assigned or, if any ID is unknown, none are.
+func (s *UserService) AssignAccessLevels(ctx context.Context, userID uuid.UUID, req *dto.AssignAccessLevelRequest) error {
+	accessLevelIDs := uniqueIDs(req.AccessLevelIDs)
+
+	return s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		// Verify user exists
+		if _, err := repos.Users.GetByID(ctx, userID); err != nil {
+			return err
+		}
+
+		if err := checkAccessLevelsExist(ctx, repos.AccessLevels, accessLevelIDs); err != nil {
+			return err
+		}
+
+		for _, accessLevelID := range accessLevelIDs {
+			if err := repos.AccessLevels.AssignToUser(ctx, userID, accessLevelID); err != nil {
+				return fmt.Errorf("failed to assign access level %d: %w", accessLevelID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ReplaceAccessLevels makes the requested access levels the user's complete set
+// of directly assigned levels and returns the result
+func (s *UserService) ReplaceAccessLevels(ctx context.Context, userID uuid.UUID, req *dto.ReplaceAccessLevelsRequest) ([]dto.AccessLevelResponse, error) {
+	accessLevelIDs := uniqueIDs(req.AccessLevelIDs)
+
+	err := s.unitOfWork.Do(ctx, func(repos repository.Repositories) error {
+		// Verify user exists
+		if _, err := repos.Users.GetByID(ctx, userID); err != nil {
+			return err
+		}
+
+		if err := checkAccessLevelsExist(ctx, repos.AccessLevels, accessLevelIDs); err != nil {
+			return err
+		}
+
+		if err := repos.AccessLevels.ReplaceUserAccessLevels(ctx, userID, accessLevelIDs); err != nil {
+			return fmt.Errorf("failed to replace access levels: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetUserAccessLevels(ctx, userID)
+}
+
 func (s *UserService) RemoveAccessLevel(ctx context.Context, userID uuid.UUID, accessLevelID int) error {
 	return s.accessLevelRepo.RemoveFromUser(ctx, userID, accessLevelID)
 }
@@ -200,39 +966,201 @@ func (s *UserService) GetUserAccessLevels(ctx context.Context, userID uuid.UUID)
 		return nil, err
 	}
 
-	responses := make([]dto.AccessLevelResponse, 0, len(accessLevels))
+	return toAccessLevelResponses(accessLevels), nil
+}
+
+// GetUserEffectiveAccessLevels returns the access levels assigned to the user
+// alongside the expanded set that includes every inherited level
+func (s *UserService) GetUserEffectiveAccessLevels(ctx context.Context, userID uuid.UUID) (*dto.UserAccessLevelsResponse, error) {
+	direct, err := s.accessLevelRepo.GetUserAccessLevels(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	effective, err := s.accessLevelRepo.GetEffectiveUserAccessLevels(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.UserAccessLevelsResponse{
+		Direct:    toAccessLevelResponses(direct),
+		Effective: toAccessLevelResponses(effective),
+	}, nil
+}
+
+// GetUserPermissions returns the names of every permission granted to the user
+// through their access levels, including inherited ones
+func (s *UserService) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	if s.permissionRepo == nil {
+		return nil, fmt.Errorf("permissions are not enabled")
+	}
+
+	accessLevels, err := s.accessLevelRepo.GetEffectiveUserAccessLevels(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(accessLevels))
 	for _, al := range accessLevels {
-		desc := ""
-		if al.Description != nil {
-			desc = *al.Description
+		ids = append(ids, al.ID)
+	}
+
+	permissions, err := s.permissionRepo.GetByAccessLevels(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		names = append(names, p.Name)
+	}
+	return names, nil
+}
+
+// effectiveAccessLevelNames returns the names of every access level the user
+// holds, including inherited ones, for embedding in access tokens
+func (s *UserService) effectiveAccessLevelNames(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	accessLevels, err := s.accessLevelRepo.GetEffectiveUserAccessLevels(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(accessLevels))
+	for _, al := range accessLevels {
+		names = append(names, al.Name)
+	}
+	return names, nil
+}
+
+func (s *UserService) issueAccessToken(userID uuid.UUID, accessLevels []string, sessionID string) (*dto.TokenResponse, error) {
+	accessToken, expiresIn, err := s.tokenIssuer.IssueAccessToken(userID, accessLevels, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue access token: %w", err)
+	}
+
+	return &dto.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   auth.TokenType,
+		ExpiresIn:   int(expiresIn.Seconds()),
+	}, nil
+}
+
+// issueTokens issues an access token and, when refresh tokens are enabled, a
+// refresh token in familyID. The access token names familyID as its session
+// when sessions are enabled.
+func (s *UserService) issueTokens(ctx context.Context, userID uuid.UUID, accessLevels []string, familyID uuid.UUID) (*dto.TokenResponse, error) {
+	var sessionID string
+	if s.sessions.repo != nil {
+		sessionID = familyID.String()
+	}
+	tokens, err := s.issueAccessToken(userID, accessLevels, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.refreshTokenRepo != nil {
+		refreshToken, stored, err := s.newRefreshToken(userID, familyID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.refreshTokenRepo.Create(ctx, stored); err != nil {
+			return nil, fmt.Errorf("failed to store refresh token: %w", err)
 		}
-		responses = append(responses, dto.AccessLevelResponse{
-			ID:          al.ID,
-			Name:        al.Name,
-			Description: desc,
-		})
+		tokens.RefreshToken = refreshToken
 	}
 
-	return responses, nil
+	return tokens, nil
+}
+
+func (s *UserService) newRefreshToken(userID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	token, tokenHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return token, &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	}, nil
+}
+
+func (s *UserService) revokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, familyID); err != nil {
+		return fmt.Errorf("refresh token reuse detected: %w", err)
+	}
+	return apperrors.Unauthorized("refresh_token_reused", "refresh token reuse detected")
+}
+
+// checkAccessLevelsExist reports every ID in ids that does not name an access level
+func checkAccessLevelsExist(ctx context.Context, repo repository.AccessLevelRepository, ids []int) error {
+	accessLevels, err := repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[int]bool, len(accessLevels))
+	for _, al := range accessLevels {
+		found[al.ID] = true
+	}
+	var missing []int
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return apperrors.Validation("access_levels_not_found", "access levels not found: %s", formatIDList(missing))
+	}
+	return nil
 }
 
 func (s *UserService) toUserResponse(ctx context.Context, user *models.User) *dto.UserResponse {
+	// The access levels are best effort; the user is returned without them
+	// when they cannot be loaded
+	accessLevels, _ := s.accessLevelRepo.GetUserAccessLevels(ctx, user.ID)
+	return newUserResponse(user, accessLevels)
+}
+
+// toUserResponses converts a page of users, loading the access levels of all
+// of them with a single query
+func (s *UserService) toUserResponses(ctx context.Context, users []*models.User) ([]dto.UserResponse, error) {
+	responses := make([]dto.UserResponse, 0, len(users))
+	if len(users) == 0 {
+		return responses, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	accessLevels, err := s.accessLevelRepo.GetUsersAccessLevels(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		responses = append(responses, *newUserResponse(user, accessLevels[user.ID]))
+	}
+	return responses, nil
+}
+
+func newUserResponse(user *models.User, accessLevels []*models.AccessLevel) *dto.UserResponse {
 	response := &dto.UserResponse{
-		ID:        user.ID,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:              user.ID,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	}
 
 	if user.PhoneNumber != nil {
 		response.PhoneNumber = *user.PhoneNumber
 	}
+	if user.DeletedAt.Valid {
+		response.DeletedAt = &user.DeletedAt.Time
+	}
 
-	// Get access levels
-	accessLevels, err := s.accessLevelRepo.GetUserAccessLevels(ctx, user.ID)
-	if err == nil && len(accessLevels) > 0 {
+	if len(accessLevels) > 0 {
 		response.AccessLevels = make([]dto.AccessLevelResponse, 0, len(accessLevels))
 		for _, al := range accessLevels {
 			desc := ""
@@ -249,22 +1177,3 @@ func (s *UserService) toUserResponse(ctx context.Context, user *models.User) *dt
 
 	return response
 }
-
-func (s *UserService) validateCreateUserRequest(req *dto.CreateUserRequest) error {
-	if strings.TrimSpace(req.FirstName) == "" {
-		return fmt.Errorf("first name is required")
-	}
-	if strings.TrimSpace(req.LastName) == "" {
-		return fmt.Errorf("last name is required")
-	}
-	if strings.TrimSpace(req.Email) == "" {
-		return fmt.Errorf("email is required")
-	}
-	if !strings.Contains(req.Email, "@") {
-		return fmt.Errorf("invalid email format")
-	}
-	if len(req.Password) < 8 {
-		return fmt.Errorf("password must be at least 8 characters")
-	}
-	return nil
-}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wabtcdi/user_service/dto"
+	"github.com/wabtcdi/user_service/models"
+	"github.com/wabtcdi/user_service/repository"
+	"gorm.io/gorm"
+)
+
+// queryCounter counts the statements a GORM database runs
+type queryCounter struct {
+	n atomic.Int64
+}
+
+func (c *queryCounter) register(db *gorm.DB) error {
+	count := func(*gorm.DB) { c.n.Add(1) }
+	if err := db.Callback().Query().After("gorm:query").Register("test:count_queries", count); err != nil {
+		return err
+	}
+	return db.Callback().Row().After("gorm:row").Register("test:count_rows", count)
+}
+
+// setupListUsersDB returns a service backed by an in-memory SQLite database
+// holding users users with two access levels each, and a counter of the
+// queries run against it
+func setupListUsersDB(tb testing.TB, users int) (*UserService, *queryCounter) {
+	tb.Helper()
+
+	db, err := repository.OpenTestDB()
+	if err != nil {
+		tb.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.UserAuthentication{}, &models.AccessLevel{}, &models.UserAccessLevel{}); err != nil {
+		tb.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	ctx := context.Background()
+	userRepo := repository.NewPostgresUserRepository(db)
+	accessLevelRepo := repository.NewPostgresAccessLevelRepository(db)
+
+	var levels []*models.AccessLevel
+	for _, name := range []string{"admin", "viewer"} {
+		level := &models.AccessLevel{Name: name}
+		if err := accessLevelRepo.Create(ctx, level); err != nil {
+			tb.Fatalf("Failed to create access level: %v", err)
+		}
+		levels = append(levels, level)
+	}
+	for i := 0; i < users; i++ {
+		user := &models.User{FirstName: "Bench", LastName: "User", Email: fmt.Sprintf("user%d@example.com", i)}
+		if err := userRepo.Create(ctx, user, &models.UserAuthentication{PasswordHash: "hashedpassword"}); err != nil {
+			tb.Fatalf("Failed to create user: %v", err)
+		}
+		for _, level := range levels {
+			if err := accessLevelRepo.AssignToUser(ctx, user.ID, level.ID); err != nil {
+				tb.Fatalf("Failed to assign access level: %v", err)
+			}
+		}
+	}
+
+	counter := &queryCounter{}
+	if err := counter.register(db); err != nil {
+		tb.Fatalf("Failed to register query counter: %v", err)
+	}
+	return NewUserService(userRepo, accessLevelRepo), counter
+}
+
+func TestUserService_ListUsers_QueryCount(t *testing.T) {
+	service, counter := setupListUsersDB(t, 100)
+	ctx := context.Background()
+
+	queries := make(map[int]int64)
+	for _, pageSize := range []int{1, 10, 100} {
+		counter.n.Store(0)
+		resp, err := service.ListUsers(ctx, &dto.ListUsersQuery{Page: 1, PageSize: pageSize})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp.Users) != pageSize {
+			t.Fatalf("Expected %d users, got %d", pageSize, len(resp.Users))
+		}
+		for _, user := range resp.Users {
+			if len(user.AccessLevels) != 2 {
+				t.Fatalf("Expected 2 access levels for user %s, got %d", user.ID, len(user.AccessLevels))
+			}
+		}
+		queries[pageSize] = counter.n.Load()
+	}
+
+	// Counting, listing and loading the access levels
+	for pageSize, n := range queries {
+		if n != 3 {
+			t.Errorf("Expected 3 queries for a page of %d users, got %d", pageSize, n)
+		}
+	}
+}
+
+func BenchmarkUserService_ListUsers(b *testing.B) {
+	service, counter := setupListUsersDB(b, 100)
+	ctx := context.Background()
+
+	for _, pageSize := range []int{10, 100} {
+		b.Run(fmt.Sprintf("PageSize%d", pageSize), func(b *testing.B) {
+			counter.n.Store(0)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := service.ListUsers(ctx, &dto.ListUsersQuery{Page: 1, PageSize: pageSize}); err != nil {
+					b.Fatalf("Failed to list users: %v", err)
+				}
+			}
+			b.ReportMetric(float64(counter.n.Load())/float64(b.N), "queries/op")
+		})
+	}
+}
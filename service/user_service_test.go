@@ -3,14 +3,21 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/auth"
 	"github.com/wabtcdi/user_service/dto"
 	"github.com/wabtcdi/user_service/mocks"
 	"github.com/wabtcdi/user_service/models"
+	"github.com/wabtcdi/user_service/notify"
+	"github.com/wabtcdi/user_service/password"
+	"github.com/wabtcdi/user_service/repository"
+	"github.com/wabtcdi/user_service/totp"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -83,6 +90,9 @@ func TestUserService_CreateUser(t *testing.T) {
 		if err == nil {
 			t.Fatal("Expected error for duplicate user, got nil")
 		}
+		if !errors.Is(err, apperrors.ErrConflict) || apperrors.Code(err) != "email_taken" {
+			t.Errorf("Expected email_taken conflict, got %v", err)
+		}
 	})
 
 	t.Run("ValidationError_EmptyFirstName", func(t *testing.T) {
@@ -327,6 +337,143 @@ func TestUserService_DeleteUser(t *testing.T) {
 	})
 }
 
+func TestUserService_DeleteUser_EndsAccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetTokenRepository(ctrl)
+	mockVerifyRepo := mocks.NewMockEmailVerificationTokenRepository(ctrl)
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	service := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl),
+		WithRefreshTokens(mockRefreshRepo, 0),
+		WithPasswordReset(mockResetRepo, mocks.NewMockNotifier(ctrl), "", 0),
+		WithEmailVerification(mockVerifyRepo, mocks.NewMockNotifier(ctrl), "", 0, false),
+		WithSessions(mockSessionRepo, 0, 0),
+	)
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		userID := uuid.New()
+		gomock.InOrder(
+			mockUserRepo.EXPECT().Delete(ctx, userID).Return(nil),
+			mockRefreshRepo.EXPECT().RevokeAllForUser(ctx, userID).Return(nil),
+			mockResetRepo.EXPECT().InvalidateForUser(ctx, userID).Return(nil),
+			mockVerifyRepo.EXPECT().InvalidateForUser(ctx, userID).Return(nil),
+			mockSessionRepo.EXPECT().RevokeAllForUser(ctx, userID).Return(nil),
+		)
+
+		if err := service.DeleteUser(ctx, userID); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		userID := uuid.New()
+		mockUserRepo.EXPECT().Delete(ctx, userID).Return(apperrors.NotFound("user_not_found", "user not found"))
+
+		if err := service.DeleteUser(ctx, userID); !errors.Is(err, apperrors.ErrNotFound) {
+			t.Fatalf("Expected not found, got %v", err)
+		}
+	})
+
+	t.Run("Revocation Fails", func(t *testing.T) {
+		userID := uuid.New()
+		mockUserRepo.EXPECT().Delete(ctx, userID).Return(nil)
+		mockRefreshRepo.EXPECT().RevokeAllForUser(ctx, userID).Return(errors.New("database unavailable"))
+
+		if err := service.DeleteUser(ctx, userID); err == nil {
+			t.Fatal("Expected the delete to fail with its revocations")
+		}
+	})
+}
+
+func TestUserService_RestoreUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockAuditRepo := mocks.NewMockAuditEventRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo, WithLockout(LockoutPolicy{}, mockAuditRepo))
+	adminID := uuid.New()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: adminID})
+
+	t.Run("Success", func(t *testing.T) {
+		userID := uuid.New()
+		mockUserRepo.EXPECT().Restore(ctx, userID).Return(&models.User{ID: userID, Email: "john@example.com"}, nil)
+		mockAuditRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, event *models.AuditEvent) error {
+				if event.UserID != userID || event.Event != models.AuditEventUserRestored {
+					t.Errorf("Unexpected audit event %+v", event)
+				}
+				if event.ActorID == nil || *event.ActorID != adminID {
+					t.Errorf("Expected the restoring admin as actor, got %v", event.ActorID)
+				}
+				return nil
+			})
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).Return(nil, nil)
+
+		resp, err := service.RestoreUser(ctx, userID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.ID != userID || resp.DeletedAt != nil {
+			t.Errorf("Expected restored user %s, got %+v", userID, resp)
+		}
+	})
+
+	t.Run("EmailTaken", func(t *testing.T) {
+		userID := uuid.New()
+		mockUserRepo.EXPECT().Restore(ctx, userID).
+			Return(nil, apperrors.Conflict("email_taken", "email john@example.com is already taken"))
+
+		if _, err := service.RestoreUser(ctx, userID); apperrors.Code(err) != "email_taken" {
+			t.Errorf("Expected email_taken, got %v", err)
+		}
+	})
+}
+
+func TestUserService_PurgeUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAuditRepo := mocks.NewMockAuditEventRepository(ctrl)
+	service := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl), WithLockout(LockoutPolicy{}, mockAuditRepo))
+	adminID := uuid.New()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: adminID})
+
+	t.Run("Success", func(t *testing.T) {
+		userID := uuid.New()
+		mockUserRepo.EXPECT().Purge(ctx, userID).Return(nil)
+		mockAuditRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, event *models.AuditEvent) error {
+				if event.UserID != userID || event.Event != models.AuditEventUserPurged {
+					t.Errorf("Unexpected audit event %+v", event)
+				}
+				if event.ActorID == nil || *event.ActorID != adminID {
+					t.Errorf("Expected the purging admin as actor, got %v", event.ActorID)
+				}
+				return nil
+			})
+
+		if err := service.PurgeUser(ctx, userID); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		userID := uuid.New()
+		mockUserRepo.EXPECT().Purge(ctx, userID).Return(apperrors.NotFound("user_not_found", "user not found"))
+
+		if err := service.PurgeUser(ctx, userID); !errors.Is(err, apperrors.ErrNotFound) {
+			t.Errorf("Expected not found, got %v", err)
+		}
+	})
+}
+
 func TestUserService_ListUsers(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -353,18 +500,17 @@ func TestUserService_ListUsers(t *testing.T) {
 		}
 
 		mockUserRepo.EXPECT().
-			List(ctx, 10, 0).
-			Return(users, 2, nil)
-
-		mockAccessLevelRepo.EXPECT().
-			GetUserAccessLevels(ctx, users[0].ID).
-			Return([]*models.AccessLevel{}, nil)
+			List(ctx, repository.UserFilter{}, 10, 0).
+			Return(users, nil)
+		mockUserRepo.EXPECT().Count(ctx, repository.UserFilter{}).Return(2, nil)
 
 		mockAccessLevelRepo.EXPECT().
-			GetUserAccessLevels(ctx, users[1].ID).
-			Return([]*models.AccessLevel{}, nil)
+			GetUsersAccessLevels(ctx, []uuid.UUID{users[0].ID, users[1].ID}).
+			Return(map[uuid.UUID][]*models.AccessLevel{
+				users[1].ID: {{ID: 1, Name: "admin"}},
+			}, nil)
 
-		resp, err := service.ListUsers(ctx, 1, 10)
+		resp, err := service.ListUsers(ctx, &dto.ListUsersQuery{Page: 1, PageSize: 10})
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -372,17 +518,34 @@ func TestUserService_ListUsers(t *testing.T) {
 		if len(resp.Users) != 2 {
 			t.Errorf("Expected 2 users, got %d", len(resp.Users))
 		}
-		if resp.Total != 2 {
-			t.Errorf("Expected total 2, got %d", resp.Total)
+		if resp.Total == nil || *resp.Total != 2 {
+			t.Errorf("Expected total 2, got %v", resp.Total)
+		}
+		if len(resp.Users[0].AccessLevels) != 0 {
+			t.Errorf("Expected no access levels for the first user, got %v", resp.Users[0].AccessLevels)
+		}
+		if len(resp.Users[1].AccessLevels) != 1 || resp.Users[1].AccessLevels[0].Name != "admin" {
+			t.Errorf("Expected admin for the second user, got %v", resp.Users[1].AccessLevels)
+		}
+	})
+
+	t.Run("AccessLevelsError", func(t *testing.T) {
+		users := []*models.User{{ID: uuid.New(), Email: "john@example.com"}}
+		mockUserRepo.EXPECT().List(ctx, repository.UserFilter{}, 10, 0).Return(users, nil)
+		mockAccessLevelRepo.EXPECT().GetUsersAccessLevels(ctx, gomock.Any()).Return(nil, errors.New("connection reset"))
+
+		if _, err := service.ListUsers(ctx, &dto.ListUsersQuery{Page: 1, PageSize: 10}); err == nil {
+			t.Error("Expected error when access levels cannot be loaded, got nil")
 		}
 	})
 
 	t.Run("DefaultPagination", func(t *testing.T) {
 		mockUserRepo.EXPECT().
-			List(ctx, 10, 0).
-			Return([]*models.User{}, 0, nil)
+			List(ctx, repository.UserFilter{}, 10, 0).
+			Return([]*models.User{}, nil)
+		mockUserRepo.EXPECT().Count(ctx, repository.UserFilter{}).Return(0, nil)
 
-		resp, err := service.ListUsers(ctx, 0, 0)
+		resp, err := service.ListUsers(ctx, &dto.ListUsersQuery{})
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -397,10 +560,11 @@ func TestUserService_ListUsers(t *testing.T) {
 
 	t.Run("MaxPageSize", func(t *testing.T) {
 		mockUserRepo.EXPECT().
-			List(ctx, 10, 0).
-			Return([]*models.User{}, 0, nil)
+			List(ctx, repository.UserFilter{}, 10, 0).
+			Return([]*models.User{}, nil)
+		mockUserRepo.EXPECT().Count(ctx, repository.UserFilter{}).Return(0, nil)
 
-		resp, err := service.ListUsers(ctx, 1, 200)
+		resp, err := service.ListUsers(ctx, &dto.ListUsersQuery{Page: 1, PageSize: 200})
 		if err != nil {
 			t.Fatalf("Expected no error, got %v", err)
 		}
@@ -409,6 +573,117 @@ func TestUserService_ListUsers(t *testing.T) {
 			t.Errorf("Expected pageSize capped at 10, got %d", resp.PageSize)
 		}
 	})
+
+	t.Run("FiltersAndSort", func(t *testing.T) {
+		after := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockUserRepo.EXPECT().
+			List(ctx, repository.UserFilter{
+				EmailPrefix:    "jo",
+				NamePrefix:     "Do",
+				AccessLevel:    "admin",
+				CreatedAfter:   &after,
+				IncludeDeleted: true,
+				Sort: []repository.UserSort{
+					{Field: "last_name"},
+					{Field: "created_at", Desc: true},
+				},
+			}, 20, 20).
+			Return([]*models.User{}, nil)
+		mockUserRepo.EXPECT().Count(ctx, gomock.Any()).Return(0, nil)
+
+		_, err := service.ListUsers(ctx, &dto.ListUsersQuery{
+			Page:           2,
+			PageSize:       20,
+			Email:          "jo",
+			Name:           "Do",
+			AccessLevel:    "admin",
+			CreatedAfter:   &after,
+			IncludeDeleted: true,
+			Sort:           "last_name, -created_at",
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("InvalidCreatedRange", func(t *testing.T) {
+		after := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+		before := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		_, err := service.ListUsers(ctx, &dto.ListUsersQuery{CreatedAfter: &after, CreatedBefore: &before})
+		if apperrors.Code(err) != "invalid_created_range" {
+			t.Errorf("Expected invalid_created_range, got %v", err)
+		}
+	})
+
+	t.Run("SkipTotal", func(t *testing.T) {
+		mockUserRepo.EXPECT().
+			List(ctx, repository.UserFilter{}, 10, 0).
+			Return([]*models.User{}, nil)
+
+		resp, err := service.ListUsers(ctx, &dto.ListUsersQuery{SkipTotal: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.Total != nil {
+			t.Errorf("Expected no total, got %d", *resp.Total)
+		}
+	})
+
+	t.Run("CursorUnsupportedSort", func(t *testing.T) {
+		_, err := service.ListUsers(ctx, &dto.ListUsersQuery{UseCursor: true, Sort: "last_name"})
+		if apperrors.Code(err) != "invalid_sort" {
+			t.Errorf("Expected invalid_sort, got %v", err)
+		}
+	})
+
+	t.Run("InvalidCursor", func(t *testing.T) {
+		_, err := service.ListUsers(ctx, &dto.ListUsersQuery{Cursor: "not-a-cursor"})
+		if apperrors.Code(err) != "invalid_cursor" {
+			t.Errorf("Expected invalid_cursor, got %v", err)
+		}
+	})
+}
+
+func TestUserService_SearchUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo)
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		users := []*models.User{{ID: uuid.New(), FirstName: "John", LastName: "Smith"}}
+		mockUserRepo.EXPECT().Search(ctx, "jon smith", 20).Return(users, nil)
+		mockAccessLevelRepo.EXPECT().GetUsersAccessLevels(ctx, []uuid.UUID{users[0].ID}).Return(nil, nil)
+
+		resp, err := service.SearchUsers(ctx, "  jon smith ", 0)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp.Users) != 1 || resp.Users[0].ID != users[0].ID {
+			t.Errorf("Expected the matching user, got %v", resp.Users)
+		}
+	})
+
+	t.Run("LimitCapped", func(t *testing.T) {
+		mockUserRepo.EXPECT().Search(ctx, "smith", 20).Return([]*models.User{}, nil)
+
+		if _, err := service.SearchUsers(ctx, "smith", 500); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		for _, query := range []string{"", " a ", strings.Repeat("a", 101)} {
+			_, err := service.SearchUsers(ctx, query, 10)
+			if apperrors.Code(err) != "invalid_search_query" {
+				t.Errorf("Query %q: expected invalid_search_query, got %v", query, err)
+			}
+		}
+	})
 }
 
 func TestUserService_AuthenticateUser(t *testing.T) {
@@ -514,6 +789,9 @@ func TestUserService_AuthenticateUser(t *testing.T) {
 		if err == nil {
 			t.Fatal("Expected error for invalid password, got nil")
 		}
+		if !errors.Is(err, apperrors.ErrUnauthorized) {
+			t.Errorf("Expected unauthorized error, got %v", err)
+		}
 	})
 
 	t.Run("AuthenticationNotFound", func(t *testing.T) {
@@ -543,6 +821,222 @@ func TestUserService_AuthenticateUser(t *testing.T) {
 	})
 }
 
+func TestUserService_AuthenticateUser_IssuesAccessToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenManager, err := auth.NewTokenManager(auth.TokenConfig{
+		Algorithm:      auth.AlgorithmHS256,
+		Secret:         "test-secret",
+		Issuer:         "user_service",
+		AccessTokenTTL: 15 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create token manager: %v", err)
+	}
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo, WithTokenIssuer(tokenManager))
+	ctx := context.Background()
+
+	userID := uuid.New()
+	password := "password123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	user := &models.User{ID: userID, Email: "john@example.com"}
+
+	mockUserRepo.EXPECT().
+		GetByEmail(ctx, user.Email).
+		Return(user, nil)
+	mockUserRepo.EXPECT().
+		GetUserAuthentication(ctx, userID).
+		Return(&models.UserAuthentication{UserID: userID, PasswordHash: string(hashedPassword)}, nil)
+	mockAccessLevelRepo.EXPECT().
+		GetUserAccessLevels(ctx, userID).
+		Return([]*models.AccessLevel{{ID: 1, Name: "admin"}}, nil)
+	mockAccessLevelRepo.EXPECT().
+		GetEffectiveUserAccessLevels(ctx, userID).
+		Return([]*models.AccessLevel{{ID: 1, Name: "admin"}, {ID: 2, Name: "viewer"}}, nil)
+
+	resp, err := service.AuthenticateUser(ctx, &dto.LoginRequest{Email: user.Email, Password: password})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if resp.TokenType != "Bearer" {
+		t.Errorf("Expected token type Bearer, got %s", resp.TokenType)
+	}
+	if resp.ExpiresIn != 900 {
+		t.Errorf("Expected expires_in 900, got %d", resp.ExpiresIn)
+	}
+
+	claims, err := tokenManager.VerifyAccessToken(resp.AccessToken)
+	if err != nil {
+		t.Fatalf("Expected issued token to verify, got %v", err)
+	}
+	if claims.Subject != userID.String() {
+		t.Errorf("Expected subject %s, got %s", userID, claims.Subject)
+	}
+	if len(claims.AccessLevels) != 2 || claims.AccessLevels[0] != "admin" || claims.AccessLevels[1] != "viewer" {
+		t.Errorf("Expected access level claims [admin viewer], got %v", claims.AccessLevels)
+	}
+}
+
+func TestUserService_RefreshTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenManager, err := auth.NewTokenManager(auth.TokenConfig{Secret: "test-secret"})
+	if err != nil {
+		t.Fatalf("Failed to create token manager: %v", err)
+	}
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockRefreshRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo,
+		WithTokenIssuer(tokenManager),
+		WithRefreshTokens(mockRefreshRepo, time.Hour),
+	)
+	ctx := context.Background()
+
+	t.Run("LoginIssuesRefreshToken", func(t *testing.T) {
+		userID := uuid.New()
+		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		user := &models.User{ID: userID, Email: "john@example.com"}
+
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).
+			Return(&models.UserAuthentication{UserID: userID, PasswordHash: string(hashedPassword)}, nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+		mockAccessLevelRepo.EXPECT().GetEffectiveUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+
+		var stored *models.RefreshToken
+		mockRefreshRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, token *models.RefreshToken) error {
+				stored = token
+				return nil
+			})
+
+		resp, err := service.AuthenticateUser(ctx, &dto.LoginRequest{Email: user.Email, Password: "password123"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.RefreshToken == "" {
+			t.Fatal("Expected refresh token in login response")
+		}
+		if stored.TokenHash != auth.HashOpaqueToken(resp.RefreshToken) {
+			t.Error("Expected only the hash of the refresh token to be stored")
+		}
+		if stored.UserID != userID || stored.FamilyID == uuid.Nil {
+			t.Errorf("Unexpected stored refresh token: %+v", stored)
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		userID := uuid.New()
+		current := &models.RefreshToken{
+			ID:        uuid.New(),
+			UserID:    userID,
+			FamilyID:  uuid.New(),
+			TokenHash: auth.HashOpaqueToken("current-token"),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+
+		mockRefreshRepo.EXPECT().GetByHash(ctx, current.TokenHash).Return(current, nil)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID}, nil)
+		mockAccessLevelRepo.EXPECT().GetEffectiveUserAccessLevels(ctx, userID).
+			Return([]*models.AccessLevel{{ID: 1, Name: "admin"}}, nil)
+		mockRefreshRepo.EXPECT().Rotate(ctx, current, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, current, next *models.RefreshToken) error {
+				if next.FamilyID != current.FamilyID {
+					t.Error("Expected rotated token to stay in the same family")
+				}
+				return nil
+			})
+
+		resp, err := service.RefreshTokens(ctx, &dto.RefreshTokenRequest{RefreshToken: "current-token"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.RefreshToken == "" || resp.RefreshToken == "current-token" {
+			t.Errorf("Expected a new refresh token, got %q", resp.RefreshToken)
+		}
+		claims, err := tokenManager.VerifyAccessToken(resp.AccessToken)
+		if err != nil {
+			t.Fatalf("Expected valid access token, got %v", err)
+		}
+		if claims.Subject != userID.String() {
+			t.Errorf("Expected subject %s, got %s", userID, claims.Subject)
+		}
+	})
+
+	t.Run("ReuseRevokesFamily", func(t *testing.T) {
+		revokedAt := time.Now().Add(-time.Minute)
+		current := &models.RefreshToken{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			FamilyID:  uuid.New(),
+			ExpiresAt: time.Now().Add(time.Hour),
+			RevokedAt: &revokedAt,
+		}
+
+		mockRefreshRepo.EXPECT().GetByHash(ctx, auth.HashOpaqueToken("replayed-token")).Return(current, nil)
+		mockRefreshRepo.EXPECT().RevokeFamily(ctx, current.FamilyID).Return(nil)
+
+		_, err := service.RefreshTokens(ctx, &dto.RefreshTokenRequest{RefreshToken: "replayed-token"})
+		if err == nil {
+			t.Fatal("Expected error for replayed token, got nil")
+		}
+	})
+
+	t.Run("ConcurrentRotationRevokesFamily", func(t *testing.T) {
+		userID := uuid.New()
+		current := &models.RefreshToken{
+			ID:        uuid.New(),
+			UserID:    userID,
+			FamilyID:  uuid.New(),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+
+		mockRefreshRepo.EXPECT().GetByHash(ctx, gomock.Any()).Return(current, nil)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID}, nil)
+		mockAccessLevelRepo.EXPECT().GetEffectiveUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+		mockRefreshRepo.EXPECT().Rotate(ctx, current, gomock.Any()).Return(repository.ErrRefreshTokenRevoked)
+		mockRefreshRepo.EXPECT().RevokeFamily(ctx, current.FamilyID).Return(nil)
+
+		_, err := service.RefreshTokens(ctx, &dto.RefreshTokenRequest{RefreshToken: "raced-token"})
+		if err == nil {
+			t.Fatal("Expected error for concurrently rotated token, got nil")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		current := &models.RefreshToken{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			FamilyID:  uuid.New(),
+			ExpiresAt: time.Now().Add(-time.Minute),
+		}
+
+		mockRefreshRepo.EXPECT().GetByHash(ctx, gomock.Any()).Return(current, nil)
+
+		_, err := service.RefreshTokens(ctx, &dto.RefreshTokenRequest{RefreshToken: "expired-token"})
+		if err == nil {
+			t.Fatal("Expected error for expired token, got nil")
+		}
+	})
+
+	t.Run("UnknownToken", func(t *testing.T) {
+		mockRefreshRepo.EXPECT().GetByHash(ctx, gomock.Any()).Return(nil, errors.New("refresh token not found"))
+
+		_, err := service.RefreshTokens(ctx, &dto.RefreshTokenRequest{RefreshToken: "unknown"})
+		if err == nil {
+			t.Fatal("Expected error for unknown token, got nil")
+		}
+	})
+}
+
 func TestUserService_AssignAccessLevels(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -572,8 +1066,8 @@ func TestUserService_AssignAccessLevels(t *testing.T) {
 			Return(user, nil)
 
 		mockAccessLevelRepo.EXPECT().
-			GetByID(ctx, 1).
-			Return(accessLevel, nil)
+			GetByIDs(ctx, []int{1}).
+			Return([]*models.AccessLevel{accessLevel}, nil)
 
 		mockAccessLevelRepo.EXPECT().
 			AssignToUser(ctx, userID, 1).
@@ -616,16 +1110,70 @@ func TestUserService_AssignAccessLevels(t *testing.T) {
 			Return(user, nil)
 
 		mockAccessLevelRepo.EXPECT().
-			GetByID(ctx, 999).
-			Return(nil, errors.New("access level not found"))
+			GetByIDs(ctx, []int{999}).
+			Return([]*models.AccessLevel{}, nil)
+
+		err := service.AssignAccessLevels(ctx, userID, req)
+		if err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("ReportsAllInvalidIDs", func(t *testing.T) {
+		userID := uuid.New()
+		req := &dto.AssignAccessLevelRequest{
+			AccessLevelIDs: []int{7, 1, 9, 1},
+		}
+
+		mockUserRepo.EXPECT().
+			GetByID(ctx, userID).
+			Return(&models.User{ID: userID}, nil)
+
+		// Nothing is assigned when any ID is unknown
+		mockAccessLevelRepo.EXPECT().
+			GetByIDs(ctx, []int{1, 7, 9}).
+			Return([]*models.AccessLevel{{ID: 1, Name: "Admin"}}, nil)
 
 		err := service.AssignAccessLevels(ctx, userID, req)
 		if err == nil {
 			t.Fatal("Expected error, got nil")
 		}
+		if err.Error() != "access levels not found: 7, 9" {
+			t.Errorf("Unexpected error: %v", err)
+		}
 	})
 }
 
+func TestUserService_AssignAccessLevels_UnitOfWork(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The service's own repositories must not be used once a unit of work is configured
+	mockUoW := mocks.NewMockUnitOfWork(ctrl)
+	txUserRepo := mocks.NewMockUserRepository(ctrl)
+	txAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewUserService(mocks.NewMockUserRepository(ctrl), mocks.NewMockAccessLevelRepository(ctrl),
+		WithUnitOfWork(mockUoW),
+	)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	mockUoW.EXPECT().Do(ctx, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(repository.Repositories) error) error {
+			return fn(repository.Repositories{Users: txUserRepo, AccessLevels: txAccessLevelRepo})
+		})
+	txUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID}, nil)
+	txAccessLevelRepo.EXPECT().GetByIDs(ctx, []int{1, 2}).
+		Return([]*models.AccessLevel{{ID: 1}, {ID: 2}}, nil)
+	txAccessLevelRepo.EXPECT().AssignToUser(ctx, userID, 1).Return(nil)
+	txAccessLevelRepo.EXPECT().AssignToUser(ctx, userID, 2).Return(errors.New("database error"))
+
+	err := service.AssignAccessLevels(ctx, userID, &dto.AssignAccessLevelRequest{AccessLevelIDs: []int{1, 2}})
+	if err == nil {
+		t.Fatal("Expected error to be returned from the unit of work, got nil")
+	}
+}
+
 func TestUserService_RemoveAccessLevel(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -732,3 +1280,1712 @@ func TestUserService_GetUserAccessLevels(t *testing.T) {
 		}
 	})
 }
+
+func TestUserService_GetUserPermissions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockPermissionRepo := mocks.NewMockPermissionRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo, WithPermissions(mockPermissionRepo))
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		userID := uuid.New()
+
+		mockAccessLevelRepo.EXPECT().
+			GetEffectiveUserAccessLevels(ctx, userID).
+			Return([]*models.AccessLevel{{ID: 1, Name: "reader"}, {ID: 2, Name: "writer"}}, nil)
+		mockPermissionRepo.EXPECT().
+			GetByAccessLevels(ctx, []int{1, 2}).
+			Return([]*models.Permission{{ID: 1, Name: "users:read"}, {ID: 2, Name: "users:write"}}, nil)
+
+		permissions, err := service.GetUserPermissions(ctx, userID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(permissions) != 2 || permissions[0] != "users:read" || permissions[1] != "users:write" {
+			t.Errorf("Expected [users:read users:write], got %v", permissions)
+		}
+	})
+
+	t.Run("AccessLevelError", func(t *testing.T) {
+		userID := uuid.New()
+
+		mockAccessLevelRepo.EXPECT().
+			GetEffectiveUserAccessLevels(ctx, userID).
+			Return(nil, errors.New("database error"))
+
+		if _, err := service.GetUserPermissions(ctx, userID); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("NotConfigured", func(t *testing.T) {
+		unconfigured := NewUserService(mockUserRepo, mockAccessLevelRepo)
+		if _, err := unconfigured.GetUserPermissions(ctx, uuid.New()); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+}
+
+func TestUserService_GetUserEffectiveAccessLevels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo)
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		userID := uuid.New()
+
+		mockAccessLevelRepo.EXPECT().
+			GetUserAccessLevels(ctx, userID).
+			Return([]*models.AccessLevel{{ID: 3, Name: "admin"}}, nil)
+		mockAccessLevelRepo.EXPECT().
+			GetEffectiveUserAccessLevels(ctx, userID).
+			Return([]*models.AccessLevel{{ID: 3, Name: "admin"}, {ID: 2, Name: "editor"}, {ID: 1, Name: "viewer"}}, nil)
+
+		resp, err := service.GetUserEffectiveAccessLevels(ctx, userID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp.Direct) != 1 || resp.Direct[0].Name != "admin" {
+			t.Errorf("Expected direct [admin], got %+v", resp.Direct)
+		}
+		if len(resp.Effective) != 3 {
+			t.Errorf("Expected 3 effective access levels, got %d", len(resp.Effective))
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		userID := uuid.New()
+
+		mockAccessLevelRepo.EXPECT().
+			GetUserAccessLevels(ctx, userID).
+			Return([]*models.AccessLevel{}, nil)
+		mockAccessLevelRepo.EXPECT().
+			GetEffectiveUserAccessLevels(ctx, userID).
+			Return(nil, errors.New("database error"))
+
+		if _, err := service.GetUserEffectiveAccessLevels(ctx, userID); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+}
+
+func TestUserService_ReplaceAccessLevels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo)
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		userID := uuid.New()
+
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID}, nil)
+		mockAccessLevelRepo.EXPECT().GetByIDs(ctx, []int{2, 3}).
+			Return([]*models.AccessLevel{{ID: 2, Name: "editor"}, {ID: 3, Name: "admin"}}, nil)
+		mockAccessLevelRepo.EXPECT().ReplaceUserAccessLevels(ctx, userID, []int{2, 3}).Return(nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).
+			Return([]*models.AccessLevel{{ID: 3, Name: "admin"}, {ID: 2, Name: "editor"}}, nil)
+
+		resp, err := service.ReplaceAccessLevels(ctx, userID, &dto.ReplaceAccessLevelsRequest{AccessLevelIDs: []int{3, 2, 3}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp) != 2 {
+			t.Errorf("Expected 2 access levels, got %d", len(resp))
+		}
+	})
+
+	t.Run("ClearAll", func(t *testing.T) {
+		userID := uuid.New()
+
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID}, nil)
+		mockAccessLevelRepo.EXPECT().GetByIDs(ctx, []int{}).Return([]*models.AccessLevel{}, nil)
+		mockAccessLevelRepo.EXPECT().ReplaceUserAccessLevels(ctx, userID, []int{}).Return(nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+
+		resp, err := service.ReplaceAccessLevels(ctx, userID, &dto.ReplaceAccessLevelsRequest{AccessLevelIDs: []int{}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp) != 0 {
+			t.Errorf("Expected 0 access levels, got %d", len(resp))
+		}
+	})
+
+	t.Run("UnknownAccessLevel", func(t *testing.T) {
+		userID := uuid.New()
+
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID}, nil)
+		mockAccessLevelRepo.EXPECT().GetByIDs(ctx, []int{99}).Return([]*models.AccessLevel{}, nil)
+
+		if _, err := service.ReplaceAccessLevels(ctx, userID, &dto.ReplaceAccessLevelsRequest{AccessLevelIDs: []int{99}}); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		userID := uuid.New()
+
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(nil, errors.New("user not found"))
+
+		if _, err := service.ReplaceAccessLevels(ctx, userID, &dto.ReplaceAccessLevelsRequest{AccessLevelIDs: []int{1}}); err == nil {
+			t.Fatal("Expected error, got nil")
+		}
+	})
+}
+
+func TestUserService_RequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetTokenRepository(ctrl)
+	mockNotifier := mocks.NewMockNotifier(ctrl)
+	service := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl),
+		WithPasswordReset(mockResetRepo, mockNotifier, "https://app.example.com/reset-password", 30*time.Minute),
+	)
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+
+		var stored *models.PasswordResetToken
+		mockResetRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, token *models.PasswordResetToken) error {
+				stored = token
+				return nil
+			})
+		var sent notify.Message
+		mockNotifier.EXPECT().Notify(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, msg notify.Message) error {
+				sent = msg
+				return nil
+			})
+
+		err := service.RequestPasswordReset(ctx, &dto.PasswordResetRequest{Email: user.Email})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if stored.UserID != user.ID {
+			t.Errorf("Expected token for user %s, got %s", user.ID, stored.UserID)
+		}
+		if until := time.Until(stored.ExpiresAt); until <= 29*time.Minute || until > 30*time.Minute {
+			t.Errorf("Expected token to expire in 30 minutes, expires in %v", until)
+		}
+		if sent.To != user.Email {
+			t.Errorf("Expected link to be sent to %s, got %s", user.Email, sent.To)
+		}
+
+		// The link carries the token; only its hash is stored
+		const prefix = "https://app.example.com/reset-password?token="
+		idx := strings.Index(sent.Body, prefix)
+		if idx < 0 {
+			t.Fatalf("Expected reset link in message body, got %q", sent.Body)
+		}
+		token := strings.Fields(sent.Body[idx+len(prefix):])[0]
+		if stored.TokenHash != auth.HashOpaqueToken(token) {
+			t.Error("Expected the stored hash to match the token in the link")
+		}
+	})
+
+	t.Run("UnknownEmail", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByEmail(ctx, "nobody@example.com").
+			Return(nil, apperrors.NotFound("user_not_found", "user not found"))
+
+		err := service.RequestPasswordReset(ctx, &dto.PasswordResetRequest{Email: "nobody@example.com"})
+		if err != nil {
+			t.Errorf("Expected unknown email to be ignored, got %v", err)
+		}
+	})
+
+	t.Run("NotifierError", func(t *testing.T) {
+		user := &models.User{ID: uuid.New(), Email: "john@example.com"}
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockResetRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		mockNotifier.EXPECT().Notify(ctx, gomock.Any()).Return(errors.New("smtp unavailable"))
+
+		if err := service.RequestPasswordReset(ctx, &dto.PasswordResetRequest{Email: user.Email}); err == nil {
+			t.Error("Expected notifier error to be returned, got nil")
+		}
+	})
+
+	// The notifier and reset repository must not be called
+	t.Run("UnverifiedEmail", func(t *testing.T) {
+		verifying := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl),
+			WithPasswordReset(mockResetRepo, mockNotifier, "https://app.example.com/reset-password", 30*time.Minute),
+			WithEmailVerification(mocks.NewMockEmailVerificationTokenRepository(ctrl), mockNotifier, "", 0, false),
+		)
+		user := &models.User{ID: uuid.New(), Email: "changed@example.com"}
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+
+		if err := verifying.RequestPasswordReset(ctx, &dto.PasswordResetRequest{Email: user.Email}); err != nil {
+			t.Errorf("Expected unverified email to be ignored, got %v", err)
+		}
+	})
+
+	t.Run("NotEnabled", func(t *testing.T) {
+		disabled := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl))
+		if err := disabled.RequestPasswordReset(ctx, &dto.PasswordResetRequest{Email: "john@example.com"}); err == nil {
+			t.Error("Expected error when password reset is not enabled, got nil")
+		}
+	})
+}
+
+func TestUserService_ConfirmPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockResetRepo := mocks.NewMockPasswordResetTokenRepository(ctrl)
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	service := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl),
+		WithRefreshTokens(mockRefreshRepo, time.Hour),
+		WithPasswordReset(mockResetRepo, mocks.NewMockNotifier(ctrl), "", time.Hour),
+		WithSessions(mockSessionRepo, 0, 0),
+	)
+	ctx := context.Background()
+
+	newToken := func(expiresAt time.Time, usedAt *time.Time) *models.PasswordResetToken {
+		return &models.PasswordResetToken{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			TokenHash: auth.HashOpaqueToken("reset-token"),
+			ExpiresAt: expiresAt,
+			UsedAt:    usedAt,
+		}
+	}
+	req := &dto.PasswordResetConfirmRequest{Token: "reset-token", NewPassword: "newpassword123"}
+
+	expectUser := func(userID uuid.UUID) {
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID, Email: "john@example.com"}, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).
+			Return(&models.UserAuthentication{UserID: userID, PasswordHash: "$2a$04$not-the-new-password"}, nil)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		token := newToken(time.Now().Add(time.Hour), nil)
+		mockResetRepo.EXPECT().GetByHash(ctx, token.TokenHash).Return(token, nil)
+		expectUser(token.UserID)
+		mockResetRepo.EXPECT().MarkUsed(ctx, token.ID).Return(nil)
+		mockUserRepo.EXPECT().UpdatePassword(ctx, token.UserID, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+				if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.NewPassword)) != nil {
+					t.Error("Expected the stored hash to match the new password")
+				}
+				return nil
+			})
+		mockResetRepo.EXPECT().InvalidateForUser(ctx, token.UserID).Return(nil)
+		mockRefreshRepo.EXPECT().RevokeAllForUser(ctx, token.UserID).Return(nil)
+		mockSessionRepo.EXPECT().RevokeAllForUser(ctx, token.UserID).Return(nil)
+
+		if err := service.ConfirmPasswordReset(ctx, req); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	invalid := []struct {
+		name  string
+		setup func()
+	}{
+		{"UnknownToken", func() {
+			mockResetRepo.EXPECT().GetByHash(ctx, gomock.Any()).
+				Return(nil, apperrors.NotFound("password_reset_token_not_found", "password reset token not found"))
+		}},
+		{"ExpiredToken", func() {
+			mockResetRepo.EXPECT().GetByHash(ctx, gomock.Any()).Return(newToken(time.Now().Add(-time.Minute), nil), nil)
+		}},
+		{"UsedToken", func() {
+			usedAt := time.Now().Add(-time.Minute)
+			mockResetRepo.EXPECT().GetByHash(ctx, gomock.Any()).Return(newToken(time.Now().Add(time.Hour), &usedAt), nil)
+		}},
+		{"SpentConcurrently", func() {
+			token := newToken(time.Now().Add(time.Hour), nil)
+			mockResetRepo.EXPECT().GetByHash(ctx, gomock.Any()).Return(token, nil)
+			expectUser(token.UserID)
+			mockResetRepo.EXPECT().MarkUsed(ctx, token.ID).Return(repository.ErrPasswordResetTokenUsed)
+		}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+
+			err := service.ConfirmPasswordReset(ctx, req)
+			if !errors.Is(err, apperrors.ErrValidation) || apperrors.Code(err) != "invalid_reset_token" {
+				t.Errorf("Expected invalid_reset_token, got %v", err)
+			}
+		})
+	}
+
+	t.Run("WeakPassword", func(t *testing.T) {
+		token := newToken(time.Now().Add(time.Hour), nil)
+		mockResetRepo.EXPECT().GetByHash(ctx, gomock.Any()).Return(token, nil)
+		expectUser(token.UserID)
+
+		err := service.ConfirmPasswordReset(ctx, &dto.PasswordResetConfirmRequest{Token: "reset-token", NewPassword: "short"})
+		fields := apperrors.Fields(err)
+		if len(fields) != 1 || fields[0].Field != "new_password" || fields[0].Rule != "min" {
+			t.Errorf("Expected new_password min violation, got %v", err)
+		}
+	})
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	service := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl))
+	ctx := context.Background()
+	userID := uuid.New()
+	user := &models.User{ID: userID, FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}
+	currentHash, _ := bcrypt.GenerateFromPassword([]byte("oldpassword123"), bcrypt.MinCost)
+	userAuth := &models.UserAuthentication{UserID: userID, PasswordHash: string(currentHash)}
+
+	expectUser := func(repo *mocks.MockUserRepository) {
+		repo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		repo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		expectUser(mockUserRepo)
+		mockUserRepo.EXPECT().UpdatePassword(ctx, userID, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+				if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte("newpassword123")) != nil {
+					t.Error("Expected the stored hash to match the new password")
+				}
+				return nil
+			})
+
+		err := service.ChangePassword(ctx, userID, &dto.ChangePasswordRequest{
+			CurrentPassword: "oldpassword123",
+			NewPassword:     "newpassword123",
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("WrongCurrentPassword", func(t *testing.T) {
+		expectUser(mockUserRepo)
+
+		err := service.ChangePassword(ctx, userID, &dto.ChangePasswordRequest{
+			CurrentPassword: "wrongpassword",
+			NewPassword:     "newpassword123",
+		})
+		if !errors.Is(err, apperrors.ErrValidation) || apperrors.Code(err) != "invalid_current_password" {
+			t.Errorf("Expected invalid_current_password, got %v", err)
+		}
+	})
+
+	t.Run("ReusedPassword", func(t *testing.T) {
+		expectUser(mockUserRepo)
+
+		err := service.ChangePassword(ctx, userID, &dto.ChangePasswordRequest{
+			CurrentPassword: "oldpassword123",
+			NewPassword:     "oldpassword123",
+		})
+		if !errors.Is(err, apperrors.ErrValidation) || apperrors.Code(err) != "password_reused" {
+			t.Errorf("Expected password_reused, got %v", err)
+		}
+	})
+
+	t.Run("TooShort", func(t *testing.T) {
+		expectUser(mockUserRepo)
+
+		err := service.ChangePassword(ctx, userID, &dto.ChangePasswordRequest{
+			CurrentPassword: "oldpassword123",
+			NewPassword:     "short",
+		})
+		fields := apperrors.Fields(err)
+		if len(fields) != 1 || fields[0].Field != "new_password" || fields[0].Rule != "min" {
+			t.Errorf("Expected new_password min violation, got %v", err)
+		}
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByID(ctx, userID).
+			Return(nil, apperrors.NotFound("user_not_found", "user not found"))
+
+		err := service.ChangePassword(ctx, userID, &dto.ChangePasswordRequest{
+			CurrentPassword: "oldpassword123",
+			NewPassword:     "newpassword123",
+		})
+		if !errors.Is(err, apperrors.ErrNotFound) || apperrors.Code(err) != "user_not_found" {
+			t.Errorf("Expected user_not_found, got %v", err)
+		}
+	})
+}
+
+func TestUserService_ChangePassword_LockoutAndSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockRefreshRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	mockSessionRepo := mocks.NewMockSessionRepository(ctrl)
+	service := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl),
+		WithRefreshTokens(mockRefreshRepo, 0),
+		WithSessions(mockSessionRepo, 0, 0),
+		WithLockout(LockoutPolicy{MaxAttempts: 3, Duration: 10 * time.Minute, BaseDelay: time.Second}, nil),
+	)
+	userID := uuid.New()
+	sessionID := uuid.New()
+	user := &models.User{ID: userID, FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"}
+	currentHash, _ := bcrypt.GenerateFromPassword([]byte("oldpassword123"), bcrypt.MinCost)
+	req := &dto.ChangePasswordRequest{CurrentPassword: "oldpassword123", NewPassword: "newpassword123"}
+
+	t.Run("WrongCurrentPasswordCountsAsFailedLogin", func(t *testing.T) {
+		ctx := context.Background()
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).
+			Return(&models.UserAuthentication{UserID: userID, PasswordHash: string(currentHash)}, nil)
+		mockUserRepo.EXPECT().RecordFailedLogin(ctx, userID, gomock.Any()).Return(1, nil)
+		mockUserRepo.EXPECT().LockUntil(ctx, userID, gomock.Any()).Return(nil)
+
+		err := service.ChangePassword(ctx, userID, &dto.ChangePasswordRequest{CurrentPassword: "wrongpassword", NewPassword: "newpassword123"})
+		if apperrors.Code(err) != "invalid_current_password" {
+			t.Errorf("Expected invalid_current_password, got %v", err)
+		}
+	})
+
+	t.Run("Locked", func(t *testing.T) {
+		ctx := context.Background()
+		lockedUntil := time.Now().Add(5 * time.Minute)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(&models.UserAuthentication{
+			UserID:           userID,
+			PasswordHash:     string(currentHash),
+			FailedLoginCount: 3,
+			LockedUntil:      &lockedUntil,
+		}, nil)
+
+		err := service.ChangePassword(ctx, userID, req)
+		if !errors.Is(err, apperrors.ErrRateLimited) || apperrors.Code(err) != "account_locked" {
+			t.Errorf("Expected account_locked, got %v", err)
+		}
+	})
+
+	t.Run("KeepsCallersSession", func(t *testing.T) {
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: userID, SessionID: sessionID.String()})
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).
+			Return(&models.UserAuthentication{UserID: userID, PasswordHash: string(currentHash), FailedLoginCount: 1}, nil)
+		mockUserRepo.EXPECT().UpdatePassword(ctx, userID, gomock.Any()).Return(nil)
+		mockUserRepo.EXPECT().ResetFailedLogins(ctx, userID).Return(nil)
+		mockRefreshRepo.EXPECT().RevokeOtherFamilies(ctx, userID, sessionID).Return(nil)
+		mockSessionRepo.EXPECT().RevokeOthersForUser(ctx, userID, sessionID).Return(nil)
+
+		if err := service.ChangePassword(ctx, userID, req); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("ChangedByAnotherUser", func(t *testing.T) {
+		ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: uuid.New(), SessionID: sessionID.String()})
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).
+			Return(&models.UserAuthentication{UserID: userID, PasswordHash: string(currentHash)}, nil)
+		mockUserRepo.EXPECT().UpdatePassword(ctx, userID, gomock.Any()).Return(nil)
+		mockRefreshRepo.EXPECT().RevokeAllForUser(ctx, userID).Return(nil)
+		mockSessionRepo.EXPECT().RevokeAllForUser(ctx, userID).Return(nil)
+
+		if err := service.ChangePassword(ctx, userID, req); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+}
+
+func TestUserService_PasswordPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	policy := password.Policy{
+		MinLength:            10,
+		MaxLength:            64,
+		RequireDigit:         true,
+		DisallowPersonalInfo: true,
+		HistorySize:          3,
+	}
+	service := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl), WithPasswordPolicy(policy))
+	ctx := context.Background()
+
+	t.Run("CreateRejectsPolicyViolations", func(t *testing.T) {
+		_, err := service.CreateUser(ctx, &dto.CreateUserRequest{
+			FirstName: "John",
+			LastName:  "Doe",
+			Email:     "john.doe@example.com",
+			Password:  "johnspassword",
+		})
+
+		var got []string
+		for _, f := range apperrors.Fields(err) {
+			got = append(got, f.Field+":"+f.Rule)
+		}
+		if strings.Join(got, ",") != "password:digit,password:personal_info" {
+			t.Errorf("Expected digit and personal_info violations, got %v", got)
+		}
+	})
+
+	t.Run("ChangeRejectsRecentPassword", func(t *testing.T) {
+		userID := uuid.New()
+		currentHash, _ := bcrypt.GenerateFromPassword([]byte("current-pass-1"), bcrypt.MinCost)
+		previousHash, _ := bcrypt.GenerateFromPassword([]byte("previous-pass-2"), bcrypt.MinCost)
+
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID, Email: "jane@example.com"}, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).
+			Return(&models.UserAuthentication{UserID: userID, PasswordHash: string(currentHash)}, nil)
+		mockUserRepo.EXPECT().GetPasswordHistory(ctx, userID, 2).
+			Return([]*models.PasswordHistory{{UserID: userID, PasswordHash: string(previousHash)}}, nil)
+
+		err := service.ChangePassword(ctx, userID, &dto.ChangePasswordRequest{
+			CurrentPassword: "current-pass-1",
+			NewPassword:     "previous-pass-2",
+		})
+		if !errors.Is(err, apperrors.ErrValidation) || apperrors.Code(err) != "password_reused" {
+			t.Errorf("Expected password_reused, got %v", err)
+		}
+	})
+}
+
+func TestUserService_AuthenticateUser_Lockout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockAuditRepo := mocks.NewMockAuditEventRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo,
+		WithLockout(LockoutPolicy{MaxAttempts: 3, Duration: 10 * time.Minute, BaseDelay: time.Second}, mockAuditRepo),
+	)
+	ctx := auth.WithClientIP(context.Background(), "203.0.113.9")
+
+	userID := uuid.New()
+	user := &models.User{ID: userID, Email: "lock@example.com"}
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.MinCost)
+	authWith := func(failures int, lockedUntil *time.Time) *models.UserAuthentication {
+		return &models.UserAuthentication{
+			UserID:           userID,
+			PasswordHash:     string(hashedPassword),
+			FailedLoginCount: failures,
+			LockedUntil:      lockedUntil,
+		}
+	}
+	login := func(password string) error {
+		_, err := service.AuthenticateUser(ctx, &dto.LoginRequest{Email: user.Email, Password: password})
+		return err
+	}
+	expectLockUntil := func(delay time.Duration) {
+		mockUserRepo.EXPECT().LockUntil(ctx, userID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, until time.Time) error {
+				if remaining := time.Until(until); remaining <= delay-time.Second || remaining > delay {
+					t.Errorf("Expected lock for %v, got %v", delay, remaining)
+				}
+				return nil
+			})
+	}
+
+	t.Run("Locked", func(t *testing.T) {
+		lockedUntil := time.Now().Add(5 * time.Minute)
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(authWith(3, &lockedUntil), nil)
+
+		// Even the correct password is refused while locked
+		err := login("correctpassword")
+		if !errors.Is(err, apperrors.ErrRateLimited) || apperrors.Code(err) != "account_locked" {
+			t.Fatalf("Expected account_locked, got %v", err)
+		}
+		if retryAfter := apperrors.RetryAfter(err); retryAfter <= 4*time.Minute || retryAfter > 5*time.Minute {
+			t.Errorf("Expected retry after about 5 minutes, got %v", retryAfter)
+		}
+	})
+
+	t.Run("Throttled", func(t *testing.T) {
+		lockedUntil := time.Now().Add(2 * time.Second)
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(authWith(2, &lockedUntil), nil)
+
+		if err := login("wrongpassword"); apperrors.Code(err) != "login_throttled" {
+			t.Fatalf("Expected login_throttled, got %v", err)
+		}
+	})
+
+	t.Run("Failure Delays Next Attempt", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(authWith(1, nil), nil)
+		mockUserRepo.EXPECT().RecordFailedLogin(ctx, userID, gomock.Any()).Return(2, nil)
+		expectLockUntil(2 * time.Second)
+
+		if err := login("wrongpassword"); apperrors.Code(err) != "invalid_credentials" {
+			t.Fatalf("Expected invalid_credentials, got %v", err)
+		}
+	})
+
+	t.Run("Failure Locks Account", func(t *testing.T) {
+		lockExpired := time.Now().Add(-time.Second)
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(authWith(2, &lockExpired), nil)
+		mockUserRepo.EXPECT().RecordFailedLogin(ctx, userID, gomock.Any()).Return(3, nil)
+		expectLockUntil(10 * time.Minute)
+		mockAuditRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, event *models.AuditEvent) error {
+				if event.UserID != userID || event.Event != models.AuditEventAccountLocked {
+					t.Errorf("Unexpected audit event %+v", event)
+				}
+				if event.IPAddress == nil || *event.IPAddress != "203.0.113.9" {
+					t.Errorf("Expected client IP to be recorded, got %v", event.IPAddress)
+				}
+				if event.ActorID != nil {
+					t.Errorf("Expected no actor for a failed login, got %v", event.ActorID)
+				}
+				return nil
+			})
+
+		if err := login("wrongpassword"); apperrors.Code(err) != "invalid_credentials" {
+			t.Fatalf("Expected invalid_credentials, got %v", err)
+		}
+	})
+
+	t.Run("Success Resets Failures", func(t *testing.T) {
+		lockExpired := time.Now().Add(-time.Second)
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(authWith(2, &lockExpired), nil)
+		mockUserRepo.EXPECT().ResetFailedLogins(ctx, userID).Return(nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+
+		if err := login("correctpassword"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+}
+
+func TestLockoutPolicy_LockFor(t *testing.T) {
+	policy := LockoutPolicy{MaxAttempts: 5, Duration: 5 * time.Second, BaseDelay: time.Second}
+
+	tests := []struct {
+		failures      int
+		expectedDelay time.Duration
+		expectedLock  bool
+	}{
+		{1, time.Second, false},
+		{2, 2 * time.Second, false},
+		{3, 4 * time.Second, false},
+		{4, 5 * time.Second, false},
+		{5, 5 * time.Second, true},
+		{70, 5 * time.Second, true},
+	}
+	for _, tt := range tests {
+		delay, locked := policy.lockFor(tt.failures)
+		if delay != tt.expectedDelay || locked != tt.expectedLock {
+			t.Errorf("lockFor(%d) = %v, %v; want %v, %v", tt.failures, delay, locked, tt.expectedDelay, tt.expectedLock)
+		}
+	}
+}
+
+func TestUserService_UnlockUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAuditRepo := mocks.NewMockAuditEventRepository(ctrl)
+	service := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl),
+		WithLockout(LockoutPolicy{}, mockAuditRepo),
+	)
+	adminID := uuid.New()
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{UserID: adminID})
+
+	t.Run("Success", func(t *testing.T) {
+		userID := uuid.New()
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID}, nil)
+		mockUserRepo.EXPECT().ResetFailedLogins(ctx, userID).Return(nil)
+		mockAuditRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, event *models.AuditEvent) error {
+				if event.UserID != userID || event.Event != models.AuditEventAccountUnlocked {
+					t.Errorf("Unexpected audit event %+v", event)
+				}
+				if event.ActorID == nil || *event.ActorID != adminID {
+					t.Errorf("Expected the unlocking admin as actor, got %v", event.ActorID)
+				}
+				return nil
+			})
+
+		if err := service.UnlockUser(ctx, userID); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		userID := uuid.New()
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(nil, apperrors.NotFound("user_not_found", "user not found"))
+
+		if err := service.UnlockUser(ctx, userID); !errors.Is(err, apperrors.ErrNotFound) {
+			t.Fatalf("Expected not found, got %v", err)
+		}
+	})
+}
+
+// testMFASecrets seals the TOTP secrets of test users
+var testMFASecrets, _ = auth.NewSecretBox([]byte("test-mfa-secret"))
+
+func sealTOTPSecret(t *testing.T, secret string) *string {
+	t.Helper()
+	sealed, err := testMFASecrets.Seal(secret)
+	if err != nil {
+		t.Fatalf("Failed to seal TOTP secret: %v", err)
+	}
+	return &sealed
+}
+
+func TestUserService_EnrollTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	service := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl),
+		WithMFA(mockMFARepo, mocks.NewMockMFAChallengeRepository(ctrl), testMFASecrets, "Example", 0),
+	)
+	ctx := context.Background()
+	userID := uuid.New()
+	user := &models.User{ID: userID, Email: "mfa@example.com"}
+
+	t.Run("Success", func(t *testing.T) {
+		var stored string
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(&models.UserAuthentication{UserID: userID}, nil)
+		mockMFARepo.EXPECT().SetTOTPSecret(ctx, userID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, secret string) error {
+				stored = secret
+				return nil
+			})
+
+		resp, err := service.EnrollTOTP(ctx, userID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.Secret == "" || stored == resp.Secret {
+			t.Errorf("Expected the secret to be stored sealed, got %q", stored)
+		}
+		if opened, err := testMFASecrets.Open(stored); err != nil || opened != resp.Secret {
+			t.Errorf("Expected the stored secret to open to %q, got %q (%v)", resp.Secret, opened, err)
+		}
+		if !strings.HasPrefix(resp.OTPAuthURI, "otpauth://totp/Example:mfa@example.com?") {
+			t.Errorf("Unexpected otpauth URI %s", resp.OTPAuthURI)
+		}
+	})
+
+	t.Run("Already Enabled", func(t *testing.T) {
+		enabledAt := time.Now()
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).
+			Return(&models.UserAuthentication{UserID: userID, MFAEnabledAt: &enabledAt}, nil)
+
+		_, err := service.EnrollTOTP(ctx, userID)
+		if !errors.Is(err, apperrors.ErrConflict) || apperrors.Code(err) != "mfa_already_enabled" {
+			t.Fatalf("Expected mfa_already_enabled, got %v", err)
+		}
+	})
+}
+
+func TestUserService_ActivateTOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	service := NewUserService(mockUserRepo, mocks.NewMockAccessLevelRepository(ctrl),
+		WithMFA(mockMFARepo, mocks.NewMockMFAChallengeRepository(ctrl), testMFASecrets, "", 0),
+	)
+	ctx := context.Background()
+	userID := uuid.New()
+	secret, _ := totp.GenerateSecret()
+	enrolled := &models.UserAuthentication{UserID: userID, TOTPSecret: sealTOTPSecret(t, secret)}
+
+	t.Run("Success", func(t *testing.T) {
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(enrolled, nil)
+		mockMFARepo.EXPECT().EnableTOTP(ctx, userID, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, _ int64, hashes []string) error {
+				if len(hashes) != recoveryCodeCount {
+					t.Errorf("Expected %d recovery code hashes, got %d", recoveryCodeCount, len(hashes))
+				}
+				return nil
+			})
+
+		resp, err := service.ActivateTOTP(ctx, userID, &dto.ActivateTOTPRequest{Code: code})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp.RecoveryCodes) != recoveryCodeCount {
+			t.Fatalf("Expected %d recovery codes, got %d", recoveryCodeCount, len(resp.RecoveryCodes))
+		}
+		if len(resp.RecoveryCodes[0]) != recoveryCodeLength+1 || resp.RecoveryCodes[0][recoveryCodeLength/2] != '-' {
+			t.Errorf("Unexpected recovery code format %q", resp.RecoveryCodes[0])
+		}
+	})
+
+	t.Run("Wrong Code", func(t *testing.T) {
+		code, _ := totp.Code(secret, totp.Step(time.Now())+5)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(enrolled, nil)
+
+		_, err := service.ActivateTOTP(ctx, userID, &dto.ActivateTOTPRequest{Code: code})
+		if !errors.Is(err, apperrors.ErrValidation) || apperrors.Code(err) != "invalid_mfa_code" {
+			t.Fatalf("Expected invalid_mfa_code, got %v", err)
+		}
+	})
+
+	t.Run("Not Enrolled", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(&models.UserAuthentication{UserID: userID}, nil)
+
+		_, err := service.ActivateTOTP(ctx, userID, &dto.ActivateTOTPRequest{Code: "123456"})
+		if apperrors.Code(err) != "mfa_not_enrolled" {
+			t.Fatalf("Expected mfa_not_enrolled, got %v", err)
+		}
+	})
+}
+
+func TestUserService_VerifyMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockChallengeRepo := mocks.NewMockMFAChallengeRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo,
+		WithMFA(mockMFARepo, mockChallengeRepo, testMFASecrets, "", 0),
+	)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	user := &models.User{ID: userID, Email: "mfa@example.com"}
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.MinCost)
+	secret, _ := totp.GenerateSecret()
+	enabledAt := time.Now().Add(-time.Hour)
+	userAuth := &models.UserAuthentication{
+		UserID:       userID,
+		PasswordHash: string(hashedPassword),
+		TOTPSecret:   sealTOTPSecret(t, secret),
+		MFAEnabledAt: &enabledAt,
+	}
+	challengeFor := func(token string) *models.MFAChallenge {
+		return &models.MFAChallenge{
+			ID:        uuid.New(),
+			UserID:    userID,
+			TokenHash: auth.HashOpaqueToken(token),
+			ExpiresAt: time.Now().Add(time.Minute),
+		}
+	}
+
+	t.Run("Password Starts Challenge", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+		mockChallengeRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, challenge *models.MFAChallenge) error {
+				if challenge.UserID != userID {
+					t.Errorf("Expected challenge for %s, got %s", userID, challenge.UserID)
+				}
+				if ttl := time.Until(challenge.ExpiresAt); ttl <= 4*time.Minute || ttl > defaultMFAChallengeTTL {
+					t.Errorf("Expected challenge to expire in about 5 minutes, got %v", ttl)
+				}
+				return nil
+			})
+
+		resp, err := service.AuthenticateUser(ctx, &dto.LoginRequest{Email: user.Email, Password: "correctpassword"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !resp.MFARequired || resp.MFAToken == "" {
+			t.Errorf("Expected an MFA challenge, got %+v", resp)
+		}
+		if resp.User != nil || resp.AccessToken != "" {
+			t.Errorf("Expected no user or session before MFA, got %+v", resp)
+		}
+	})
+
+	t.Run("TOTP Code", func(t *testing.T) {
+		challenge := challengeFor("challenge-token")
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		mockChallengeRepo.EXPECT().GetByHash(ctx, challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+		mockMFARepo.EXPECT().UseTOTPStep(ctx, userID, gomock.Any()).Return(nil)
+		mockChallengeRepo.EXPECT().MarkUsed(ctx, challenge.ID).Return(nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+
+		resp, err := service.VerifyMFA(ctx, &dto.MFAVerifyRequest{MFAToken: "challenge-token", Code: code})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.User == nil || resp.User.Email != user.Email {
+			t.Errorf("Expected the logged in user, got %+v", resp.User)
+		}
+	})
+
+	t.Run("Recovery Code", func(t *testing.T) {
+		challenge := challengeFor("challenge-token")
+		mockChallengeRepo.EXPECT().GetByHash(ctx, challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+		mockMFARepo.EXPECT().UseRecoveryCode(ctx, userID, auth.HashOpaqueToken("abcdefghij")).Return(nil)
+		mockChallengeRepo.EXPECT().MarkUsed(ctx, challenge.ID).Return(nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+
+		if _, err := service.VerifyMFA(ctx, &dto.MFAVerifyRequest{MFAToken: "challenge-token", Code: "ABCDE-FGHIJ"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Replayed Code", func(t *testing.T) {
+		challenge := challengeFor("challenge-token")
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		mockChallengeRepo.EXPECT().GetByHash(ctx, challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+		mockMFARepo.EXPECT().UseTOTPStep(ctx, userID, gomock.Any()).Return(repository.ErrTOTPStepUsed)
+		mockChallengeRepo.EXPECT().RecordFailedAttempt(ctx, challenge.ID).Return(1, nil)
+
+		_, err := service.VerifyMFA(ctx, &dto.MFAVerifyRequest{MFAToken: "challenge-token", Code: code})
+		if !errors.Is(err, apperrors.ErrUnauthorized) || apperrors.Code(err) != "invalid_mfa_code" {
+			t.Fatalf("Expected invalid_mfa_code, got %v", err)
+		}
+	})
+
+	t.Run("Wrong Code", func(t *testing.T) {
+		challenge := challengeFor("challenge-token")
+		code, _ := totp.Code(secret, totp.Step(time.Now())+5)
+		mockChallengeRepo.EXPECT().GetByHash(ctx, challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+		mockChallengeRepo.EXPECT().RecordFailedAttempt(ctx, challenge.ID).Return(1, nil)
+
+		_, err := service.VerifyMFA(ctx, &dto.MFAVerifyRequest{MFAToken: "challenge-token", Code: code})
+		if apperrors.Code(err) != "invalid_mfa_code" {
+			t.Fatalf("Expected invalid_mfa_code, got %v", err)
+		}
+	})
+
+	t.Run("Invalid Challenge", func(t *testing.T) {
+		past := time.Now().Add(-time.Second)
+		expired := challengeFor("expired-token")
+		expired.ExpiresAt = past
+		used := challengeFor("used-token")
+		used.UsedAt = &past
+		exhausted := challengeFor("exhausted-token")
+		exhausted.FailedAttempts = mfaChallengeMaxAttempts
+
+		mockChallengeRepo.EXPECT().GetByHash(ctx, auth.HashOpaqueToken("unknown-token")).
+			Return(nil, apperrors.NotFound("mfa_challenge_not_found", "MFA challenge not found"))
+		for _, challenge := range []*models.MFAChallenge{expired, used, exhausted} {
+			mockChallengeRepo.EXPECT().GetByHash(ctx, challenge.TokenHash).Return(challenge, nil)
+		}
+
+		for _, token := range []string{"unknown-token", "expired-token", "used-token", "exhausted-token"} {
+			_, err := service.VerifyMFA(ctx, &dto.MFAVerifyRequest{MFAToken: token, Code: "123456"})
+			if apperrors.Code(err) != "invalid_mfa_token" {
+				t.Errorf("Expected invalid_mfa_token for %s, got %v", token, err)
+			}
+		}
+	})
+}
+
+func TestUserService_RequiredMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockChallengeRepo := mocks.NewMockMFAChallengeRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo,
+		WithMFA(mockMFARepo, mockChallengeRepo, testMFASecrets, "", 0),
+		WithRequiredMFA("admin"),
+	)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	user := &models.User{ID: userID, Email: "admin@example.com"}
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.MinCost)
+	adminLevels := []*models.AccessLevel{{ID: 1, Name: "admin"}}
+	login := &dto.LoginRequest{Email: user.Email, Password: "correctpassword"}
+
+	t.Run("Login Starts Enrollment", func(t *testing.T) {
+		var stored string
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).
+			Return(&models.UserAuthentication{UserID: userID, PasswordHash: string(hashedPassword)}, nil)
+		mockAccessLevelRepo.EXPECT().GetEffectiveUserAccessLevels(ctx, userID).Return(adminLevels, nil)
+		mockMFARepo.EXPECT().SetTOTPSecret(ctx, userID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, secret string) error {
+				stored = secret
+				return nil
+			})
+		mockChallengeRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, challenge *models.MFAChallenge) error {
+				if challenge.Purpose != models.MFAChallengeEnroll {
+					t.Errorf("Expected an enroll challenge, got %q", challenge.Purpose)
+				}
+				return nil
+			})
+
+		resp, err := service.AuthenticateUser(ctx, login)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !resp.MFAEnrollmentRequired || resp.MFAToken == "" || resp.TOTPEnrollment == nil {
+			t.Fatalf("Expected an enrollment challenge, got %+v", resp)
+		}
+		if resp.User != nil || resp.AccessToken != "" {
+			t.Errorf("Expected no user or session before enrollment, got %+v", resp)
+		}
+		if opened, _ := testMFASecrets.Open(stored); opened != resp.TOTPEnrollment.Secret {
+			t.Errorf("Expected the returned secret to be stored sealed, got %q", stored)
+		}
+	})
+
+	t.Run("Not Required", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).
+			Return(&models.UserAuthentication{UserID: userID, PasswordHash: string(hashedPassword)}, nil)
+		mockAccessLevelRepo.EXPECT().GetEffectiveUserAccessLevels(ctx, userID).
+			Return([]*models.AccessLevel{{ID: 2, Name: "viewer"}}, nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+
+		resp, err := service.AuthenticateUser(ctx, login)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.MFAEnrollmentRequired || resp.User == nil {
+			t.Errorf("Expected a plain login, got %+v", resp)
+		}
+	})
+
+	secret, _ := totp.GenerateSecret()
+	enrolling := &models.UserAuthentication{UserID: userID, TOTPSecret: sealTOTPSecret(t, secret)}
+	enrollChallenge := func() *models.MFAChallenge {
+		return &models.MFAChallenge{
+			ID:        uuid.New(),
+			UserID:    userID,
+			TokenHash: auth.HashOpaqueToken("enroll-token"),
+			Purpose:   models.MFAChallengeEnroll,
+			ExpiresAt: time.Now().Add(time.Minute),
+		}
+	}
+
+	t.Run("Enrollment Completes Login", func(t *testing.T) {
+		challenge := enrollChallenge()
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		mockChallengeRepo.EXPECT().GetByHash(ctx, challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(enrolling, nil)
+		mockMFARepo.EXPECT().EnableTOTP(ctx, userID, totp.Step(time.Now()), gomock.Len(recoveryCodeCount)).Return(nil)
+		mockChallengeRepo.EXPECT().MarkUsed(ctx, challenge.ID).Return(nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+
+		resp, err := service.VerifyMFA(ctx, &dto.MFAVerifyRequest{MFAToken: "enroll-token", Code: code})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.User == nil || len(resp.RecoveryCodes) != recoveryCodeCount {
+			t.Errorf("Expected the user and their recovery codes, got %+v", resp)
+		}
+	})
+
+	t.Run("Enrollment Rejects Recovery Codes", func(t *testing.T) {
+		challenge := enrollChallenge()
+		mockChallengeRepo.EXPECT().GetByHash(ctx, challenge.TokenHash).Return(challenge, nil)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(enrolling, nil)
+		mockChallengeRepo.EXPECT().RecordFailedAttempt(ctx, challenge.ID).Return(1, nil)
+
+		_, err := service.VerifyMFA(ctx, &dto.MFAVerifyRequest{MFAToken: "enroll-token", Code: "abcde-fghij"})
+		if apperrors.Code(err) != "invalid_mfa_code" {
+			t.Fatalf("Expected invalid_mfa_code, got %v", err)
+		}
+	})
+
+	t.Run("Challenges Lapse When MFA Changes", func(t *testing.T) {
+		enabledAt := time.Now()
+		enabled := &models.UserAuthentication{UserID: userID, TOTPSecret: enrolling.TOTPSecret, MFAEnabledAt: &enabledAt}
+		enroll := enrollChallenge()
+		verify := enrollChallenge()
+		verify.TokenHash = auth.HashOpaqueToken("verify-token")
+		verify.Purpose = models.MFAChallengeVerify
+
+		mockChallengeRepo.EXPECT().GetByHash(ctx, enroll.TokenHash).Return(enroll, nil)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil).Times(2)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(enabled, nil)
+		mockChallengeRepo.EXPECT().GetByHash(ctx, verify.TokenHash).Return(verify, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(enrolling, nil)
+
+		for _, token := range []string{"enroll-token", "verify-token"} {
+			_, err := service.VerifyMFA(ctx, &dto.MFAVerifyRequest{MFAToken: token, Code: "123456"})
+			if apperrors.Code(err) != "invalid_mfa_token" {
+				t.Errorf("Expected invalid_mfa_token for %s, got %v", token, err)
+			}
+		}
+	})
+}
+
+func TestUserService_ManageMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockMFARepo := mocks.NewMockMFARepository(ctrl)
+	mockAuditRepo := mocks.NewMockAuditEventRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo,
+		WithMFA(mockMFARepo, mocks.NewMockMFAChallengeRepository(ctrl), testMFASecrets, "", 0),
+		WithRequiredMFA("admin"),
+		WithLockout(LockoutPolicy{MaxAttempts: 3, Duration: 10 * time.Minute, BaseDelay: time.Second}, mockAuditRepo),
+	)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	secret, _ := totp.GenerateSecret()
+	enabledAt := time.Now().Add(-time.Hour)
+	userAuth := &models.UserAuthentication{UserID: userID, TOTPSecret: sealTOTPSecret(t, secret), MFAEnabledAt: &enabledAt}
+	expectAudit := func(event string) {
+		mockAuditRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, auditEvent *models.AuditEvent) error {
+				if auditEvent.UserID != userID || auditEvent.Event != event {
+					t.Errorf("Expected %s event for %s, got %+v", event, userID, auditEvent)
+				}
+				return nil
+			})
+	}
+
+	t.Run("Disable", func(t *testing.T) {
+		code, _ := totp.Code(secret, totp.Step(time.Now()))
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+		mockAccessLevelRepo.EXPECT().GetEffectiveUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+		mockMFARepo.EXPECT().UseTOTPStep(ctx, userID, totp.Step(time.Now())).Return(nil)
+		mockMFARepo.EXPECT().DisableTOTP(ctx, userID).Return(nil)
+		expectAudit(models.AuditEventMFADisabled)
+
+		if err := service.DisableTOTP(ctx, userID, &dto.MFACodeRequest{Code: code}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Disable Required", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+		mockAccessLevelRepo.EXPECT().GetEffectiveUserAccessLevels(ctx, userID).
+			Return([]*models.AccessLevel{{ID: 1, Name: "admin"}}, nil)
+
+		err := service.DisableTOTP(ctx, userID, &dto.MFACodeRequest{Code: "123456"})
+		if !errors.Is(err, apperrors.ErrConflict) || apperrors.Code(err) != "mfa_required" {
+			t.Fatalf("Expected mfa_required, got %v", err)
+		}
+	})
+
+	t.Run("Disable Wrong Code Counts As Failed Login", func(t *testing.T) {
+		code, _ := totp.Code(secret, totp.Step(time.Now())+5)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+		mockAccessLevelRepo.EXPECT().GetEffectiveUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+		mockUserRepo.EXPECT().RecordFailedLogin(ctx, userID, gomock.Any()).Return(1, nil)
+		mockUserRepo.EXPECT().LockUntil(ctx, userID, gomock.Any()).Return(nil)
+
+		err := service.DisableTOTP(ctx, userID, &dto.MFACodeRequest{Code: code})
+		if !errors.Is(err, apperrors.ErrValidation) || apperrors.Code(err) != "invalid_mfa_code" {
+			t.Fatalf("Expected invalid_mfa_code, got %v", err)
+		}
+	})
+
+	t.Run("Disable Not Enabled", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(&models.UserAuthentication{UserID: userID}, nil)
+
+		err := service.DisableTOTP(ctx, userID, &dto.MFACodeRequest{Code: "123456"})
+		if apperrors.Code(err) != "mfa_not_enabled" {
+			t.Fatalf("Expected mfa_not_enabled, got %v", err)
+		}
+	})
+
+	t.Run("Regenerate Recovery Codes", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+		mockMFARepo.EXPECT().UseRecoveryCode(ctx, userID, auth.HashOpaqueToken("abcdefghij")).Return(nil)
+		mockMFARepo.EXPECT().ReplaceRecoveryCodes(ctx, userID, gomock.Len(recoveryCodeCount)).Return(nil)
+		expectAudit(models.AuditEventRecoveryCodesRegenerated)
+
+		resp, err := service.RegenerateRecoveryCodes(ctx, userID, &dto.MFACodeRequest{Code: "abcde-fghij"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp.RecoveryCodes) != recoveryCodeCount {
+			t.Errorf("Expected %d recovery codes, got %d", recoveryCodeCount, len(resp.RecoveryCodes))
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID}, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(userAuth, nil)
+		mockMFARepo.EXPECT().DisableTOTP(ctx, userID).Return(nil)
+		expectAudit(models.AuditEventMFAReset)
+
+		if err := service.ResetMFA(ctx, userID); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Reset Not Enabled", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&models.User{ID: userID}, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, userID).Return(&models.UserAuthentication{UserID: userID}, nil)
+
+		if err := service.ResetMFA(ctx, userID); apperrors.Code(err) != "mfa_not_enabled" {
+			t.Fatalf("Expected mfa_not_enabled, got %v", err)
+		}
+	})
+}
+
+func TestUserService_EmailVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockVerifyRepo := mocks.NewMockEmailVerificationTokenRepository(ctrl)
+	mockNotifier := mocks.NewMockNotifier(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo,
+		WithEmailVerification(mockVerifyRepo, mockNotifier, "https://app.example.com/verify-email", 0, false),
+	)
+	ctx := context.Background()
+
+	// expectVerificationSent expects a new token for email and returns the
+	// link that was sent
+	expectVerificationSent := func(userID uuid.UUID, email string, notifyErr error) *notify.Message {
+		sent := &notify.Message{}
+		mockVerifyRepo.EXPECT().InvalidateForUser(ctx, userID).Return(nil)
+		mockVerifyRepo.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, token *models.EmailVerificationToken) error {
+				if token.UserID != userID || token.Email != email {
+					t.Errorf("Expected token for %s <%s>, got %s <%s>", userID, email, token.UserID, token.Email)
+				}
+				if until := time.Until(token.ExpiresAt); until <= 23*time.Hour || until > defaultEmailVerificationTTL {
+					t.Errorf("Expected token to expire in 24 hours, expires in %v", until)
+				}
+				return nil
+			})
+		mockNotifier.EXPECT().Notify(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, msg notify.Message) error {
+				*sent = msg
+				return notifyErr
+			})
+		return sent
+	}
+
+	t.Run("Sent On Create", func(t *testing.T) {
+		req := &dto.CreateUserRequest{FirstName: "Vera", LastName: "Fied", Email: "vera@example.com", Password: "password123"}
+		userID := uuid.New()
+		mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(nil, apperrors.NotFound("user_not_found", "user not found"))
+		mockUserRepo.EXPECT().Create(ctx, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, user *models.User, _ *models.UserAuthentication) error {
+				user.ID = userID
+				return nil
+			})
+		sent := expectVerificationSent(userID, req.Email, nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+
+		resp, err := service.CreateUser(ctx, req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.EmailVerifiedAt != nil {
+			t.Error("Expected a new user to be unverified")
+		}
+		if sent.To != req.Email || !strings.Contains(sent.Body, "https://app.example.com/verify-email?token=") {
+			t.Errorf("Expected verification link to be sent to %s, got %+v", req.Email, sent)
+		}
+	})
+
+	t.Run("Send Failure Does Not Fail Create", func(t *testing.T) {
+		req := &dto.CreateUserRequest{FirstName: "Vera", LastName: "Fied", Email: "vera2@example.com", Password: "password123"}
+		userID := uuid.New()
+		mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(nil, apperrors.NotFound("user_not_found", "user not found"))
+		mockUserRepo.EXPECT().Create(ctx, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, user *models.User, _ *models.UserAuthentication) error {
+				user.ID = userID
+				return nil
+			})
+		expectVerificationSent(userID, req.Email, errors.New("smtp unavailable"))
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+
+		if _, err := service.CreateUser(ctx, req); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Sent On Email Change", func(t *testing.T) {
+		verifiedAt := time.Now().Add(-time.Hour)
+		user := &models.User{ID: uuid.New(), FirstName: "Vera", Email: "old@example.com", EmailVerifiedAt: &verifiedAt}
+		mockUserRepo.EXPECT().GetByID(ctx, user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().GetByEmail(ctx, "new@example.com").Return(nil, apperrors.NotFound("user_not_found", "user not found"))
+		mockUserRepo.EXPECT().Update(ctx, user).Return(nil)
+		expectVerificationSent(user.ID, "new@example.com", nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, user.ID).Return([]*models.AccessLevel{}, nil)
+
+		resp, err := service.UpdateUser(ctx, user.ID, &dto.UpdateUserRequest{Email: "new@example.com"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.EmailVerifiedAt != nil {
+			t.Error("Expected the new address to be unverified")
+		}
+	})
+
+	t.Run("Not Sent For Other Changes", func(t *testing.T) {
+		verifiedAt := time.Now().Add(-time.Hour)
+		user := &models.User{ID: uuid.New(), FirstName: "Vera", Email: "same@example.com", EmailVerifiedAt: &verifiedAt}
+		mockUserRepo.EXPECT().GetByID(ctx, user.ID).Return(user, nil)
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().Update(ctx, user).Return(nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, user.ID).Return([]*models.AccessLevel{}, nil)
+
+		resp, err := service.UpdateUser(ctx, user.ID, &dto.UpdateUserRequest{FirstName: "Veronica", Email: user.Email})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if resp.EmailVerifiedAt == nil {
+			t.Error("Expected verification to be kept")
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		token := &models.EmailVerificationToken{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			Email:     "vera@example.com",
+			TokenHash: auth.HashOpaqueToken("verify-token"),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		mockVerifyRepo.EXPECT().GetByHash(ctx, token.TokenHash).Return(token, nil)
+		mockVerifyRepo.EXPECT().MarkUsed(ctx, token.ID).Return(nil)
+		mockUserRepo.EXPECT().MarkEmailVerified(ctx, token.UserID, token.Email).Return(nil)
+
+		if err := service.VerifyEmail(ctx, &dto.VerifyEmailRequest{Token: "verify-token"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Verify Changed Email", func(t *testing.T) {
+		token := &models.EmailVerificationToken{
+			ID:        uuid.New(),
+			UserID:    uuid.New(),
+			Email:     "old@example.com",
+			TokenHash: auth.HashOpaqueToken("old-token"),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		mockVerifyRepo.EXPECT().GetByHash(ctx, token.TokenHash).Return(token, nil)
+		mockVerifyRepo.EXPECT().MarkUsed(ctx, token.ID).Return(nil)
+		mockUserRepo.EXPECT().MarkEmailVerified(ctx, token.UserID, token.Email).Return(repository.ErrEmailChanged)
+
+		err := service.VerifyEmail(ctx, &dto.VerifyEmailRequest{Token: "old-token"})
+		if apperrors.Code(err) != "invalid_verification_token" {
+			t.Fatalf("Expected invalid_verification_token, got %v", err)
+		}
+	})
+
+	t.Run("Verify Invalid Token", func(t *testing.T) {
+		past := time.Now().Add(-time.Second)
+		expired := &models.EmailVerificationToken{ID: uuid.New(), ExpiresAt: past}
+		used := &models.EmailVerificationToken{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour), UsedAt: &past}
+		mockVerifyRepo.EXPECT().GetByHash(ctx, auth.HashOpaqueToken("unknown-token")).
+			Return(nil, apperrors.NotFound("email_verification_token_not_found", "email verification token not found"))
+		mockVerifyRepo.EXPECT().GetByHash(ctx, auth.HashOpaqueToken("expired-token")).Return(expired, nil)
+		mockVerifyRepo.EXPECT().GetByHash(ctx, auth.HashOpaqueToken("used-token")).Return(used, nil)
+
+		for _, token := range []string{"unknown-token", "expired-token", "used-token"} {
+			err := service.VerifyEmail(ctx, &dto.VerifyEmailRequest{Token: token})
+			if !errors.Is(err, apperrors.ErrValidation) || apperrors.Code(err) != "invalid_verification_token" {
+				t.Errorf("Expected invalid_verification_token for %s, got %v", token, err)
+			}
+		}
+	})
+
+	t.Run("Resend", func(t *testing.T) {
+		verifiedAt := time.Now()
+		unverified := &models.User{ID: uuid.New(), Email: "unverified@example.com"}
+		verified := &models.User{ID: uuid.New(), Email: "verified@example.com", EmailVerifiedAt: &verifiedAt}
+		mockUserRepo.EXPECT().GetByEmail(ctx, unverified.Email).Return(unverified, nil)
+		expectVerificationSent(unverified.ID, unverified.Email, nil)
+		mockUserRepo.EXPECT().GetByEmail(ctx, verified.Email).Return(verified, nil)
+		mockUserRepo.EXPECT().GetByEmail(ctx, "unknown@example.com").
+			Return(nil, apperrors.NotFound("user_not_found", "user not found"))
+
+		for _, email := range []string{unverified.Email, verified.Email, "unknown@example.com"} {
+			if err := service.ResendVerificationEmail(ctx, &dto.ResendVerificationEmailRequest{Email: email}); err != nil {
+				t.Errorf("Expected no error for %s, got %v", email, err)
+			}
+		}
+	})
+}
+
+func TestUserService_AuthenticateUser_EmailVerificationRequired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo,
+		WithEmailVerification(mocks.NewMockEmailVerificationTokenRepository(ctrl), mocks.NewMockNotifier(ctrl), "", 0, true),
+	)
+	ctx := context.Background()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.MinCost)
+	login := func(user *models.User, password string) error {
+		mockUserRepo.EXPECT().GetByEmail(ctx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(ctx, user.ID).
+			Return(&models.UserAuthentication{UserID: user.ID, PasswordHash: string(hashedPassword)}, nil)
+		_, err := service.AuthenticateUser(ctx, &dto.LoginRequest{Email: user.Email, Password: password})
+		return err
+	}
+
+	t.Run("Unverified", func(t *testing.T) {
+		user := &models.User{ID: uuid.New(), Email: "unverified@example.com"}
+		if err := login(user, "correctpassword"); apperrors.Code(err) != "email_not_verified" {
+			t.Fatalf("Expected email_not_verified, got %v", err)
+		}
+	})
+
+	t.Run("Unverified Wrong Password", func(t *testing.T) {
+		// The verification state is not revealed without the password
+		user := &models.User{ID: uuid.New(), Email: "unverified@example.com"}
+		if err := login(user, "wrongpassword"); apperrors.Code(err) != "invalid_credentials" {
+			t.Fatalf("Expected invalid_credentials, got %v", err)
+		}
+	})
+
+	t.Run("Verified", func(t *testing.T) {
+		verifiedAt := time.Now()
+		user := &models.User{ID: uuid.New(), Email: "verified@example.com", EmailVerifiedAt: &verifiedAt}
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(ctx, user.ID).Return([]*models.AccessLevel{}, nil)
+		if err := login(user, "correctpassword"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	})
+}
+
+func TestUserService_Sessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokenManager, err := auth.NewTokenManager(auth.TokenConfig{Secret: "test-secret"})
+	if err != nil {
+		t.Fatalf("Failed to create token manager: %v", err)
+	}
+
+	mockUserRepo := mocks.NewMockUserRepository(ctrl)
+	mockAccessLevelRepo := mocks.NewMockAccessLevelRepository(ctrl)
+	mockRefreshRepo := mocks.NewMockRefreshTokenRepository(ctrl)
+	sessions := repository.NewMemorySessionRepository()
+	service := NewUserService(mockUserRepo, mockAccessLevelRepo,
+		WithTokenIssuer(tokenManager),
+		WithRefreshTokens(mockRefreshRepo, time.Hour),
+		WithSessions(sessions, time.Hour, 24*time.Hour),
+	)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	user := &models.User{ID: userID, Email: "john@example.com"}
+
+	// addSession stores a session of the user last seen at lastSeen
+	addSession := func(t *testing.T, userID uuid.UUID, lastSeen, expiresAt time.Time) uuid.UUID {
+		t.Helper()
+		session := &models.Session{UserID: userID, LastSeenAt: lastSeen, ExpiresAt: expiresAt}
+		if err := sessions.Create(ctx, session); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		return session.ID
+	}
+
+	t.Run("LoginCreatesSession", func(t *testing.T) {
+		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		loginCtx := auth.WithClientIP(auth.WithUserAgent(ctx, "test-agent/1.0"), "203.0.113.7")
+
+		mockUserRepo.EXPECT().GetByEmail(loginCtx, user.Email).Return(user, nil)
+		mockUserRepo.EXPECT().GetUserAuthentication(loginCtx, userID).
+			Return(&models.UserAuthentication{UserID: userID, PasswordHash: string(hashedPassword)}, nil)
+		mockAccessLevelRepo.EXPECT().GetUserAccessLevels(loginCtx, userID).Return([]*models.AccessLevel{}, nil)
+		mockAccessLevelRepo.EXPECT().GetEffectiveUserAccessLevels(loginCtx, userID).Return([]*models.AccessLevel{}, nil)
+		var stored *models.RefreshToken
+		mockRefreshRepo.EXPECT().Create(loginCtx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, token *models.RefreshToken) error {
+				stored = token
+				return nil
+			})
+
+		resp, err := service.AuthenticateUser(loginCtx, &dto.LoginRequest{Email: user.Email, Password: "password123"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		sessionID, err := uuid.Parse(resp.SessionID)
+		if err != nil {
+			t.Fatalf("Expected a session ID in the login response, got %q", resp.SessionID)
+		}
+		if stored.FamilyID != sessionID {
+			t.Errorf("Expected refresh token family %s to match the session, got %s", sessionID, stored.FamilyID)
+		}
+		claims, err := tokenManager.VerifyAccessToken(resp.AccessToken)
+		if err != nil {
+			t.Fatalf("Expected valid access token, got %v", err)
+		}
+		if claims.SessionID != resp.SessionID {
+			t.Errorf("Expected sid claim %s, got %s", resp.SessionID, claims.SessionID)
+		}
+
+		session, err := sessions.GetByID(ctx, sessionID)
+		if err != nil {
+			t.Fatalf("Expected session to be stored, got %v", err)
+		}
+		if session.UserAgent == nil || *session.UserAgent != "test-agent/1.0" ||
+			session.IPAddress == nil || *session.IPAddress != "203.0.113.7" {
+			t.Errorf("Expected user agent and IP to be recorded, got %+v", session)
+		}
+		if time.Until(session.ExpiresAt) > 24*time.Hour || time.Until(session.ExpiresAt) < 23*time.Hour {
+			t.Errorf("Expected absolute expiry in 24h, got %v", session.ExpiresAt)
+		}
+	})
+
+	t.Run("ValidateSession", func(t *testing.T) {
+		now := time.Now()
+		active := addSession(t, userID, now.Add(-10*time.Minute), now.Add(time.Hour))
+		idle := addSession(t, userID, now.Add(-2*time.Hour), now.Add(time.Hour))
+		pastExpiry := addSession(t, userID, now, now.Add(-time.Minute))
+		revoked := addSession(t, userID, now, now.Add(time.Hour))
+		if err := sessions.Revoke(ctx, revoked); err != nil {
+			t.Fatalf("Failed to revoke session: %v", err)
+		}
+
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		if err := service.ValidateSession(ctx, userID, active); err != nil {
+			t.Errorf("Expected active session to be valid, got %v", err)
+		}
+		session, _ := sessions.GetByID(ctx, active)
+		if time.Since(session.LastSeenAt) > time.Minute {
+			t.Errorf("Expected last seen to be updated, got %v", session.LastSeenAt)
+		}
+
+		tests := []struct {
+			name      string
+			userID    uuid.UUID
+			sessionID uuid.UUID
+			code      string
+		}{
+			{"Idle", userID, idle, "session_expired"},
+			{"Past Absolute Expiry", userID, pastExpiry, "session_expired"},
+			{"Revoked", userID, revoked, "session_revoked"},
+			{"Unknown", userID, uuid.New(), "session_revoked"},
+			{"Other User", uuid.New(), active, "session_revoked"},
+		}
+		for _, tt := range tests {
+			err := service.ValidateSession(ctx, tt.userID, tt.sessionID)
+			if apperrors.Code(err) != tt.code || !errors.Is(err, apperrors.ErrUnauthorized) {
+				t.Errorf("%s: expected unauthorized %s, got %v", tt.name, tt.code, err)
+			}
+		}
+
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(nil, apperrors.NotFound("user_not_found", "user not found"))
+		if err := service.ValidateSession(ctx, userID, active); apperrors.Code(err) != "session_revoked" {
+			t.Errorf("Deleted User: expected session_revoked, got %v", err)
+		}
+	})
+
+	t.Run("RefreshChecksSession", func(t *testing.T) {
+		sessionID := addSession(t, userID, time.Now(), time.Now().Add(time.Hour))
+		current := &models.RefreshToken{
+			ID:        uuid.New(),
+			UserID:    userID,
+			FamilyID:  sessionID,
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+
+		mockRefreshRepo.EXPECT().GetByHash(ctx, gomock.Any()).Return(current, nil).Times(2)
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil).Times(2)
+		mockAccessLevelRepo.EXPECT().GetEffectiveUserAccessLevels(ctx, userID).Return([]*models.AccessLevel{}, nil)
+		mockRefreshRepo.EXPECT().Rotate(ctx, current, gomock.Any()).Return(nil)
+
+		resp, err := service.RefreshTokens(ctx, &dto.RefreshTokenRequest{RefreshToken: "current-token"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		claims, _ := tokenManager.VerifyAccessToken(resp.AccessToken)
+		if claims == nil || claims.SessionID != sessionID.String() {
+			t.Errorf("Expected refreshed token to keep session %s, got %+v", sessionID, claims)
+		}
+
+		if err := sessions.Revoke(ctx, sessionID); err != nil {
+			t.Fatalf("Failed to revoke session: %v", err)
+		}
+		_, err = service.RefreshTokens(ctx, &dto.RefreshTokenRequest{RefreshToken: "current-token"})
+		if apperrors.Code(err) != "session_revoked" {
+			t.Errorf("Expected session_revoked after revoking the session, got %v", err)
+		}
+	})
+
+	t.Run("ListSessions", func(t *testing.T) {
+		listUserID := uuid.New()
+		now := time.Now()
+		older := addSession(t, listUserID, now.Add(-30*time.Minute), now.Add(time.Hour))
+		current := addSession(t, listUserID, now, now.Add(time.Hour))
+		addSession(t, listUserID, now.Add(-2*time.Hour), now.Add(time.Hour))
+
+		listCtx := auth.WithPrincipal(ctx, &auth.Principal{UserID: listUserID, SessionID: current.String()})
+		mockUserRepo.EXPECT().GetByID(listCtx, listUserID).Return(&models.User{ID: listUserID}, nil)
+
+		resp, err := service.ListSessions(listCtx, listUserID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resp) != 2 || resp[0].ID != current || resp[1].ID != older {
+			t.Fatalf("Expected current and older session without the idle one, got %+v", resp)
+		}
+		if !resp[0].Current || resp[1].Current {
+			t.Errorf("Expected only the first session to be current, got %+v", resp)
+		}
+	})
+
+	t.Run("RevokeSession", func(t *testing.T) {
+		sessionID := addSession(t, userID, time.Now(), time.Now().Add(time.Hour))
+
+		err := service.RevokeSession(ctx, uuid.New(), sessionID)
+		if apperrors.Code(err) != "session_not_found" {
+			t.Errorf("Expected session_not_found for another user's session, got %v", err)
+		}
+
+		mockRefreshRepo.EXPECT().RevokeFamily(ctx, sessionID).Return(nil)
+		if err := service.RevokeSession(ctx, userID, sessionID); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		session, _ := sessions.GetByID(ctx, sessionID)
+		if session.RevokedAt == nil {
+			t.Error("Expected session to be revoked")
+		}
+	})
+
+	t.Run("RevokeAllSessions", func(t *testing.T) {
+		sessionID := addSession(t, userID, time.Now(), time.Now().Add(time.Hour))
+
+		mockUserRepo.EXPECT().GetByID(ctx, userID).Return(user, nil)
+		mockRefreshRepo.EXPECT().RevokeAllForUser(ctx, userID).Return(nil)
+
+		if err := service.RevokeAllSessions(ctx, userID); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := service.ValidateSession(ctx, userID, sessionID); apperrors.Code(err) != "session_revoked" {
+			t.Errorf("Expected session_revoked after logging out everywhere, got %v", err)
+		}
+	})
+}
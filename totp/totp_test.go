@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 test key from RFC 6238 appendix B
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists eight digit codes; six digit codes are their last six digits
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if code != tt.expected {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, code, tt.expected)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	code, _ := Code(rfcSecret, step)
+
+	if got, ok := Validate(rfcSecret, code, now); !ok || got != step {
+		t.Errorf("Expected current code to validate at step %d, got %d, %v", step, got, ok)
+	}
+	if got, ok := Validate(rfcSecret, code, now.Add(Period)); !ok || got != step {
+		t.Errorf("Expected previous step to be accepted for clock drift, got %d, %v", got, ok)
+	}
+	if _, ok := Validate(rfcSecret, code, now.Add(3*Period)); ok {
+		t.Error("Expected code from three steps ago to be rejected")
+	}
+	if _, ok := Validate(rfcSecret, "000000", now); ok && code != "000000" {
+		t.Error("Expected wrong code to be rejected")
+	}
+	if _, ok := Validate(rfcSecret, "12345", now); ok {
+		t.Error("Expected short code to be rejected")
+	}
+	if _, ok := Validate("not base32!", code, now); ok {
+		t.Error("Expected invalid secret to be rejected")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("Expected 32 base32 characters, got %d", len(secret))
+	}
+	other, _ := GenerateSecret()
+	if secret == other {
+		t.Error("Expected different secrets on each call")
+	}
+	if _, err := Code(secret, 1); err != nil {
+		t.Errorf("Expected generated secret to be usable, got %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("user_service", "john@example.com", "JBSWY3DPEHPK3PXP")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/user_service:john@example.com?") {
+		t.Errorf("Unexpected label in %s", uri)
+	}
+	for _, param := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=user_service", "digits=6", "period=30", "algorithm=SHA1"} {
+		if !strings.Contains(uri, param) {
+			t.Errorf("Expected %s in %s", param, uri)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package validation checks request DTOs against their `validate` struct tags.
+//
+// The supported rules are the ones the DTOs use:
+//
+//	required   the field must be present; strings must not be blank
+//	omitempty  skip the remaining rules when the field is empty
+//	min=N      strings need at least N characters, slices N items, numbers a value of N
+//	max=N      the upper bound counterpart of min
+//	email      the field must be a plain email address
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wabtcdi/user_service/apperrors"
+)
+
+// Struct validates the struct v points to and returns an apperrors validation
+// error listing every failing field, or nil when v is valid. Fields are named
+// after their JSON keys and each reports only its first failing rule. Tags the
+// package cannot apply are reported as plain errors, so the request fails as
+// an internal error instead of going unchecked.
+func Struct(v any) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return fmt.Errorf("validation: expected a struct, got %T", v)
+	}
+
+	var fields []apperrors.FieldError
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		if err := checkTag(jsonName(field), field.Type, tag); err != nil {
+			return err
+		}
+		if fieldErr := checkField(jsonName(field), value.Field(i), tag); fieldErr != nil {
+			fields = append(fields, *fieldErr)
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperrors.InvalidFields(fields...)
+	}
+	return nil
+}
+
+// checkTag reports rules that cannot be applied to a field of type fieldType.
+// Every rule is checked before any is applied, so a bad rule is found even
+// when the field's value would stop at an earlier one.
+func checkTag(name string, fieldType reflect.Type, tag string) error {
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		ruleName, param, _ := strings.Cut(rule, "=")
+		switch ruleName {
+		case "omitempty", "required":
+		case "min", "max":
+			if _, err := strconv.Atoi(param); err != nil {
+				return fmt.Errorf("validation: invalid %s bound %q on field %s", ruleName, param, name)
+			}
+			switch fieldType.Kind() {
+			case reflect.String, reflect.Slice, reflect.Map,
+				reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			default:
+				return fmt.Errorf("validation: %s is not supported on field %s of kind %s", ruleName, name, fieldType.Kind())
+			}
+		case "email":
+			if fieldType.Kind() != reflect.String {
+				return fmt.Errorf("validation: email is not supported on field %s of kind %s", name, fieldType.Kind())
+			}
+		default:
+			return fmt.Errorf("validation: unknown rule %q on field %s", ruleName, name)
+		}
+	}
+	return nil
+}
+
+// checkField applies the rules of a tag that checkTag has accepted
+func checkField(name string, value reflect.Value, tag string) *apperrors.FieldError {
+	for _, rule := range strings.Split(tag, ",") {
+		ruleName, param, _ := strings.Cut(rule, "=")
+		switch ruleName {
+		case "omitempty":
+			if isEmpty(value) {
+				return nil
+			}
+		case "required":
+			if isEmpty(value) {
+				return fieldError(name, ruleName, "%s is required", name)
+			}
+		case "min", "max":
+			if value.Kind() == reflect.Ptr && value.IsNil() {
+				continue
+			}
+			if fieldErr := checkBound(name, ruleName, param, reflect.Indirect(value)); fieldErr != nil {
+				return fieldErr
+			}
+		case "email":
+			if value.Kind() == reflect.Ptr && value.IsNil() {
+				continue
+			}
+			if !isEmail(reflect.Indirect(value).String()) {
+				return fieldError(name, ruleName, "%s must be a valid email address", name)
+			}
+		}
+	}
+	return nil
+}
+
+func checkBound(name, rule, param string, value reflect.Value) *apperrors.FieldError {
+	bound, _ := strconv.Atoi(param)
+
+	var size int
+	var unit string
+	switch value.Kind() {
+	case reflect.String:
+		size, unit = utf8.RuneCountInString(value.String()), " character"
+	case reflect.Slice, reflect.Map:
+		size, unit = value.Len(), " item"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = int(value.Int())
+	}
+
+	if unit != "" && bound != 1 {
+		unit += "s"
+	}
+
+	if rule == "min" && size < bound {
+		return fieldError(name, rule, "%s must be at least %d%s", name, bound, unit)
+	}
+	if rule == "max" && size > bound {
+		return fieldError(name, rule, "%s must be at most %d%s", name, bound, unit)
+	}
+	return nil
+}
+
+// isEmpty reports whether value holds nothing. Blank strings count as empty,
+// while empty but non-nil slices do not, so "[]" can be sent deliberately.
+func isEmpty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	case reflect.Slice, reflect.Map:
+		return value.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	default:
+		return value.IsZero()
+	}
+}
+
+func isEmail(s string) bool {
+	address, err := mail.ParseAddress(s)
+	return err == nil && address.Address == s
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func fieldError(field, rule, format string, args ...any) *apperrors.FieldError {
+	return &apperrors.FieldError{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
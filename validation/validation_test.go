@@ -0,0 +1,274 @@
+package validation
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/wabtcdi/user_service/apperrors"
+	"github.com/wabtcdi/user_service/dto"
+)
+
+func TestStruct_CreateUserRequest(t *testing.T) {
+	valid := dto.CreateUserRequest{
+		FirstName: "John",
+		LastName:  "Doe",
+		Email:     "john.doe@example.com",
+		Password:  "password123",
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(req *dto.CreateUserRequest)
+		expected []apperrors.FieldError
+	}{
+		{
+			name:   "Valid",
+			modify: func(req *dto.CreateUserRequest) {},
+		},
+		{
+			name:   "Missing First Name",
+			modify: func(req *dto.CreateUserRequest) { req.FirstName = "" },
+			expected: []apperrors.FieldError{
+				{Field: "first_name", Rule: "required", Message: "first_name is required"},
+			},
+		},
+		{
+			name:   "Blank Last Name",
+			modify: func(req *dto.CreateUserRequest) { req.LastName = "   " },
+			expected: []apperrors.FieldError{
+				{Field: "last_name", Rule: "required", Message: "last_name is required"},
+			},
+		},
+		{
+			name:   "Invalid Email",
+			modify: func(req *dto.CreateUserRequest) { req.Email = "John <john@example.com>" },
+			expected: []apperrors.FieldError{
+				{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+			},
+		},
+		{
+			name: "Several Fields",
+			modify: func(req *dto.CreateUserRequest) {
+				req.FirstName = strings.Repeat("a", 51)
+				req.PhoneNumber = strings.Repeat("5", 21)
+				req.Password = ""
+			},
+			expected: []apperrors.FieldError{
+				{Field: "first_name", Rule: "max", Message: "first_name must be at most 50 characters"},
+				{Field: "phone_number", Rule: "max", Message: "phone_number must be at most 20 characters"},
+				{Field: "password", Rule: "required", Message: "password is required"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+
+			err := Struct(&req)
+			assertFieldErrors(t, err, tt.expected)
+		})
+	}
+}
+
+func TestStruct_OptionalFields(t *testing.T) {
+	empty := ""
+	long := strings.Repeat("a", 51)
+	name := "editor"
+
+	tests := []struct {
+		name     string
+		req      any
+		expected []apperrors.FieldError
+	}{
+		{
+			name: "Empty Update Is Valid",
+			req:  &dto.UpdateUserRequest{},
+		},
+		{
+			name: "Update With Invalid Email",
+			req:  &dto.UpdateUserRequest{Email: "not-an-email"},
+			expected: []apperrors.FieldError{
+				{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+			},
+		},
+		{
+			name: "Nil Pointer Is Skipped",
+			req:  &dto.UpdateAccessLevelRequest{},
+		},
+		{
+			name: "Pointer Value Is Checked",
+			req:  &dto.UpdateAccessLevelRequest{Name: &long},
+			expected: []apperrors.FieldError{
+				{Field: "name", Rule: "max", Message: "name must be at most 50 characters"},
+			},
+		},
+		{
+			name: "Pointer To Empty String Fails Min",
+			req:  &dto.UpdateAccessLevelRequest{Name: &empty},
+			expected: []apperrors.FieldError{
+				{Field: "name", Rule: "min", Message: "name must be at least 1 character"},
+			},
+		},
+		{
+			name: "Pointer To Valid Name",
+			req:  &dto.UpdateAccessLevelRequest{Name: &name},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertFieldErrors(t, Struct(tt.req), tt.expected)
+		})
+	}
+}
+
+func TestStruct_Slices(t *testing.T) {
+	// A missing list fails required, but an explicit empty list is allowed
+	assertFieldErrors(t, Struct(&dto.ReplaceAccessLevelsRequest{}), []apperrors.FieldError{
+		{Field: "access_level_ids", Rule: "required", Message: "access_level_ids is required"},
+	})
+	assertFieldErrors(t, Struct(&dto.ReplaceAccessLevelsRequest{AccessLevelIDs: []int{}}), nil)
+
+	assertFieldErrors(t, Struct(&dto.AssignAccessLevelRequest{AccessLevelIDs: []int{}}), []apperrors.FieldError{
+		{Field: "access_level_ids", Rule: "min", Message: "access_level_ids must be at least 1 item"},
+	})
+}
+
+func TestStruct_InvalidTags(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"Unknown Rule", &struct {
+			Name string `json:"name" validate:"uuid"`
+		}{}},
+		{"Unknown Rule After A Failing One", &struct {
+			Name string `json:"name" validate:"required,uuid"`
+		}{}},
+		{"Invalid Bound", &struct {
+			Name string `json:"name" validate:"min=one"`
+		}{Name: "x"}},
+		{"Unsupported Kind", &struct {
+			Enabled bool `json:"enabled" validate:"max=1"`
+		}{}},
+		{"Not A Struct", "name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Struct(tt.v)
+			if err == nil || errors.Is(err, apperrors.ErrValidation) {
+				t.Errorf("Expected an internal error, got %v", err)
+			}
+		})
+	}
+}
+
+// Every DTO with validate tags must only use rules this package supports, so
+// none of them can fail with an internal error at request time
+func TestStruct_AllRequestTypes(t *testing.T) {
+	requests := map[string]any{
+		"CreateUserRequest":              &dto.CreateUserRequest{},
+		"UpdateUserRequest":              &dto.UpdateUserRequest{},
+		"LoginRequest":                   &dto.LoginRequest{},
+		"MFAVerifyRequest":               &dto.MFAVerifyRequest{},
+		"ActivateTOTPRequest":            &dto.ActivateTOTPRequest{},
+		"MFACodeRequest":                 &dto.MFACodeRequest{},
+		"RefreshTokenRequest":            &dto.RefreshTokenRequest{},
+		"ChangePasswordRequest":          &dto.ChangePasswordRequest{},
+		"PasswordResetRequest":           &dto.PasswordResetRequest{},
+		"PasswordResetConfirmRequest":    &dto.PasswordResetConfirmRequest{},
+		"VerifyEmailRequest":             &dto.VerifyEmailRequest{},
+		"ResendVerificationEmailRequest": &dto.ResendVerificationEmailRequest{},
+		"AssignAccessLevelRequest":       &dto.AssignAccessLevelRequest{},
+		"ReplaceAccessLevelsRequest":     &dto.ReplaceAccessLevelsRequest{},
+		"CreateAccessLevelRequest":       &dto.CreateAccessLevelRequest{},
+		"UpdateAccessLevelRequest":       &dto.UpdateAccessLevelRequest{},
+		"AssignPermissionsRequest":       &dto.AssignPermissionsRequest{},
+	}
+
+	for _, name := range taggedDTOTypes(t) {
+		t.Run(name, func(t *testing.T) {
+			req, ok := requests[name]
+			if !ok {
+				t.Fatalf("dto.%s has validate tags; add it to this test", name)
+			}
+			if err := Struct(req); err != nil && !errors.Is(err, apperrors.ErrValidation) {
+				t.Errorf("Expected nil or a validation error, got %v", err)
+			}
+		})
+	}
+}
+
+// taggedDTOTypes returns the names of the dto struct types that have at least
+// one validate tag
+func taggedDTOTypes(t *testing.T) []string {
+	t.Helper()
+	packages, err := parser.ParseDir(token.NewFileSet(), "../dto", nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse the dto package: %v", err)
+	}
+
+	var names []string
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(node ast.Node) bool {
+				spec, ok := node.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				if structType, ok := spec.Type.(*ast.StructType); ok && hasValidateTag(structType) {
+					names = append(names, spec.Name.Name)
+				}
+				return false
+			})
+		}
+	}
+	if len(names) == 0 {
+		t.Fatal("Expected the dto package to have validated types")
+	}
+	return names
+}
+
+func hasValidateTag(structType *ast.StructType) bool {
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err == nil && strings.Contains(tag, `validate:"`) {
+			return true
+		}
+	}
+	return false
+}
+
+func assertFieldErrors(t *testing.T, err error, expected []apperrors.FieldError) {
+	t.Helper()
+	if len(expected) == 0 {
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return
+	}
+
+	if !errors.Is(err, apperrors.ErrValidation) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	fields := apperrors.Fields(err)
+	if len(fields) != len(expected) {
+		t.Fatalf("Expected %d field errors, got %v", len(expected), fields)
+	}
+	for i := range expected {
+		if fields[i] != expected[i] {
+			t.Errorf("Field error %d: expected %+v, got %+v", i, expected[i], fields[i])
+		}
+	}
+}